@@ -9,12 +9,18 @@ import (
 func main() {
 	var opt serve.Option
 
-	// 支持双模式启动
+	// 支持多模式启动
 	// 默认启用Web服务模式
 	opt = serve.WithWebDefault()
 	if len(os.Args) > 1 {
-		// 有命令行参数：启用命令执行模式
-		opt = serve.WithCommandDefault()
+		switch os.Args[1] {
+		case "dns":
+			// 仅启用DNS拦截解析服务
+			opt = serve.WithDNSDefault()
+		default:
+			// 有命令行参数：启用命令执行模式
+			opt = serve.WithCommandDefault()
+		}
 	}
 
 	// 统一通过serve.Run()运行
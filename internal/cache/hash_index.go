@@ -0,0 +1,9 @@
+package cache
+
+import (
+	"godex/pkg/hashprefix"
+	"sync/atomic"
+)
+
+// PhishingSitesHashIndex 哈希前缀索引，每次加载缓存后整体重建替换
+var PhishingSitesHashIndex = &atomic.Pointer[hashprefix.Index]{}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"godex/internal/entity"
+	"godex/pkg/metrics"
+	"time"
+)
+
+func init() {
+	metrics.MustRegister(cacheCollector{})
+}
+
+var (
+	cacheEntriesDesc = prometheus.NewDesc(metrics.Namespace+"_phishing_sites_cache_entries",
+		"Entries in the phishing sites cache by source.", []string{"source"}, nil)
+	snapshotVersionDesc = prometheus.NewDesc(metrics.Namespace+"_phishing_sites_snapshot_version",
+		"Current cache snapshot version, 0 before the first successful load.", nil, nil)
+	snapshotAgeDesc = prometheus.NewDesc(metrics.Namespace+"_phishing_sites_snapshot_age_seconds",
+		"Seconds since the last successful cache load.", nil, nil)
+	dataAgeDesc = prometheus.NewDesc(metrics.Namespace+"_phishing_sites_data_age_seconds",
+		"Seconds since the remote data file was last modified.", nil, nil)
+)
+
+// cacheCollector 采集时统计缓存条数及快照新鲜度，尚未发生的时间不输出对应指标
+type cacheCollector struct{}
+
+// Describe 实现prometheus.Collector
+func (cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheEntriesDesc
+	ch <- snapshotVersionDesc
+	ch <- snapshotAgeDesc
+	ch <- dataAgeDesc
+}
+
+// Collect 实现prometheus.Collector
+func (cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	sources := map[string]int{}
+	PhishingSitesCache.Range(func(key, value any) bool {
+		sources[value.(*entity.PhishingSite).Source]++
+		return true
+	})
+	for source, count := range sources {
+		ch <- prometheus.MustNewConstMetric(cacheEntriesDesc, prometheus.GaugeValue, float64(count), source)
+	}
+
+	snapshot := PhishingSitesStats.Snapshot()
+	ch <- prometheus.MustNewConstMetric(snapshotVersionDesc, prometheus.GaugeValue, float64(snapshot.Version))
+	if !snapshot.LastLoadSuccessAt.IsZero() {
+		ch <- prometheus.MustNewConstMetric(snapshotAgeDesc, prometheus.GaugeValue, time.Since(snapshot.LastLoadSuccessAt).Seconds())
+	}
+	if !snapshot.DataUpdatedAt.IsZero() {
+		ch <- prometheus.MustNewConstMetric(dataAgeDesc, prometheus.GaugeValue, time.Since(snapshot.DataUpdatedAt).Seconds())
+	}
+}
@@ -0,0 +1,8 @@
+package cache
+
+import (
+	"godex/pkg/listsync"
+)
+
+// PhishingSitesHistory 快照历史，每次成功加载缓存后提交，用于客户端增量同步
+var PhishingSitesHistory = listsync.NewHistory()
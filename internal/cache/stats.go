@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRecentDurations 保留最近加载耗时的条数
+const maxRecentDurations = 10
+
+// PhishingSitesStats 缓存加载/导入统计
+var PhishingSitesStats = &LoadStats{}
+
+// LoadStats 记录缓存快照版本、加载与导入情况，线程安全
+type LoadStats struct {
+	mu sync.RWMutex
+
+	version          uint64
+	loadCount        int
+	loadFailCount    int
+	lastLoadAt       time.Time
+	lastLoadSuccess  time.Time
+	lastLoadDuration time.Duration
+	recentDurations  []time.Duration
+	lastLoadSources  map[string]int
+
+	lastImportAt       time.Time
+	lastImportDuration time.Duration
+	dataUpdatedAt      time.Time
+
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// LoadStatsSnapshot 统计数据快照
+type LoadStatsSnapshot struct {
+	Version             uint64
+	LoadCount           int
+	LoadFailCount       int
+	LastLoadAt          time.Time
+	LastLoadSuccessAt   time.Time
+	LastLoadDuration    time.Duration
+	RecentLoadDurations []time.Duration
+	LastLoadSources     map[string]int
+	LastImportAt        time.Time
+	LastImportDuration  time.Duration
+	DataUpdatedAt       time.Time
+	LastError           string
+	LastErrorAt         time.Time
+}
+
+// RecordLoad 记录一次加载结果，成功时快照版本号加一
+// sources为本次加载中各数据来源写入的条数(部分失败时也应传入已写入的部分)
+func (s *LoadStats) RecordLoad(start time.Time, sources map[string]int, err error) {
+	duration := time.Since(start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loadCount++
+	s.lastLoadAt = start
+	s.lastLoadDuration = duration
+	s.recentDurations = append(s.recentDurations, duration)
+	if len(s.recentDurations) > maxRecentDurations {
+		s.recentDurations = s.recentDurations[len(s.recentDurations)-maxRecentDurations:]
+	}
+	s.lastLoadSources = sources
+
+	if err != nil {
+		s.loadFailCount++
+		s.setError(err)
+		return
+	}
+	s.version++
+	s.lastLoadSuccess = start.Add(duration)
+}
+
+// RecordImport 记录一次导入结果
+func (s *LoadStats) RecordImport(start time.Time, err error) {
+	duration := time.Since(start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.setError(err)
+		return
+	}
+	s.lastImportAt = start.Add(duration)
+	s.lastImportDuration = duration
+}
+
+// RecordDataUpdatedAt 记录远端数据文件的最后修改时间(即最近一次导入的落盘时间)
+func (s *LoadStats) RecordDataUpdatedAt(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dataUpdatedAt = t
+}
+
+// Version 当前快照版本号，0表示尚未成功加载
+func (s *LoadStats) Version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version
+}
+
+// Snapshot 获取统计快照
+func (s *LoadStats) Snapshot() LoadStatsSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sources := make(map[string]int, len(s.lastLoadSources))
+	for source, count := range s.lastLoadSources {
+		sources[source] = count
+	}
+	return LoadStatsSnapshot{
+		Version:             s.version,
+		LoadCount:           s.loadCount,
+		LoadFailCount:       s.loadFailCount,
+		LastLoadAt:          s.lastLoadAt,
+		LastLoadSuccessAt:   s.lastLoadSuccess,
+		LastLoadDuration:    s.lastLoadDuration,
+		RecentLoadDurations: append([]time.Duration(nil), s.recentDurations...),
+		LastLoadSources:     sources,
+		LastImportAt:        s.lastImportAt,
+		LastImportDuration:  s.lastImportDuration,
+		DataUpdatedAt:       s.dataUpdatedAt,
+		LastError:           s.lastError,
+		LastErrorAt:         s.lastErrorAt,
+	}
+}
+
+// setError 记录最近一次错误，调用方需持有写锁
+func (s *LoadStats) setError(err error) {
+	s.lastError = err.Error()
+	s.lastErrorAt = time.Now()
+}
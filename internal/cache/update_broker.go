@@ -0,0 +1,8 @@
+package cache
+
+import (
+	"godex/pkg/listsync"
+)
+
+// PhishingSitesUpdates 版本更新广播，每次提交快照后发布，用于向已连接的客户端推送
+var PhishingSitesUpdates = listsync.NewBroker()
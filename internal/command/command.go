@@ -20,6 +20,14 @@ func Execute() error {
 func RegisterCommands() {
 	// 注册导入命令
 	rootCmd.AddCommand(importPhishingSitesCmd)
+	// 注册缓存统计命令
+	rootCmd.AddCommand(phishingSitesStatsCmd)
+	// 注册导出命令
+	rootCmd.AddCommand(exportPhishingSitesCmd)
+	// 注册本地上报接收命令
+	rootCmd.AddCommand(reportReceiverCmd)
+	// 注册定时任务状态命令
+	rootCmd.AddCommand(tasksCmd)
 
 	// 后续可以在这里注册其他命令
 	// rootCmd.AddCommand(otherCmd)
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+	"godex/internal/service"
+	"godex/pkg/blocklist"
+	"godex/pkg/logger"
+	"os"
+)
+
+var exportPhishingSitesCmd = &cobra.Command{
+	Use:   "exportPhishingSites",
+	Short: "Export phishing sites as hosts/dnsmasq/unbound/rpz/adblock/plain blocklist",
+	Long:  `Load phishing sites into cache and export them in a DNS resolver or ad-blocker format, honoring the allow list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		formatFlag, _ := cmd.Flags().GetString("format")
+		address, _ := cmd.Flags().GetString("address")
+		output, _ := cmd.Flags().GetString("output")
+
+		format, err := blocklist.ParseFormat(formatFlag)
+		if err != nil {
+			logger.Fatalf("ExportPhishingSites command failed: %v", err)
+		}
+
+		// 创建服务实例，命令模式下缓存为空，先执行一次加载
+		phishingSitesService := service.NewPhishingSitesService()
+		if err := phishingSitesService.LoadPhishingSites2Cache(context.Background()); err != nil {
+			logger.Fatalf("LoadPhishingSites2Cache failed: %v", err)
+		}
+
+		// 默认输出到标准输出
+		out := os.Stdout
+		if output != "" && output != "-" {
+			file, err := os.Create(output)
+			if err != nil {
+				logger.Fatalf("ExportPhishingSites create output file failed: %v", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		count, err := phishingSitesService.ExportPhishingSites(context.Background(), out, format, address)
+		if err != nil {
+			logger.Fatalf("ExportPhishingSites command failed: %v", err)
+		}
+		logger.Infof("ExportPhishingSites command completed successfully, %d domains exported.", count)
+	},
+}
+
+func init() {
+	exportPhishingSitesCmd.Flags().StringP("format", "f", string(blocklist.FormatPlain), "export format: hosts, dnsmasq, unbound, rpz, adblock, plain")
+	exportPhishingSitesCmd.Flags().StringP("address", "a", "", "sinkhole address, empty means 0.0.0.0 for hosts and NXDOMAIN for dnsmasq/unbound")
+	exportPhishingSitesCmd.Flags().StringP("output", "o", "", "output file, empty or - for stdout")
+}
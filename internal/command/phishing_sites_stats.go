@@ -0,0 +1,34 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"godex/internal/service"
+	"godex/pkg/logger"
+)
+
+var phishingSitesStatsCmd = &cobra.Command{
+	Use:   "phishingSitesStats",
+	Short: "Load phishing sites into cache and print cache statistics",
+	Long:  `Load phishing sites into cache and print cache statistics, same as /browserext/phishing_sites/stats`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 创建服务实例
+		phishingSitesService := service.NewPhishingSitesService()
+		// 命令模式下缓存为空，先执行一次加载，加载失败也输出统计(包含错误信息)
+		if err := phishingSitesService.LoadPhishingSites2Cache(context.Background()); err != nil {
+			logger.Errorf("LoadPhishingSites2Cache failed: %v", err)
+		}
+
+		stats, err := phishingSitesService.Stats(context.Background())
+		if err != nil {
+			logger.Fatalf("PhishingSitesStats command failed: %v", err)
+		}
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			logger.Fatalf("PhishingSitesStats marshal failed: %v", err)
+		}
+		fmt.Println(string(data))
+	},
+}
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"godex/pkg/logger"
+	"godex/pkg/reportdecoder"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var reportReceiverCmd = &cobra.Command{
+	Use:   "reportReceiver",
+	Short: "Run a local report receiver that decrypts and prints reports",
+	Long: `Listen locally for reports sent by system.report, decrypt them with an RSA private key, validate the schema and print them as JSON lines.
+Point system.report.endpoint at the listen address and aes-public-key at the matching public key; use --generate-key to create a key pair for development.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		listen, _ := cmd.Flags().GetString("listen")
+		keyFile, _ := cmd.Flags().GetString("private-key")
+		generate, _ := cmd.Flags().GetBool("generate-key")
+		output, _ := cmd.Flags().GetString("output")
+
+		privateKey, err := loadReceiverKey(keyFile, generate)
+		if err != nil {
+			logger.Fatalf("ReportReceiver load private key failed: %v", err)
+		}
+		decoder, err := reportdecoder.NewDecoder(privateKey)
+		if err != nil {
+			logger.Fatalf("ReportReceiver create decoder failed: %v", err)
+		}
+
+		// 每条上报输出到标准输出，指定output时同时追加写入文件(JSON Lines)
+		var file *os.File
+		if output != "" {
+			if file, err = os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+				logger.Fatalf("ReportReceiver open output file failed: %v", err)
+			}
+			defer file.Close()
+		}
+		var mu sync.Mutex
+		handler := reportdecoder.Handler(decoder, func(received reportdecoder.Received) error {
+			line, err := json.Marshal(received)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Println(string(line))
+			if file != nil {
+				_, err = file.Write(append(line, '\n'))
+			}
+			return err
+		})
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		server := &http.Server{Addr: listen, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+		}()
+
+		logger.Infof("ReportReceiver listening on %s", listen)
+		if err = server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatalf("ReportReceiver command failed: %v", err)
+		}
+	},
+}
+
+// loadReceiverKey 读取私钥，文件不存在且generate为true时生成密钥对，写入私钥并输出公钥
+func loadReceiverKey(keyFile string, generate bool) ([]byte, error) {
+	if keyFile == "" {
+		return nil, fmt.Errorf("--private-key is required")
+	}
+	privateKey, err := os.ReadFile(keyFile)
+	if err == nil || !os.IsNotExist(err) || !generate {
+		return privateKey, err
+	}
+
+	privateKey, publicKey, err := reportdecoder.GenerateKey(2048)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(keyFile, privateKey, 0600); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Generated %s, set system.report.aes-public-key to:\n%s", keyFile, publicKey)
+	return privateKey, nil
+}
+
+func init() {
+	reportReceiverCmd.Flags().StringP("listen", "l", "127.0.0.1:8089", "listen address")
+	reportReceiverCmd.Flags().StringP("private-key", "k", "", "RSA private key PEM file (PKCS#1 or PKCS#8)")
+	reportReceiverCmd.Flags().Bool("generate-key", false, "generate a key pair when the private key file does not exist")
+	reportReceiverCmd.Flags().StringP("output", "o", "", "also append received reports to this file (JSON Lines)")
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"godex/internal/logic/impl"
+	"godex/pkg/api"
+	"godex/pkg/client"
+	"godex/pkg/logger"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+var tasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "Show last run and next scheduled time of configured tasks",
+	Long: `Show every task in system.tasks with its last run and next scheduled time, same as /admin/tasks.
+Without --server the last run is read from system.task-history.file; with --server the running instance is queried.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		server, _ := cmd.Flags().GetString("server")
+		apiKey, _ := cmd.Flags().GetString("api-key")
+		runs, _ := cmd.Flags().GetInt("runs")
+		taskName, _ := cmd.Flags().GetString("task")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		ctx := context.Background()
+		var data any
+		var err error
+		switch {
+		case server != "" && runs > 0:
+			data, err = client.New(server, client.WithAPIKey(apiKey)).TaskRuns(ctx, api.TaskRunsReq{Task: taskName, Limit: runs})
+		case server != "":
+			data, err = client.New(server, client.WithAPIKey(apiKey)).Tasks(ctx, api.TasksReq{})
+		case runs > 0:
+			data, err = impl.TaskLogic.Runs(ctx, api.TaskRunsReq{Task: taskName, Limit: runs})
+		default:
+			data, err = impl.TaskLogic.Tasks(ctx, api.TasksReq{})
+		}
+		if err != nil {
+			logger.Fatalf("Tasks command failed: %v", err)
+		}
+
+		if asJSON {
+			out, err := json.MarshalIndent(data, "", "  ")
+			if err != nil {
+				logger.Fatalf("Tasks marshal failed: %v", err)
+			}
+			fmt.Println(string(out))
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+		switch data := data.(type) {
+		case api.TasksRsp:
+			fmt.Fprintln(w, "NAME\tENABLE\tCRON\tLAST RUN\tRESULT\tDURATION\tNEXT RUN\tERROR")
+			for _, status := range data {
+				lastRun, result, duration, errMsg := "-", "-", "-", status.Error
+				if run := status.LastRun; run != nil {
+					lastRun, result, duration = formatUnix(run.StartedAt), run.Result, formatMillis(run.DurationMs)
+					if errMsg == "" {
+						errMsg = run.Error
+					}
+				}
+				fmt.Fprintf(w, "%s\t%t\t%s\t%s\t%s\t%s\t%s\t%s\n", status.Name, status.Enable, status.Cron,
+					lastRun, result, duration, formatUnix(status.NextRunAt), orDash(errMsg))
+			}
+		case api.TaskRunsRsp:
+			fmt.Fprintln(w, "TASK\tTRIGGER\tSTARTED\tDURATION\tRESULT\tERROR")
+			for _, run := range data {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", run.Task, run.Trigger, formatUnix(run.StartedAt),
+					formatMillis(run.DurationMs), run.Result, orDash(run.Error))
+			}
+		}
+	},
+}
+
+// formatUnix 格式化unix秒，0输出-
+func formatUnix(seconds int64) string {
+	if seconds == 0 {
+		return "-"
+	}
+	return time.Unix(seconds, 0).Format(time.DateTime)
+}
+
+// formatMillis 格式化毫秒耗时
+func formatMillis(millis int64) string {
+	return (time.Duration(millis) * time.Millisecond).String()
+}
+
+// orDash 空字符串输出-
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	tasksCmd.Flags().StringP("server", "s", "", "query a running instance, e.g. http://127.0.0.1:8000")
+	tasksCmd.Flags().String("api-key", "", "admin API key (system.auth.admin-clients) for --server")
+	tasksCmd.Flags().IntP("runs", "n", 0, "show the latest N runs instead of the task summary")
+	tasksCmd.Flags().StringP("task", "t", "", "only show runs of this task (with --runs)")
+	tasksCmd.Flags().Bool("json", false, "print JSON")
+}
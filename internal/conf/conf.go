@@ -1,11 +1,15 @@
 package conf
 
 import (
+	"godex/pkg/auth"
 	"godex/pkg/cfgs"
 	"godex/pkg/constant"
 	"godex/pkg/logger"
+	"godex/pkg/ratelimit"
 	"godex/pkg/report"
 	"godex/pkg/task"
+	"godex/pkg/tracing"
+	"godex/pkg/webhook"
 )
 
 // 全局配置及加载器
@@ -34,6 +38,22 @@ type AppSettingConfig struct {
 	FixedSniffer    []string `yaml:"fixed-sniffer"`
 	BucketName      string   `yaml:"bucket-name"`
 	BucketEndpoint  string   `yaml:"bucket-endpoint"`
+	AllowList       []string `yaml:"allow-list"`      // 放行名单，其中的域名及其子域名不会被判定命中
+	HashPrefixLen   int      `yaml:"hash-prefix-len"` // 哈希前缀下载默认长度(字节)，默认4
+
+	SyncHistorySize       int `yaml:"sync-history-size"`        // 增量同步保留的版本个数，默认24
+	SyncHistoryMaxEntries int `yaml:"sync-history-max-entries"` // 增量同步保留的差异条目总数上限，默认500000
+	SyncMaxDeltaEntries   int `yaml:"sync-max-delta-entries"`   // 单次增量同步最多返回的条目数，超过则全量重置，默认50000
+
+	StreamMaxLines      int `yaml:"stream-max-lines"`      // 流式检查单个流最多处理的行数，默认10000000
+	StreamMaxLineBytes  int `yaml:"stream-max-line-bytes"` // 流式检查单行最大字节数，默认4096
+	StreamMaxConcurrent int `yaml:"stream-max-concurrent"` // 流式检查同时处理的流个数上限，默认4
+
+	PushMaxConnections   int `yaml:"push-max-connections"`   // 同时订阅列表更新的连接数上限，默认1000
+	PushHeartbeatSeconds int `yaml:"push-heartbeat-seconds"` // 列表更新推送的心跳间隔(秒)，默认25
+
+	WatchPatterns       []string `yaml:"watch-patterns"`        // 关注规则(通配符，如*binance*)，新增域名命中时产生watch.matched事件
+	HighSeveritySources []string `yaml:"high-severity-sources"` // 高风险来源，检查命中时产生check.hit事件
 }
 
 // SystemConfig 包含其他相关的配置
@@ -44,12 +64,45 @@ type SystemConfig struct {
 	Log      logger.LogConfig    `yaml:"log" json:"log"`
 	Tasks    []task.TaskConfig   `yaml:"tasks" json:"tasks"`
 	Report   report.ReportConfig `yaml:"report" json:"report"`
+
+	// 功能模块配置
+	TaskHistory task.HistoryConfig `yaml:"task-history" json:"task-history"` // 任务执行记录
+	DNS         DNSConfig          `yaml:"dns" json:"dns"`
+	Webhook     webhook.Config     `yaml:"webhook" json:"webhook"`
+	Tracing     tracing.Config     `yaml:"tracing" json:"tracing"`
+	Auth        auth.Config        `yaml:"auth" json:"auth"`             // /browserext接口认证
+	RateLimit   ratelimit.Config   `yaml:"rate-limit" json:"rate-limit"` // /browserext接口限流
 }
 
 // ServiceConfig 是服务相关的配置
 type ServiceConfig struct {
 	Name string `yaml:"name"`
 	Port int    `yaml:"port"`
+
+	// GRPC gRPC服务配置
+	GRPC GRPCConfig `yaml:"grpc" json:"grpc"`
+	// TrustedProxies 可信反向代理(IP或CIDR)，仅来自这些地址的X-Forwarded-For/X-Real-IP会被采用，为空时使用直连地址
+	TrustedProxies []string `yaml:"trusted-proxies" json:"trusted-proxies"`
+	// DocsScriptURL /docs页面加载的Redoc脚本地址，为空时使用官方CDN，无法访问外网时可指向自托管的redoc.standalone.js
+	DocsScriptURL string `yaml:"docs-script-url" json:"docs-script-url"`
+}
+
+// GRPCConfig gRPC服务配置
+type GRPCConfig struct {
+	Enable bool `yaml:"enable" json:"enable"`
+	Port   int  `yaml:"port" json:"port"` // 独立端口，为0或与Web端口相同时与Web服务共用端口(h2c)
+}
+
+// DNSConfig DNS拦截解析服务配置
+type DNSConfig struct {
+	Enable       bool     `yaml:"enable" json:"enable"`
+	Listen       string   `yaml:"listen" json:"listen"`               // 监听地址(UDP+TCP)，默认:5353
+	Upstreams    []string `yaml:"upstreams" json:"upstreams"`         // 上游DNS服务器，按顺序尝试，如8.8.8.8:53
+	SinkholeIPv4 string   `yaml:"sinkhole-ipv4" json:"sinkhole-ipv4"` // 命中时A记录返回的地址，与sinkhole-ipv6均为空时返回NXDOMAIN
+	SinkholeIPv6 string   `yaml:"sinkhole-ipv6" json:"sinkhole-ipv6"` // 命中时AAAA记录返回的地址
+	TTL          uint32   `yaml:"ttl" json:"ttl"`                     // 拦截应答的TTL(秒)，默认60
+	Timeout      int      `yaml:"timeout" json:"timeout"`             // 上游查询超时(毫秒)，默认2000
+	LogQueries   bool     `yaml:"log-queries" json:"log-queries"`     // 是否记录每条查询日志
 }
 
 // InitConfig 初始化配置，使用默认的配置路径
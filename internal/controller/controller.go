@@ -5,31 +5,48 @@ import (
 	"github.com/kataras/iris/v12"
 	recovermw "github.com/kataras/iris/v12/middleware/recover"
 	"github.com/kataras/iris/v12/middleware/requestid"
+	"godex/internal/conf"
 	"godex/internal/errors"
 	"godex/internal/logic/impl"
 	"godex/internal/middleware"
 	"godex/pkg/api"
+	"godex/pkg/auth"
 	"godex/pkg/errs"
+	"godex/pkg/metrics"
+	"godex/pkg/ratelimit"
+	"time"
 )
 
 // Routing ...
-func Routing(app *iris.Application) {
+func Routing(app *iris.Application) error {
 	// 1. 全局中间件
 	{
-		app.UseRouter(recovermw.New())               // panic保护
-		app.UseRouter(middleware.LoggerMiddleware()) // 自定义日志中间件
-		app.AllowMethods(iris.MethodOptions)         // OPTIONS预检
-		app.UseRouter(requestid.New())               // 自动生成/传递 X-Request-ID
-		app.UseRouter(cors.AllowAll())               // 开启跨域
-		app.Use(iris.Compression)                    // 启用数据压缩
+		realIP, err := middleware.RealIPMiddleware(conf.AppConfig.System.Service.TrustedProxies)
+		if err != nil {
+			return err
+		}
+		app.UseRouter(recovermw.New())                // panic保护
+		app.UseRouter(realIP)                         // 客户端真实IP，需在使用真实IP的中间件之前
+		app.UseRouter(middleware.LoggerMiddleware())  // 自定义日志中间件
+		app.UseRouter(middleware.MetricsMiddleware()) // 请求指标
+		app.AllowMethods(iris.MethodOptions)          // OPTIONS预检
+		app.UseRouter(requestid.New())                // 自动生成/传递 X-Request-ID
+		app.UseRouter(middleware.TracingMiddleware()) // 链路追踪，需在requestid之后
+		app.UseRouter(cors.AllowAll())                // 开启跨域
+		app.Use(iris.Compression)                     // 启用数据压缩
 	}
 
-	// 2. 健康检查(仅用于观察服务是否在运行)
+	// 注册路由时收集请求/响应类型，用于生成接口文档
+	spec := api.NewSpec(apiTitle(), apiVersion)
+	spec.AddErrorCodes(errors.Catalogue...)
+
+	// 2. 健康检查(仅用于观察服务是否在运行)及Prometheus指标
 	{
 		app.Get("/health", func(ctx iris.Context) {
 			ctx.JSON(iris.Map{"status": "ok"})
 		})
-		app.Get("/working", api.Handler[api.WorkingReq, api.WorkingRsp](impl.WorkingLogic.Working))
+		app.Get("/metrics", iris.FromStd(metrics.Handler()))
+		api.Get(api.NewRouter(app, spec).Party("/", "health"), "/working", "服务运行状态", impl.WorkingLogic.Working)
 	}
 
 	// 3. 全局错误捕获
@@ -45,8 +62,81 @@ func Routing(app *iris.Application) {
 
 	// 4. 业务路由
 	{
-		browserextAPI := app.Party("/browserext")
-		phishingSitesAPI := browserextAPI.Party("/phishing_sites")
-		phishingSitesAPI.Post("/check", api.Handler[api.CheckSitesReq, api.CheckSitesRsp](impl.PhishingSitesLogic.CheckSites))
+		// 启用认证时/browserext接口需认证
+		var authMiddleware iris.Handler
+		if authConfig := conf.AppConfig.System.Auth; authConfig.Enable {
+			authenticator, err := auth.NewAuthenticator(authConfig)
+			if err != nil {
+				return err
+			}
+			// 浏览器EventSource/WebSocket无法设置请求头，订阅接口允许通过查询参数api_key认证
+			authMiddleware = middleware.AuthMiddleware(authenticator,
+				"/browserext/phishing_sites/updates/sse", "/browserext/phishing_sites/updates/ws")
+		}
+
+		// 启用限流时认证前先按IP限流，被拒绝的凭证同样计数
+		var limiter *ratelimit.Limiter
+		if rateLimitConfig := conf.AppConfig.System.RateLimit; rateLimitConfig.Enable {
+			var err error
+			if limiter, err = ratelimit.NewLimiter(rateLimitConfig); err != nil {
+				return err
+			}
+		}
+
+		browserextAPI := api.NewRouter(app, spec).Party("/browserext", "")
+		if limiter != nil {
+			browserextAPI.Use(middleware.PreAuthRateLimitMiddleware(limiter)).Responds(api.RateLimitResponse(errors.RateLimitExceeded))
+		}
+		if authMiddleware != nil {
+			browserextAPI.Use(authMiddleware).Responds(api.UnauthorizedResponse(errors.Unauthorized))
+		}
+		if limiter != nil {
+			browserextAPI.Use(middleware.RateLimitMiddleware(limiter))
+		}
+		browserextAPI.Use(middleware.CallerMiddleware())
+		phishingSitesAPI := browserextAPI.Party("/phishing_sites", "phishing_sites")
+		api.Post(phishingSitesAPI, "/check", "批量检查域名是否命中", impl.PhishingSitesLogic.CheckSites)
+		api.PostBodyStream(phishingSitesAPI, "/check/stream", "流式批量检查(NDJSON)", "application/x-ndjson", "application/x-ndjson", impl.PhishingSitesLogic.CheckStream)
+		api.Get(phishingSitesAPI, "/stats", "缓存统计及数据新鲜度", impl.PhishingSitesLogic.Stats)
+		api.GetStream(phishingSitesAPI, "/export", "按指定格式导出列表", "text/plain", impl.PhishingSitesLogic.ExportSites)
+		api.Post(phishingSitesAPI, "/hash/find", "按哈希前缀查找完整哈希", impl.PhishingSitesLogic.HashFind)
+		api.Get(phishingSitesAPI, "/hash/prefixes", "下载哈希前缀集合", impl.PhishingSitesLogic.HashPrefixes)
+		api.Post(phishingSitesAPI, "/sync", "增量同步列表", impl.PhishingSitesLogic.SyncSites)
+		heartbeat := time.Duration(conf.AppConfig.AppSetting.PushHeartbeatSeconds) * time.Second
+		api.GetSSE(phishingSitesAPI, "/updates/sse", "订阅列表更新(Server-Sent Events)", heartbeat, impl.PhishingSitesLogic.SubscribeUpdates)
+		api.GetWebSocket(phishingSitesAPI, "/updates/ws", "订阅列表更新(WebSocket)", heartbeat, impl.PhishingSitesLogic.SubscribeUpdates)
+
+		// /admin接口使用独立的管理员凭证(auth.admin-clients)，未配置时不注册
+		if adminAuthConfig := conf.AppConfig.System.Auth.Admin(); len(adminAuthConfig.Clients) > 0 {
+			adminAuthenticator, err := auth.NewAuthenticator(adminAuthConfig)
+			if err != nil {
+				return err
+			}
+			adminAPI := api.NewRouter(app, spec).Party("/admin", "admin")
+			if limiter != nil {
+				adminAPI.Use(middleware.PreAuthRateLimitMiddleware(limiter)).Responds(api.RateLimitResponse(errors.RateLimitExceeded))
+			}
+			adminAPI.Use(middleware.AuthMiddleware(adminAuthenticator)).Responds(api.UnauthorizedResponse(errors.Unauthorized))
+			api.Get(adminAPI, "/tasks", "定时任务状态(最近一次及下次执行)", impl.TaskLogic.Tasks)
+			api.Get(adminAPI, "/tasks/runs", "定时任务执行记录", impl.TaskLogic.Runs)
+		}
+	}
+
+	// 5. 接口文档
+	{
+		app.Get("/openapi.json", spec.Handler())
+		app.Get("/docs", api.DocsHandler("/openapi.json", conf.AppConfig.System.Service.DocsScriptURL))
+	}
+	return nil
+}
+
+// apiVersion 接口文档版本
+const apiVersion = "1.0.0"
+
+// apiTitle 接口文档标题，使用配置中的服务名称
+func apiTitle() string {
+	if name := conf.AppConfig.System.Service.Name; name != "" {
+		return name + " API"
 	}
+	return "godex API"
 }
@@ -10,3 +10,22 @@ type PhishingSiteCheckRet struct {
 	Domain string `json:"domain"`
 	Source string `json:"source"`
 }
+
+// PhishingSitesStats 缓存统计，时间为unix秒(0表示未发生)，耗时为毫秒
+type PhishingSitesStats struct {
+	Total               int            `json:"total"`
+	Sources             map[string]int `json:"sources"`
+	Version             uint64         `json:"version"`
+	LoadCount           int            `json:"load_count"`
+	LoadFailCount       int            `json:"load_fail_count"`
+	LastLoadAt          int64          `json:"last_load_at"`
+	LastLoadSuccessAt   int64          `json:"last_load_success_at"`
+	LastLoadDurationMs  int64          `json:"last_load_duration_ms"`
+	RecentLoadDurations []int64        `json:"recent_load_durations_ms"`
+	LastLoadSources     map[string]int `json:"last_load_sources"`
+	LastImportAt        int64          `json:"last_import_at"`
+	LastImportDuration  int64          `json:"last_import_duration_ms"`
+	DataUpdatedAt       int64          `json:"data_updated_at"`
+	LastError           string         `json:"last_error"`
+	LastErrorAt         int64          `json:"last_error_at"`
+}
@@ -0,0 +1,38 @@
+package entity
+
+// SitesImportedEvent sites.imported事件数据：从外部数据源导入到OSS
+// AddedDomains为导入前缓存中不存在的域名，最多包含100个
+type SitesImportedEvent struct {
+	Source       string   `json:"source"`
+	Total        int      `json:"total"`
+	AddedCount   int      `json:"added_count"`
+	AddedDomains []string `json:"added_domains"`
+}
+
+// SitesLoadedEvent sites.loaded事件数据：加载到缓存并产生了新的快照版本
+// Adds/Removes最多包含100个域名，首个版本不包含差异
+type SitesLoadedEvent struct {
+	Version      uint64   `json:"version"`
+	Total        int      `json:"total"`
+	AddedCount   int      `json:"added_count"`
+	RemovedCount int      `json:"removed_count"`
+	Adds         []string `json:"adds"`
+	Removes      []string `json:"removes"`
+}
+
+// WatchMatch 命中关注规则的域名
+type WatchMatch struct {
+	Domain  string `json:"domain"`
+	Pattern string `json:"pattern"`
+}
+
+// WatchMatchedEvent watch.matched事件数据：新版本中新增的域名命中关注规则
+type WatchMatchedEvent struct {
+	Version uint64       `json:"version"`
+	Matches []WatchMatch `json:"matches"`
+}
+
+// CheckHitEvent check.hit事件数据：检查命中高风险来源的域名
+type CheckHitEvent struct {
+	Hits []*PhishingSiteCheckRet `json:"hits"`
+}
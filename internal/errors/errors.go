@@ -21,8 +21,33 @@ var (
 
 	// CallFail 调用错误
 	CallFail = errorCode(retcode.ErrorTypeRPCFail, 4)
+
+	// DataNotReady 数据尚未加载完成，如缓存或索引未构建
+	DataNotReady = errorCode(retcode.ErrorTypeBusinessErr, 5)
+
+	// StreamLimitExceeded 同时处理的流式请求个数超过上限
+	StreamLimitExceeded = errorCode(retcode.ErrorTypeReqLimit, 6)
+
+	// Unauthorized 认证失败，如缺少凭证、API Key无效、签名错误或请求重放
+	Unauthorized = errorCode(retcode.ErrorTypeAuthFail, 7)
+
+	// RateLimitExceeded 请求频率超过限额
+	RateLimitExceeded = errorCode(retcode.ErrorTypeReqLimit, 8)
 )
 
+// Catalogue 错误码清单，用于生成接口文档，新增错误码时需同步添加
+var Catalogue = []errs.CodeInfo{
+	{Code: errs.RetClientEncodeFail, Name: "RequestDecodeFail", Description: "请求参数解析失败"},
+	{Code: RequestParamInvalid, Name: "RequestParamInvalid", Description: "参数错误"},
+	{Code: InternalConfigErr, Name: "InternalConfigErr", Description: "内部配置错误，如配置文件中缺少某种配置"},
+	{Code: InternalError, Name: "InternalError", Description: "服务内部错误"},
+	{Code: CallFail, Name: "CallFail", Description: "调用错误，如请求的接口不存在"},
+	{Code: DataNotReady, Name: "DataNotReady", Description: "数据尚未加载完成，如缓存或索引未构建"},
+	{Code: StreamLimitExceeded, Name: "StreamLimitExceeded", Description: "同时处理的流式请求或订阅连接个数超过上限"},
+	{Code: Unauthorized, Name: "Unauthorized", Description: "认证失败，如缺少凭证、API Key无效、签名错误或请求重放"},
+	{Code: RateLimitExceeded, Name: "RateLimitExceeded", Description: "请求频率超过限额，按Retry-After响应头等待后重试"},
+}
+
 // ErrorCode ...
 func ErrorCode(bizType retcode.BizType, errorType retcode.ErrorType, customCode int) int32 {
 	return int32(int(bizType)*100000 + int(errorType)*1000 + customCode)
@@ -2,16 +2,37 @@ package impl
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 	"github.com/jinzhu/copier"
+	"godex/internal/conf"
 	"godex/internal/errors"
 	"godex/internal/logic"
 	"godex/internal/service"
 	"godex/pkg/api"
+	"godex/pkg/blocklist"
 	"godex/pkg/errs"
+	"godex/pkg/hashprefix"
+	"godex/pkg/listsync"
+	"godex/pkg/logger"
+	"io"
+	"net"
+	"strconv"
+	"strings"
 )
 
 var PhishingSitesLogic logic.PhishingSitesLogic = &phishingSitesLogic{}
 
+// maxHashFindPrefixes 单次哈希前缀查询最多的前缀个数
+const maxHashFindPrefixes = 500
+
+// defaultSyncMaxDeltaEntries 单次增量同步默认最多返回的条目数
+const defaultSyncMaxDeltaEntries = 50000
+
+// defaultPushMaxConnections 默认同时订阅列表更新的连接数上限
+const defaultPushMaxConnections = 1000
+
 type phishingSitesLogic struct {
 	/* dependencies */
 }
@@ -33,3 +54,249 @@ func (c *phishingSitesLogic) CheckSites(ctx context.Context, req api.CheckSitesR
 
 	return rsp, nil
 }
+
+// Stats 获取缓存统计及数据新鲜度
+func (c *phishingSitesLogic) Stats(ctx context.Context, req api.PhishingSitesStatsReq) (api.PhishingSitesStatsRsp, error) {
+	stats, err := service.NewPhishingSitesService().Stats(ctx)
+	if err != nil {
+		return api.PhishingSitesStatsRsp{}, errs.Newf(errors.InternalError, "get phishing sites stats failed")
+	}
+
+	var rsp api.PhishingSitesStatsRsp
+	if err = copier.Copy(&rsp, stats); err != nil {
+		return api.PhishingSitesStatsRsp{}, errs.Newf(errors.InternalError, "copy response data failed: %v", err)
+	}
+
+	return rsp, nil
+}
+
+// ExportSites 按指定格式流式导出当前缓存快照
+func (c *phishingSitesLogic) ExportSites(ctx context.Context, req api.ExportSitesReq, w io.Writer) error {
+	format, err := blocklist.ParseFormat(req.Format)
+	if err != nil {
+		return errs.Newf(errors.RequestParamInvalid, "%v", err)
+	}
+	if req.Address != "" && net.ParseIP(req.Address) == nil {
+		return errs.Newf(errors.RequestParamInvalid, "invalid address %q", req.Address)
+	}
+
+	if irisCtx, ok := api.ToIrisContext(ctx); ok {
+		irisCtx.ContentType(format.ContentType())
+		irisCtx.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", format.FileName("phishing-sites")))
+	}
+
+	if _, err = service.NewPhishingSitesService().ExportPhishingSites(ctx, w, format, req.Address); err != nil {
+		return errs.Newf(errors.InternalError, "export phishing sites failed: %v", err)
+	}
+	return nil
+}
+
+// HashFind 按哈希前缀查找完整哈希
+func (c *phishingSitesLogic) HashFind(ctx context.Context, req api.HashFindReq) (api.HashFindRsp, error) {
+	if len(req.Prefixes) == 0 || len(req.Prefixes) > maxHashFindPrefixes {
+		return api.HashFindRsp{}, errs.Newf(errors.RequestParamInvalid, "prefixes count must be between 1 and %d", maxHashFindPrefixes)
+	}
+
+	prefixes := make([][]byte, 0, len(req.Prefixes))
+	for _, prefixHex := range req.Prefixes {
+		prefix, err := hex.DecodeString(prefixHex)
+		if err != nil || len(prefix) < hashprefix.MinPrefixLen || len(prefix) > hashprefix.MaxPrefixLen {
+			return api.HashFindRsp{}, errs.Newf(errors.RequestParamInvalid, "invalid prefix %q, must be %d~%d bytes hex",
+				prefixHex, hashprefix.MinPrefixLen, hashprefix.MaxPrefixLen)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	version, matches, err := service.NewPhishingSitesService().FindFullHashes(ctx, prefixes)
+	if err != nil {
+		return api.HashFindRsp{}, errs.Newf(errors.DataNotReady, "find full hashes failed: %v", err)
+	}
+
+	rsp := api.HashFindRsp{Version: version, Matches: make([]api.HashMatch, 0, len(matches))}
+	for i, entries := range matches {
+		match := api.HashMatch{Prefix: strings.ToLower(req.Prefixes[i]), FullHashes: make([]api.FullHash, 0, len(entries))}
+		for _, entry := range entries {
+			match.FullHashes = append(match.FullHashes, api.FullHash{Hash: hex.EncodeToString(entry.Hash[:]), Source: entry.Source})
+		}
+		rsp.Matches = append(rsp.Matches, match)
+	}
+	return rsp, nil
+}
+
+// HashPrefixes 下载哈希前缀集合
+func (c *phishingSitesLogic) HashPrefixes(ctx context.Context, req api.HashPrefixesReq) (api.HashPrefixesRsp, error) {
+	prefixLen := req.PrefixLen
+	if prefixLen == 0 {
+		prefixLen = conf.AppConfig.AppSetting.HashPrefixLen
+	}
+	if prefixLen == 0 {
+		prefixLen = hashprefix.DefaultPrefixLen
+	}
+	if prefixLen < hashprefix.MinPrefixLen || prefixLen > hashprefix.MaxPrefixLen {
+		return api.HashPrefixesRsp{}, errs.Newf(errors.RequestParamInvalid, "prefix_len must be between %d and %d",
+			hashprefix.MinPrefixLen, hashprefix.MaxPrefixLen)
+	}
+
+	version, prefixes, count, err := service.NewPhishingSitesService().HashPrefixes(ctx, prefixLen)
+	if err != nil {
+		return api.HashPrefixesRsp{}, errs.Newf(errors.DataNotReady, "get hash prefixes failed: %v", err)
+	}
+
+	return api.HashPrefixesRsp{
+		Version:   version,
+		PrefixLen: prefixLen,
+		Count:     count,
+		Prefixes:  base64.StdEncoding.EncodeToString(prefixes),
+	}, nil
+}
+
+// SyncSites 增量同步列表
+func (c *phishingSitesLogic) SyncSites(ctx context.Context, req api.SyncSitesReq) (api.SyncSitesRsp, error) {
+	if req.MaxEntries < 0 {
+		return api.SyncSitesRsp{}, errs.Newf(errors.RequestParamInvalid, "max_entries must not be negative")
+	}
+	delta, err := service.NewPhishingSitesService().SyncPhishingSites(ctx, req.Epoch, req.Version, syncMaxDeltaEntries(req.MaxEntries))
+	if err != nil {
+		return api.SyncSitesRsp{}, errs.Newf(errors.DataNotReady, "sync phishing sites failed: %v", err)
+	}
+
+	encoding := listsync.Negotiate(listsync.Encoding(req.Encoding))
+	rsp := api.SyncSitesRsp{
+		Epoch:    delta.Epoch,
+		Version:  delta.Version,
+		Reset:    delta.Reset,
+		Encoding: int(encoding),
+	}
+	if encoding == listsync.EncodingJSON {
+		rsp.Adds, rsp.Removes = delta.Adds, delta.Removes
+		return rsp, nil
+	}
+
+	if rsp.AddsData, err = listsync.EncodeLines(delta.Adds); err != nil {
+		return api.SyncSitesRsp{}, errs.Newf(errors.InternalError, "encode adds failed: %v", err)
+	}
+	if rsp.RemovesData, err = listsync.EncodeLines(delta.Removes); err != nil {
+		return api.SyncSitesRsp{}, errs.Newf(errors.InternalError, "encode removes failed: %v", err)
+	}
+	return rsp, nil
+}
+
+// CheckStream 流式批量检查，逐行读取请求体并逐行输出NDJSON结果
+func (c *phishingSitesLogic) CheckStream(ctx context.Context, req api.CheckStreamReq, r io.Reader, w io.Writer) error {
+	limits := service.StreamCheckLimits{
+		MaxLines:      conf.AppConfig.AppSetting.StreamMaxLines,
+		MaxLineBytes:  conf.AppConfig.AppSetting.StreamMaxLineBytes,
+		MaxConcurrent: conf.AppConfig.AppSetting.StreamMaxConcurrent,
+	}
+	release, err := service.AcquireCheckStream(limits)
+	if err != nil {
+		return errs.Newf(errors.StreamLimitExceeded, "%v", err)
+	}
+	defer release()
+
+	if irisCtx, ok := api.ToIrisContext(ctx); ok {
+		irisCtx.ContentType("application/x-ndjson")
+	}
+
+	summary, err := service.NewPhishingSitesService().CheckPhishingSitesStream(ctx, r, w, limits)
+	logger.Infof("Check stream finished, lines: %d, hits: %d, errors: %d", summary.Lines, summary.Hits, summary.Errors)
+	if err != nil {
+		return errs.Newf(errors.InternalError, "check stream failed: %v", err)
+	}
+	return nil
+}
+
+// SubscribeUpdates 订阅列表更新：先按客户端版本发送hello/delta/resync，再持续推送后续版本的差异
+// 只有delta事件带事件ID，客户端收到hello或resync后需通过增量同步接口同步到最新版本
+func (c *phishingSitesLogic) SubscribeUpdates(ctx context.Context, req api.SubscribeUpdatesReq, send api.EventSender) error {
+	epoch, version := req.Epoch, req.Version
+	if epoch == "" && req.LastEventID != "" {
+		var ok bool
+		if epoch, version, ok = parseUpdateEventID(req.LastEventID); !ok {
+			return errs.Newf(errors.RequestParamInvalid, "invalid last event id %q", req.LastEventID)
+		}
+	}
+	maxConnections := conf.AppConfig.AppSetting.PushMaxConnections
+	if maxConnections <= 0 {
+		maxConnections = defaultPushMaxConnections
+	}
+	maxEntries := syncMaxDeltaEntries(0)
+
+	// 先订阅再计算客户端版本的差异，避免遗漏期间提交的版本
+	svc := service.NewPhishingSitesService()
+	sub, err := svc.SubscribeUpdates(ctx, maxConnections)
+	if err != nil {
+		return errs.Newf(errors.StreamLimitExceeded, "%v", err)
+	}
+	defer sub.Close()
+
+	delta, err := svc.SyncPhishingSites(ctx, epoch, version, maxEntries)
+	event := api.UpdateEvent{Type: api.UpdateEventHello, Epoch: delta.Epoch, Version: delta.Version}
+	switch {
+	case err != nil || version == 0:
+		// 快照尚未就绪或客户端未指定版本
+	case delta.Reset:
+		event.Type = api.UpdateEventResync
+	case len(delta.Adds)+len(delta.Removes) > 0:
+		event.Type, event.Adds, event.Removes = api.UpdateEventDelta, delta.Adds, delta.Removes
+	}
+	if err = send(updateEvent(event)); err != nil {
+		return errs.Newf(errors.InternalError, "send update event failed: %v", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case update, ok := <-sub.C:
+			if !ok {
+				return errs.Newf(errors.InternalError, "update subscriber lagged behind at version %d", event.Version)
+			}
+			if update.Epoch == event.Epoch && update.Version <= event.Version {
+				continue
+			}
+
+			event = api.UpdateEvent{Type: api.UpdateEventDelta, Epoch: update.Epoch, Version: update.Version, Adds: update.Adds, Removes: update.Removes}
+			if update.Reset || len(update.Adds)+len(update.Removes) > maxEntries {
+				event.Type, event.Adds, event.Removes = api.UpdateEventResync, nil, nil
+			}
+			if err = send(updateEvent(event)); err != nil {
+				return errs.Newf(errors.InternalError, "send update event failed: %v", err)
+			}
+		}
+	}
+}
+
+// syncMaxDeltaEntries 单次同步最多返回的条目数，requested>0时不超过requested
+func syncMaxDeltaEntries(requested int) int {
+	maxEntries := conf.AppConfig.AppSetting.SyncMaxDeltaEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultSyncMaxDeltaEntries
+	}
+	if requested > 0 && requested < maxEntries {
+		maxEntries = requested
+	}
+	return maxEntries
+}
+
+// updateEvent 构造推送事件，delta事件的ID为"epoch:version"
+func updateEvent(event api.UpdateEvent) api.Event {
+	id := ""
+	if event.Type == api.UpdateEventDelta {
+		id = event.Epoch + ":" + strconv.FormatUint(event.Version, 10)
+	}
+	return api.Event{ID: id, Name: event.Type, Data: event}
+}
+
+// parseUpdateEventID 解析"epoch:version"格式的事件ID
+func parseUpdateEventID(id string) (string, uint64, bool) {
+	index := strings.LastIndex(id, ":")
+	if index <= 0 {
+		return "", 0, false
+	}
+	version, err := strconv.ParseUint(id[index+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return id[:index], version, true
+}
@@ -0,0 +1,67 @@
+package impl
+
+import (
+	"context"
+	"godex/internal/logic"
+	"godex/internal/task"
+	"godex/pkg/api"
+	pkgtask "godex/pkg/task"
+)
+
+var TaskLogic logic.TaskLogic = &taskLogic{}
+
+// 执行记录默认及最多返回的条数
+const (
+	defaultTaskRunsLimit = 50
+	maxTaskRunsLimit     = 1000
+)
+
+type taskLogic struct {
+	/* dependencies */
+}
+
+// 编译时检查接口实现
+var _ logic.TaskLogic = (*taskLogic)(nil)
+
+// Tasks 获取已配置任务的最近一次执行及下次执行时间
+func (c *taskLogic) Tasks(ctx context.Context, req api.TasksReq) (api.TasksRsp, error) {
+	statuses := task.Status()
+	rsp := make(api.TasksRsp, 0, len(statuses))
+	for _, status := range statuses {
+		item := api.TaskStatus{
+			Name: status.Name, Function: status.Function, Cron: status.Cron,
+			Description: status.Description, Enable: status.Enable, Error: status.Error,
+		}
+		if status.LastRun != nil {
+			run := toTaskRun(*status.LastRun)
+			item.LastRun = &run
+		}
+		if status.NextRun != nil {
+			item.NextRunAt = status.NextRun.Unix()
+		}
+		rsp = append(rsp, item)
+	}
+	return rsp, nil
+}
+
+// Runs 获取任务执行记录
+func (c *taskLogic) Runs(ctx context.Context, req api.TaskRunsReq) (api.TaskRunsRsp, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultTaskRunsLimit
+	}
+	runs := task.Runs(req.Task, min(limit, maxTaskRunsLimit))
+	rsp := make(api.TaskRunsRsp, 0, len(runs))
+	for _, run := range runs {
+		rsp = append(rsp, toTaskRun(run))
+	}
+	return rsp, nil
+}
+
+// toTaskRun 执行记录转换为响应
+func toTaskRun(run pkgtask.Run) api.TaskRun {
+	return api.TaskRun{
+		Task: run.Task, Function: run.Function, Trigger: run.Trigger,
+		StartedAt: run.Start.Unix(), DurationMs: run.Duration, Result: run.Result, Error: run.Error,
+	}
+}
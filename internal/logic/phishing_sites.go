@@ -3,10 +3,32 @@ package logic
 import (
 	"context"
 	"godex/pkg/api"
+	"io"
 )
 
 // PhishingSitesLogic 逻辑层接口
 type PhishingSitesLogic interface {
 	// CheckSites 检查网站是否为
 	CheckSites(ctx context.Context, req api.CheckSitesReq) (api.CheckSitesRsp, error)
+
+	// Stats 获取缓存统计及数据新鲜度
+	Stats(ctx context.Context, req api.PhishingSitesStatsReq) (api.PhishingSitesStatsRsp, error)
+
+	// ExportSites 按指定格式流式导出当前缓存快照
+	ExportSites(ctx context.Context, req api.ExportSitesReq, w io.Writer) error
+
+	// HashFind 按哈希前缀查找完整哈希
+	HashFind(ctx context.Context, req api.HashFindReq) (api.HashFindRsp, error)
+
+	// HashPrefixes 下载哈希前缀集合
+	HashPrefixes(ctx context.Context, req api.HashPrefixesReq) (api.HashPrefixesRsp, error)
+
+	// SyncSites 增量同步列表
+	SyncSites(ctx context.Context, req api.SyncSitesReq) (api.SyncSitesRsp, error)
+
+	// CheckStream 流式批量检查，逐行读取请求体并逐行输出NDJSON结果
+	CheckStream(ctx context.Context, req api.CheckStreamReq, r io.Reader, w io.Writer) error
+
+	// SubscribeUpdates 订阅列表更新，阻塞直到ctx结束或发送失败
+	SubscribeUpdates(ctx context.Context, req api.SubscribeUpdatesReq, send api.EventSender) error
 }
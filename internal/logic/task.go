@@ -0,0 +1,15 @@
+package logic
+
+import (
+	"context"
+	"godex/pkg/api"
+)
+
+// TaskLogic 定时任务管理逻辑层接口
+type TaskLogic interface {
+	// Tasks 获取已配置任务的最近一次执行及下次执行时间
+	Tasks(ctx context.Context, req api.TasksReq) (api.TasksRsp, error)
+
+	// Runs 获取任务执行记录
+	Runs(ctx context.Context, req api.TaskRunsReq) (api.TaskRunsRsp, error)
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/kataras/iris/v12"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"godex/internal/errors"
+	"godex/pkg/api"
+	"godex/pkg/auth"
+	"godex/pkg/errs"
+	"slices"
+)
+
+// AuthMiddleware 校验API Key或HMAC签名，失败时以统一响应格式返回401并终止处理
+// 认证通过的调用方放入请求的ctx，供日志、上报及限流使用；需在TracingMiddleware之后注册
+// queryKeyRoutes为允许通过查询参数传递API Key的路由模板(浏览器EventSource/WebSocket无法设置请求头)
+func AuthMiddleware(authenticator *auth.Authenticator, queryKeyRoutes ...string) iris.Handler {
+	return func(ctx iris.Context) {
+		req := ctx.Request()
+		authenticate := authenticator.Authenticate
+		if slices.Contains(queryKeyRoutes, ctx.GetCurrentRoute().Path()) {
+			authenticate = authenticator.AuthenticateQuery
+		}
+		identity, err := authenticate(req)
+		if err != nil {
+			api.ErrorWithStatus(ctx, iris.StatusUnauthorized, errs.Newf(errors.Unauthorized, "%v", err))
+			ctx.StopExecution()
+			return
+		}
+
+		trace.SpanFromContext(req.Context()).SetAttributes(
+			attribute.String("godex.client_id", identity.ClientID),
+			attribute.String("godex.auth_method", identity.Method),
+		)
+		ctx.ResetRequest(req.WithContext(auth.WithIdentity(req.Context(), identity)))
+		ctx.Next()
+	}
+}
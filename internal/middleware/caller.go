@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"github.com/kataras/iris/v12"
+	"go.opentelemetry.io/otel/trace"
+	"godex/pkg/report"
+	"strconv"
+	"strings"
+)
+
+// maxCallerHeaderLength 调用方请求头的最大长度，超过时忽略
+const maxCallerHeaderLength = 128
+
+// CallerMiddleware 从请求头及链路信息中提取调用方信息放入请求的ctx，供命中上报使用
+// 上报哪些字段由system.report.fields决定，携带opt-out请求头的请求不上报；需在TracingMiddleware之后注册
+func CallerMiddleware() iris.Handler {
+	return func(ctx iris.Context) {
+		req := ctx.Request()
+		caller := report.Caller{
+			InstallID:        callerHeader(ctx, report.HeaderInstallID),
+			ExtensionVersion: callerHeader(ctx, report.HeaderExtensionVersion),
+			IP:               getRealIP(ctx),
+			OptOut:           report.OptedOut(ctx.GetHeader(report.HeaderOptOut)),
+		}
+		if userID, err := strconv.Atoi(callerHeader(ctx, report.HeaderUserID)); err == nil && userID > 0 {
+			caller.UserID = userID
+		}
+		// 未启用链路追踪时使用请求ID
+		if spanContext := trace.SpanContextFromContext(req.Context()); spanContext.HasTraceID() {
+			caller.TraceID = spanContext.TraceID().String()
+		} else {
+			caller.TraceID, _ = ctx.GetID().(string)
+		}
+
+		ctx.ResetRequest(req.WithContext(report.WithCaller(req.Context(), caller)))
+		ctx.Next()
+	}
+}
+
+// callerHeader 读取调用方请求头，过长时忽略
+func callerHeader(ctx iris.Context, key string) string {
+	value := strings.TrimSpace(ctx.GetHeader(key))
+	if len(value) > maxCallerHeaderLength {
+		return ""
+	}
+	return value
+}
@@ -4,27 +4,38 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/kataras/iris/v12"
 	"github.com/sirupsen/logrus"
+	"godex/pkg/auth"
 	"godex/pkg/constant"
 	"godex/pkg/logger"
 	"io"
 	"math"
+	"net/url"
+	"strings"
 	"time"
 )
 
+// maxLoggedBodySize 日志中记录请求体的最大字节数，超过则不缓冲请求体
+const maxLoggedBodySize = 64 * 1024
+
 // LoggerMiddleware API请求日志中间件
 func LoggerMiddleware() iris.Handler {
 	return func(ctx iris.Context) {
 		path := ctx.Request().URL.Path
-		query := ctx.Request().URL.RawQuery
+		query := redactQuery(ctx.Request().URL.RawQuery)
 
-		// 读取请求体
+		// 读取请求体，流式请求及大请求体不缓冲，避免读取整个请求体到内存
 		var requestBody []byte
 		if ctx.Request().Body != nil {
-			requestBody, _ = io.ReadAll(ctx.Request().Body)
-			ctx.ResetRequest(ctx.Request().Clone(context.Background()))
-			ctx.Request().Body = io.NopCloser(bytes.NewBuffer(requestBody))
+			if isStreamingRequest(ctx) {
+				requestBody = []byte(fmt.Sprintf("[streaming body, content-length: %d]", ctx.Request().ContentLength))
+			} else {
+				requestBody, _ = io.ReadAll(ctx.Request().Body)
+				ctx.ResetRequest(ctx.Request().Clone(context.Background()))
+				ctx.Request().Body = io.NopCloser(bytes.NewBuffer(requestBody))
+			}
 		}
 
 		// 处理请求
@@ -51,6 +62,7 @@ func LoggerMiddleware() iris.Handler {
 			constant.IPKey:            getRealIP(ctx),
 			constant.HostNameKey:      ctx.Host(),
 			constant.UserIDKey:        0,
+			constant.ClientIDKey:      auth.ClientID(ctx.Request().Context()),
 			constant.MethodKey:        ctx.Request().Method,
 			constant.PathKey:          path,
 			constant.QueryKey:         query,
@@ -64,18 +76,31 @@ func LoggerMiddleware() iris.Handler {
 	}
 }
 
-// 获取真实IP，优先取 X-Forwarded-For/X-Real-IPKey
-func getRealIP(ctx iris.Context) string {
-	ip := ctx.GetHeader("X-Forwarded-For")
-	if ip != "" {
-		// X-Forwarded-For 可能有多个IP，取第一个
-		ips := bytes.Split([]byte(ip), []byte(","))
-		if len(ips) > 0 {
-			return string(bytes.TrimSpace(ips[0]))
-		}
+// redactQuery 隐去查询参数中的API Key
+func redactQuery(rawQuery string) string {
+	if !strings.Contains(rawQuery, auth.QueryAPIKey) {
+		return rawQuery
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil || !values.Has(auth.QueryAPIKey) {
+		return rawQuery
 	}
-	ip = ctx.GetHeader("X-Real-IPKey")
-	if ip != "" {
+	values.Set(auth.QueryAPIKey, "REDACTED")
+	return values.Encode()
+}
+
+// isStreamingRequest 判断是否为流式请求：NDJSON请求体、长度未知(分块传输)或超过日志记录上限
+func isStreamingRequest(ctx iris.Context) bool {
+	contentLength := ctx.Request().ContentLength
+	if contentLength < 0 || contentLength > maxLoggedBodySize {
+		return true
+	}
+	return strings.HasPrefix(ctx.GetContentTypeRequested(), "application/x-ndjson")
+}
+
+// getRealIP 获取客户端真实IP，由RealIPMiddleware解析，未注册时为直连地址
+func getRealIP(ctx iris.Context) string {
+	if ip := ctx.Values().GetString(realIPKey); ip != "" {
 		return ip
 	}
 	return ctx.RemoteAddr()
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bufio"
+	"github.com/kataras/iris/v12"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoggerMiddlewareStreamingBody(t *testing.T) {
+	app := iris.New()
+	app.Use(LoggerMiddleware())
+	// 读取第一行后通知测试写入请求体的其余部分
+	started := make(chan struct{})
+	app.Post("/stream", func(ctx iris.Context) {
+		reader := bufio.NewReader(ctx.Request().Body)
+		first, _ := reader.ReadString('\n')
+		close(started)
+		rest, _ := io.ReadAll(reader)
+		_, _ = ctx.WriteString(first + string(rest))
+	})
+	if err := app.Build(); err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	server := httptest.NewServer(app)
+	defer server.Close()
+
+	first, rest := "evil.com\n", "good.com\n"
+	body, writer := io.Pipe()
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/stream", body)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	// 声明长度使请求不是分块传输，仅按Content-Type判断为流式请求
+	req.ContentLength = int64(len(first + rest))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	type result struct {
+		data string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		results <- result{data: string(data), err: err}
+	}()
+	_, _ = io.WriteString(writer, first)
+
+	// 中间件缓冲请求体时会等待整个请求体，处理函数不会在剩余部分写入前开始执行
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		_ = writer.CloseWithError(io.ErrUnexpectedEOF)
+		t.Fatal("ndjson body was buffered by the logger")
+	}
+	_, _ = io.WriteString(writer, rest)
+	_ = writer.Close()
+
+	got := <-results
+	if got.err != nil || got.data != first+rest {
+		t.Fatalf("response = %q, %v", got.data, got.err)
+	}
+}
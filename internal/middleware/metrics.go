@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/kataras/iris/v12"
+	"godex/pkg/metrics"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute 未匹配到路由(404)时的route标签，避免按任意路径产生大量时间序列
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware 记录HTTP请求数及耗时，按方法、路由模板及状态码区分
+func MetricsMiddleware() iris.Handler {
+	return func(ctx iris.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := unmatchedRoute
+		if current := ctx.GetCurrentRoute(); current != nil {
+			route = current.Path()
+		}
+		labels := []string{ctx.Method(), route, strconv.Itoa(ctx.GetStatusCode())}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	}
+}
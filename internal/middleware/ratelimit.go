@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"github.com/kataras/iris/v12"
+	"godex/internal/errors"
+	"godex/pkg/api"
+	"godex/pkg/auth"
+	"godex/pkg/errs"
+	"godex/pkg/metrics"
+	"godex/pkg/ratelimit"
+	"math"
+	"strconv"
+	"time"
+)
+
+// 限流响应头(IETF RateLimit header fields草案)
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+)
+
+// RateLimitMiddleware 按路由令牌桶限流，认证通过的请求按客户端ID计数，否则按真实IP计数
+// 超过限额时以统一响应格式返回429并附带Retry-After；需在AuthMiddleware之后注册
+func RateLimitMiddleware(limiter *ratelimit.Limiter) iris.Handler {
+	return func(ctx iris.Context) {
+		route := ctx.GetCurrentRoute().Path()
+		if rateLimited(ctx, route, limiter.Allow(ctx.Method(), route, rateLimitKey(ctx))) {
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// PreAuthRateLimitMiddleware 认证前按真实IP限流(所有路由共用)，被拒绝的凭证同样计数，限制凭证猜测；需在AuthMiddleware之前注册
+func PreAuthRateLimitMiddleware(limiter *ratelimit.Limiter) iris.Handler {
+	return func(ctx iris.Context) {
+		if rateLimited(ctx, ctx.GetCurrentRoute().Path(), limiter.AllowPreAuth("ip:"+getRealIP(ctx))) {
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// rateLimited 写入限流响应头，被拒绝时返回429并结束请求
+func rateLimited(ctx iris.Context, route string, decision ratelimit.Decision) bool {
+	if !decision.Limited {
+		return false
+	}
+	ctx.Header(headerRateLimitLimit, strconv.Itoa(decision.Limit))
+	ctx.Header(headerRateLimitRemaining, strconv.Itoa(decision.Remaining))
+	ctx.Header(headerRateLimitReset, ceilSeconds(decision.Reset))
+	if decision.Allowed {
+		return false
+	}
+	metrics.RateLimited.WithLabelValues(route).Inc()
+	ctx.Header(headerRetryAfter, ceilSeconds(decision.RetryAfter))
+	api.ErrorWithStatus(ctx, iris.StatusTooManyRequests, errs.Newf(errors.RateLimitExceeded, "rate limit exceeded, retry after %s", decision.RetryAfter.Round(time.Millisecond)))
+	ctx.StopExecution()
+	return true
+}
+
+// rateLimitKey 限流计数的调用方标识
+func rateLimitKey(ctx iris.Context) string {
+	if clientID := auth.ClientID(ctx.Request().Context()); clientID != "" {
+		return "client:" + clientID
+	}
+	return "ip:" + getRealIP(ctx)
+}
+
+// ceilSeconds 时长向上取整为秒数
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/kataras/iris/v12"
+	"net"
+	"strings"
+)
+
+// realIPKey 客户端真实IP在请求上下文中的键
+const realIPKey = "godex.realIP"
+
+// RealIPMiddleware 解析客户端真实IP放入请求上下文，供日志、限流及上报使用
+// 仅当直连地址属于可信代理时采用X-Forwarded-For(从右向左第一个非可信代理的地址)或X-Real-IP，防止客户端伪造；
+// trustedProxies为IP或CIDR，格式错误时返回错误；需在使用真实IP的中间件之前注册
+func RealIPMiddleware(trustedProxies []string) (iris.Handler, error) {
+	proxies, err := parseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx iris.Context) {
+		ctx.Values().Set(realIPKey, proxies.realIP(ctx))
+		ctx.Next()
+	}, nil
+}
+
+// trustedProxies 可信代理地址段
+type trustedProxies []*net.IPNet
+
+// parseTrustedProxies 解析可信代理，单个IP按/32或/128处理
+func parseTrustedProxies(values []string) (trustedProxies, error) {
+	var proxies trustedProxies
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", value)
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %v", value, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// contains 判断地址是否属于可信代理
+func (p trustedProxies) contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// realIP 客户端真实IP，代理请求头中的地址无效时使用直连地址
+func (p trustedProxies) realIP(ctx iris.Context) string {
+	remote := ctx.RemoteAddr()
+	if !p.contains(remote) {
+		return remote
+	}
+	if forwarded := ctx.GetHeader("X-Forwarded-For"); forwarded != "" {
+		ips := strings.Split(forwarded, ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if net.ParseIP(ip) == nil {
+				return remote
+			}
+			if i == 0 || !p.contains(ip) {
+				return ip
+			}
+		}
+	}
+	if ip := strings.TrimSpace(ctx.GetHeader("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return remote
+}
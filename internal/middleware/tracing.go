@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/kataras/iris/v12"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"godex/pkg/tracing"
+	"net/http"
+)
+
+// TracingMiddleware 为每个请求创建server span，继承请求头中的W3C trace-context，并将请求ID放入baggage随出站请求传递
+// span放入请求的ctx，后续处理函数及业务代码可通过iris.Context获取；需在requestid中间件之后注册
+func TracingMiddleware() iris.Handler {
+	return func(ctx iris.Context) {
+		req := ctx.Request()
+		parent := tracing.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		requestID, _ := ctx.GetID().(string)
+		if requestID != "" {
+			parent = tracing.WithRequestID(parent, requestID)
+		}
+
+		spanCtx, span := tracing.Tracer().Start(parent, req.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.URLPath(req.URL.Path),
+				semconv.UserAgentOriginal(req.UserAgent()),
+				semconv.ClientAddress(getRealIP(ctx)),
+				attribute.String("http.request_id", requestID),
+			))
+		defer span.End()
+		ctx.ResetRequest(req.WithContext(spanCtx))
+
+		ctx.Next()
+
+		// 路由在处理完成后才能确定，未匹配路由时不设置http.route
+		status := ctx.GetStatusCode()
+		if route := ctx.GetCurrentRoute(); route != nil {
+			span.SetName(req.Method + " " + route.Path())
+			span.SetAttributes(semconv.HTTPRoute(route.Path()))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package resty
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/go-resty/resty/v2"
 	"godex/internal/conf"
 	"godex/internal/errors"
 	"godex/pkg/errs"
 	"godex/pkg/logger"
+	"godex/pkg/metrics"
+	"godex/pkg/tracing"
 	"time"
 )
 
@@ -19,20 +22,24 @@ type scamsniffer struct {
 func NewScamsnifferResty() *scamsniffer {
 	client := resty.New()
 	client.SetTimeout(30 * time.Second)
-	return &scamsniffer{client: client}
+	return &scamsniffer{client: tracing.InstrumentResty(client, "scamsniffer")}
 }
 
 // FetchDomains 读取
-func (r *scamsniffer) FetchDomains() ([]string, error) {
+func (r *scamsniffer) FetchDomains(ctx context.Context) ([]string, error) {
 	// 获取配置的URL
 	url := conf.AppConfig.AppSetting.ScamSniffer
 
 	// 发送HTTP请求获取数据
-	resp, err := r.client.R().Get(url)
+	start := time.Now()
+	resp, err := r.client.R().SetContext(ctx).Get(url)
 	if err != nil {
+		metrics.ObserveUpstream("scamsniffer", "fetch_domains", start, err)
 		return nil, errs.Newf(errors.InternalError, "failed to fetch data from %s: %v", url, err)
 	}
 
+	metrics.ObserveHTTPUpstream("scamsniffer", "fetch_domains", start, resp.StatusCode())
+
 	// 检查HTTP状态码
 	if resp.StatusCode() != 200 {
 		return nil, errs.Newf(errors.InternalError, "HTTP request failed with status: %d", resp.StatusCode())
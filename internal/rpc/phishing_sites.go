@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+	"godex/internal/logic"
+	"godex/internal/logic/impl"
+	"godex/pkg/api"
+	"godex/pkg/pb"
+)
+
+// phishingSitesServer gRPC钓鱼网站检测服务，复用HTTP接口的逻辑层实现
+type phishingSitesServer struct {
+	pb.UnimplementedPhishingSitesServer
+	logic logic.PhishingSitesLogic
+}
+
+// 编译时检查接口实现
+var _ pb.PhishingSitesServer = (*phishingSitesServer)(nil)
+
+// newPhishingSitesServer 创建gRPC钓鱼网站检测服务
+func newPhishingSitesServer() *phishingSitesServer {
+	return &phishingSitesServer{logic: impl.PhishingSitesLogic}
+}
+
+// CheckSites 批量检查
+func (s *phishingSitesServer) CheckSites(ctx context.Context, req *pb.CheckSitesRequest) (*pb.CheckSitesResponse, error) {
+	rsp, err := s.logic.CheckSites(ctx, req.GetSites())
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.CheckResult, 0, len(rsp))
+	for _, item := range rsp {
+		results = append(results, &pb.CheckResult{Query: item.Query, Domain: item.Domain, Source: item.Source})
+	}
+	return &pb.CheckSitesResponse{Results: results}, nil
+}
+
+// CheckSite 检查单个域名
+func (s *phishingSitesServer) CheckSite(ctx context.Context, req *pb.CheckSiteRequest) (*pb.CheckSiteResponse, error) {
+	rsp, err := s.logic.CheckSites(ctx, api.CheckSitesReq{req.GetSite()})
+	if err != nil {
+		return nil, err
+	}
+	if len(rsp) == 0 {
+		return &pb.CheckSiteResponse{}, nil
+	}
+	return &pb.CheckSiteResponse{
+		Hit:    true,
+		Result: &pb.CheckResult{Query: rsp[0].Query, Domain: rsp[0].Domain, Source: rsp[0].Source},
+	}, nil
+}
+
+// Stats 缓存统计及数据新鲜度
+func (s *phishingSitesServer) Stats(ctx context.Context, req *pb.StatsRequest) (*pb.StatsResponse, error) {
+	rsp, err := s.logic.Stats(ctx, api.PhishingSitesStatsReq{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.StatsResponse{
+		Total:                 int64(rsp.Total),
+		Sources:               toInt64Map(rsp.Sources),
+		Version:               rsp.Version,
+		LoadCount:             int64(rsp.LoadCount),
+		LoadFailCount:         int64(rsp.LoadFailCount),
+		LastLoadAt:            rsp.LastLoadAt,
+		LastLoadSuccessAt:     rsp.LastLoadSuccessAt,
+		LastLoadDurationMs:    rsp.LastLoadDurationMs,
+		RecentLoadDurationsMs: rsp.RecentLoadDurations,
+		LastLoadSources:       toInt64Map(rsp.LastLoadSources),
+		LastImportAt:          rsp.LastImportAt,
+		LastImportDurationMs:  rsp.LastImportDuration,
+		DataUpdatedAt:         rsp.DataUpdatedAt,
+		LastError:             rsp.LastError,
+		LastErrorAt:           rsp.LastErrorAt,
+	}, nil
+}
+
+// toInt64Map 转换计数map
+func toInt64Map(m map[string]int) map[string]int64 {
+	out := make(map[string]int64, len(m))
+	for k, v := range m {
+		out[k] = int64(v)
+	}
+	return out
+}
@@ -0,0 +1,292 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"godex/internal/errors"
+	"godex/pkg/auth"
+	"godex/pkg/constant"
+	"godex/pkg/errs"
+	"godex/pkg/logger"
+	"godex/pkg/metrics"
+	"godex/pkg/pb"
+	"godex/pkg/ratelimit"
+	"godex/pkg/retcode"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"math"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// healthServicePrefix 健康检查服务的方法前缀，不需要认证，供负载均衡及容器探针使用
+const healthServicePrefix = "/grpc.health.v1.Health/"
+
+// 元数据键
+const (
+	requestIDKey = "x-request-id" // 请求ID，与HTTP的X-Request-ID一致
+	errorCodeKey = "x-godex-code" // 业务错误码，与APIResponse.code一致
+	apiKeyKey    = "x-api-key"    // 静态API Key，与HTTP的X-Api-Key一致
+)
+
+// ServerOption gRPC服务器选项函数类型
+type ServerOption func(*serverOptions)
+
+// serverOptions gRPC服务器选项
+type serverOptions struct {
+	authenticator *auth.Authenticator
+	limiter       *ratelimit.Limiter
+}
+
+// WithAuthenticator 校验元数据x-api-key中的API Key(gRPC不支持HMAC签名)，未设置时不认证
+func WithAuthenticator(authenticator *auth.Authenticator) ServerOption {
+	return func(o *serverOptions) {
+		o.authenticator = authenticator
+	}
+}
+
+// WithRateLimiter 认证前按对端IP、认证后按客户端ID限流，路由为完整方法名(如/godex.v1.PhishingSites/CheckSites)，未设置时不限流
+func WithRateLimiter(limiter *ratelimit.Limiter) ServerOption {
+	return func(o *serverOptions) {
+		o.limiter = limiter
+	}
+}
+
+// requestIDCtxKey 请求ID在context中的键
+type requestIDCtxKey struct{}
+
+// NewServer 创建gRPC服务器，注册钓鱼网站检测服务、健康检查与反射
+// 认证及限流与HTTP的/browserext接口使用相同的配置，健康检查不需要认证
+func NewServer(options ...ServerOption) *grpc.Server {
+	var o serverOptions
+	for _, option := range options {
+		option(&o)
+	}
+	interceptors := []grpc.UnaryServerInterceptor{
+		requestIDInterceptor, // 自动生成/传递 x-request-id
+		loggerInterceptor,    // 请求日志
+		errorInterceptor,     // errs错误转换为gRPC status
+		recoveryInterceptor,  // panic保护
+	}
+	if o.limiter != nil {
+		interceptors = append(interceptors, preAuthRateLimitInterceptor(o.limiter)) // 认证前按对端IP限流
+	}
+	if o.authenticator != nil {
+		interceptors = append(interceptors, authInterceptor(o.authenticator)) // API Key认证
+	}
+	if o.limiter != nil {
+		interceptors = append(interceptors, rateLimitInterceptor(o.limiter)) // 按客户端限流
+	}
+	grpcOptions := []grpc.ServerOption{grpc.ChainUnaryInterceptor(interceptors...)}
+	if o.authenticator != nil {
+		// 反射等流式接口不经过一元拦截器
+		grpcOptions = append(grpcOptions, grpc.ChainStreamInterceptor(streamAuthInterceptor(o.authenticator)))
+	}
+	server := grpc.NewServer(grpcOptions...)
+
+	pb.RegisterPhishingSitesServer(server, newPhishingSitesServer())
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(pb.PhishingSites_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server
+}
+
+// RequestID 获取gRPC请求的请求ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// recoveryInterceptor panic保护，返回Internal错误
+func recoveryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (rsp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("grpc panic in %s: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = errs.Newf(errors.InternalError, "Internal Server Error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// requestIDInterceptor 从元数据读取请求ID，没有则生成，并写回响应头
+func requestIDInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	logger.IgnoreError(grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id)))
+	return handler(context.WithValue(ctx, requestIDCtxKey{}, id), req)
+}
+
+// loggerInterceptor 请求日志，字段与HTTP日志中间件保持一致
+func loggerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	rsp, err := handler(ctx, req)
+	latency := math.Round(time.Since(start).Seconds()*10000) / 10000
+
+	ip := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+	}
+	request, _ := json.Marshal(req)
+	jsonData, _ := json.Marshal(logrus.Fields{
+		constant.TraceIDKey: RequestID(ctx),
+		constant.IPKey:      ip,
+		constant.MethodKey:  "GRPC",
+		constant.PathKey:    info.FullMethod,
+		constant.RequestKey: string(request),
+		constant.StatusKey:  status.Code(err).String(),
+		constant.LatencyKey: latency,
+	})
+	logger.Info("Request metadata: " + string(jsonData))
+	return rsp, err
+}
+
+// errorInterceptor 将errs错误转换为gRPC status错误，业务错误码通过trailer x-godex-code返回
+func errorInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	rsp, err := handler(ctx, req)
+	if err == nil {
+		return rsp, nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return rsp, err
+	}
+
+	logger.Errorf("grpc handler error: %+v", err)
+	logger.IgnoreError(grpc.SetTrailer(ctx, metadata.Pairs(errorCodeKey, strconv.Itoa(errs.Code(err)))))
+	return rsp, toStatusError(err)
+}
+
+// toStatusError 将errs错误按错误类型映射为gRPC status错误
+func toStatusError(err error) error {
+	code := errs.Code(err)
+	grpcCode := codes.Internal
+	switch {
+	case code == errors.DataNotReady:
+		grpcCode = codes.Unavailable
+	case code >= 1000:
+		switch retcode.ErrorType(code / 1000 % 100) {
+		case retcode.ErrorTypeParamsInvalid:
+			grpcCode = codes.InvalidArgument
+		case retcode.ErrorTypeReqLimit:
+			grpcCode = codes.ResourceExhausted
+		case retcode.ErrorTypeAuthFail:
+			grpcCode = codes.Unauthenticated
+		case retcode.ErrorTypeBusinessErr:
+			grpcCode = codes.FailedPrecondition
+		}
+	case code == errs.RetClientEncodeFail || code == errs.RetClientValidateFail:
+		grpcCode = codes.InvalidArgument
+	}
+	return status.Error(grpcCode, fmt.Sprintf("code:%d, msg:%s", code, errs.Msg(err)))
+}
+
+// authInterceptor 校验元数据x-api-key中的API Key，认证通过的调用方放入ctx
+func authInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, authenticator)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamAuthInterceptor 流式接口(如反射)的API Key认证
+func streamAuthInterceptor(authenticator *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(srv, stream)
+		}
+		if _, err := authenticate(stream.Context(), authenticator); err != nil {
+			return toStatusError(err)
+		}
+		return handler(srv, stream)
+	}
+}
+
+// authenticate 校验元数据中的API Key，返回携带调用方信息的ctx
+func authenticate(ctx context.Context, authenticator *auth.Authenticator) (context.Context, error) {
+	key := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(apiKeyKey); len(values) > 0 {
+			key = values[0]
+		}
+	}
+	identity, err := authenticator.AuthenticateAPIKey(key)
+	if err != nil {
+		return ctx, errs.Newf(errors.Unauthorized, "%v", err)
+	}
+	return auth.WithIdentity(ctx, identity), nil
+}
+
+// preAuthRateLimitInterceptor 认证前按对端IP限流(所有方法共用)，被拒绝的凭证同样计数
+func preAuthRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := rateLimited(info.FullMethod, limiter.AllowPreAuth("ip:"+peerIP(ctx))); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitInterceptor 按方法令牌桶限流，认证通过的请求按客户端ID计数，否则按对端IP计数
+// gRPC请求均为POST，限流配置中的路由为完整方法名
+func rateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key := "ip:" + peerIP(ctx)
+		if clientID := auth.ClientID(ctx); clientID != "" {
+			key = "client:" + clientID
+		}
+		if err := rateLimited(info.FullMethod, limiter.Allow(http.MethodPost, info.FullMethod, key)); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimited 被拒绝时返回限流错误，转换为ResourceExhausted
+func rateLimited(method string, decision ratelimit.Decision) error {
+	if !decision.Limited || decision.Allowed {
+		return nil
+	}
+	metrics.RateLimited.WithLabelValues(method).Inc()
+	return errs.Newf(errors.RateLimitExceeded, "rate limit exceeded, retry after %s", decision.RetryAfter.Round(time.Millisecond))
+}
+
+// peerIP 对端IP，gRPC不经过反向代理，不读取转发头
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"context"
+	"godex/internal/errors"
+	"godex/internal/logic"
+	"godex/internal/logic/impl"
+	"godex/pkg/api"
+	"godex/pkg/auth"
+	"godex/pkg/errs"
+	"godex/pkg/pb"
+	"godex/pkg/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+)
+
+// fakeLogic 固定的检查结果，查询panic.test时panic
+type fakeLogic struct {
+	logic.PhishingSitesLogic
+}
+
+func (fakeLogic) CheckSites(ctx context.Context, req api.CheckSitesReq) (api.CheckSitesRsp, error) {
+	rsp := api.CheckSitesRsp{}
+	for _, site := range req {
+		switch site {
+		case "panic.test":
+			panic("boom")
+		case "evil.com":
+			rsp = append(rsp, struct {
+				Query  string `json:"query"`
+				Domain string `json:"domain"`
+				Source string `json:"source"`
+			}{Query: site, Domain: site, Source: "s1"})
+		}
+	}
+	return rsp, nil
+}
+
+// startServer 使用fakeLogic启动gRPC服务器，返回客户端连接
+func startServer(t *testing.T, options ...ServerOption) *grpc.ClientConn {
+	original := impl.PhishingSitesLogic
+	impl.PhishingSitesLogic = fakeLogic{}
+	server := NewServer(options...)
+	impl.PhishingSitesLogic = original
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestServerCheckSites(t *testing.T) {
+	client := pb.NewPhishingSitesClient(startServer(t))
+
+	var header metadata.MD
+	rsp, err := client.CheckSites(context.Background(), &pb.CheckSitesRequest{Sites: []string{"evil.com", "good.com"}}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("CheckSites: %v", err)
+	}
+	if len(rsp.GetResults()) != 1 || rsp.GetResults()[0].GetDomain() != "evil.com" || rsp.GetResults()[0].GetSource() != "s1" {
+		t.Fatalf("results = %v", rsp.GetResults())
+	}
+	if len(header.Get(requestIDKey)) != 1 {
+		t.Fatalf("header = %v, want %s", header, requestIDKey)
+	}
+
+	// panic转换为Internal，错误码通过trailer返回
+	var trailer metadata.MD
+	_, err = client.CheckSite(context.Background(), &pb.CheckSiteRequest{Site: "panic.test"}, grpc.Trailer(&trailer))
+	if status.Code(err) != codes.Internal || len(trailer.Get(errorCodeKey)) != 1 {
+		t.Fatalf("panic = %v, trailer = %v", err, trailer)
+	}
+}
+
+func TestServerAuth(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(auth.Config{Enable: true, Clients: []auth.ClientConfig{{ID: "c1", APIKey: "k1"}}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	conn := startServer(t, WithAuthenticator(authenticator))
+	client := pb.NewPhishingSitesClient(conn)
+	req := &pb.CheckSitesRequest{Sites: []string{"evil.com"}}
+
+	for name, ctx := range map[string]context.Context{
+		"missing": context.Background(),
+		"bad":     metadata.AppendToOutgoingContext(context.Background(), apiKeyKey, "bad"),
+	} {
+		if _, err = client.CheckSites(ctx, req); status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("%s key: %v", name, err)
+		}
+	}
+	if _, err = client.CheckSites(metadata.AppendToOutgoingContext(context.Background(), apiKeyKey, "k1"), req); err != nil {
+		t.Fatalf("valid key: %v", err)
+	}
+
+	// 健康检查不需要认证
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil || health.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("health = %v, %v", health, err)
+	}
+}
+
+func TestServerRateLimit(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(auth.Config{Enable: true, Clients: []auth.ClientConfig{{ID: "c1", APIKey: "k1"}}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	limiter, err := ratelimit.NewLimiter(ratelimit.Config{
+		Enable: true,
+		Routes: []ratelimit.RouteLimit{{Path: pb.PhishingSites_CheckSites_FullMethodName, Limit: ratelimit.Limit{Rate: 0.001, Burst: 1}}},
+	})
+	if err != nil {
+		t.Fatalf("NewLimiter: %v", err)
+	}
+	client := pb.NewPhishingSitesClient(startServer(t, WithAuthenticator(authenticator), WithRateLimiter(limiter)))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), apiKeyKey, "k1")
+	req := &pb.CheckSitesRequest{Sites: []string{"evil.com"}}
+
+	if _, err = client.CheckSites(ctx, req); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if _, err = client.CheckSites(ctx, req); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second call: %v", err)
+	}
+	// 其他方法不受该路由限额影响
+	if _, err = client.CheckSite(ctx, &pb.CheckSiteRequest{Site: "evil.com"}); err != nil {
+		t.Fatalf("other method: %v", err)
+	}
+}
+
+func TestToStatusError(t *testing.T) {
+	for code, want := range map[int]codes.Code{
+		errors.RequestParamInvalid: codes.InvalidArgument,
+		errors.DataNotReady:        codes.Unavailable,
+		errors.RateLimitExceeded:   codes.ResourceExhausted,
+		errors.Unauthorized:        codes.Unauthenticated,
+		errors.InternalConfigErr:   codes.FailedPrecondition,
+		errors.InternalError:       codes.Internal,
+	} {
+		if got := status.Code(toStatusError(errs.New(code, "msg"))); got != want {
+			t.Errorf("code %d = %s, want %s", code, got, want)
+		}
+	}
+}
@@ -8,16 +8,51 @@ import (
 	"godex/internal/conf"
 	"godex/internal/controller"
 	"godex/internal/errors"
+	"godex/internal/rpc"
 	"godex/internal/service"
+	"godex/internal/sinkhole"
 	"godex/internal/task"
+	"godex/pkg/api"
+	"godex/pkg/auth"
 	"godex/pkg/errs"
 	"godex/pkg/logger"
+	"godex/pkg/ratelimit"
+	"godex/pkg/tracing"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"net"
+	"net/http"
+	"strings"
+	"time"
 )
 
+// webhookCloseTimeout 退出时等待Webhook事件投递完成的最长时间
+const webhookCloseTimeout = 10 * time.Second
+
+// reporterCloseTimeout 退出时等待剩余上报发送的最长时间，超时的批次写入spool
+const reporterCloseTimeout = 10 * time.Second
+
+// taskStopTimeout 退出时等待执行中的定时任务响应取消并结束的最长时间
+const taskStopTimeout = 10 * time.Second
+
+// tracingShutdownTimeout 退出时等待已结束的span导出完成的最长时间
+const tracingShutdownTimeout = 5 * time.Second
+
+// httpShutdownTimeout 退出时等待进行中的HTTP请求完成的最长时间
+const httpShutdownTimeout = 10 * time.Second
+
 // Serve 服务器结构体
 type Serve struct {
-	app     *iris.Application
-	options *ServeOptions
+	app        *iris.Application
+	grpcServer *grpc.Server
+	dnsServer  *sinkhole.Server
+	options    *ServeOptions
+
+	// shutdownTracing 刷新并关闭链路追踪导出器
+	shutdownTracing func(ctx context.Context) error
+	// stopped 退出流程(shutdown)执行完成时关闭
+	stopped chan struct{}
 }
 
 // ServeOptions 服务器配置选项
@@ -29,10 +64,13 @@ type ServeOptions struct {
 	enableWebServer  bool
 	enableCommand    bool
 	enableTask       bool
+	enableGRPC       bool
+	enableDNS        bool
 
 	// 配置选项
 	configPaths []string
 	port        int
+	grpcPort    int
 
 	// 自定义初始化函数
 	customInitFuncs []func() error
@@ -91,6 +129,28 @@ func WithPort(port int) Option {
 	}
 }
 
+// WithGRPC 启用gRPC服务(也可通过配置system.service.grpc.enable启用)
+func WithGRPC() Option {
+	return func(opts *ServeOptions) {
+		opts.enableGRPC = true
+	}
+}
+
+// WithGRPCPort 启用gRPC服务并设置独立端口，为0或与Web端口相同时与Web服务共用端口
+func WithGRPCPort(port int) Option {
+	return func(opts *ServeOptions) {
+		opts.enableGRPC = true
+		opts.grpcPort = port
+	}
+}
+
+// WithDNS 启用DNS拦截解析服务(也可通过配置system.dns.enable启用)
+func WithDNS() Option {
+	return func(opts *ServeOptions) {
+		opts.enableDNS = true
+	}
+}
+
 // WithCustomInit 添加自定义初始化函数
 func WithCustomInit(initFunc func() error) Option {
 	return func(opts *ServeOptions) {
@@ -107,6 +167,16 @@ func WithCommandDefault() Option {
 	}
 }
 
+// WithDNSDefault 仅启用DNS拦截解析服务（包括定时任务，不启动Web服务），对应启动参数dns
+func WithDNSDefault() Option {
+	return func(opts *ServeOptions) {
+		opts.enableConfig = true
+		opts.enableLogger = true
+		opts.enableTask = true
+		opts.enableDNS = true
+	}
+}
+
 // WithWebDefault 启用所有默认组件（包括定时任务）
 func WithWebDefault() Option {
 	return func(opts *ServeOptions) {
@@ -130,13 +200,16 @@ func NewServe(options ...Option) *Serve {
 	// 创建iris应用
 	app := iris.New()
 	// 禁用iris的默认启动日志，我们将手动输出
+	// 禁用iris的默认退出处理，由shutdown按顺序关闭Web服务及其他组件
 	app.Configure(iris.WithConfiguration(iris.Configuration{
-		DisableStartupLog: true,
+		DisableStartupLog:       true,
+		DisableInterruptHandler: true,
 	}))
 
 	return &Serve{
 		app:     app,
 		options: opts,
+		stopped: make(chan struct{}),
 	}
 }
 
@@ -156,6 +229,20 @@ func (s *Serve) Run() error {
 		}
 	}
 
+	// 3. 启动报告器，上报策略配置有误时启动失败；仅服务模式重新上报spool，避免命令与服务同时重新上报同一spool目录
+	if s.options.enableConfig && conf.AppConfig.System.Report.Enable {
+		if err := service.StartReporter(!s.options.enableCommand); err != nil {
+			return errs.Newf(errors.InternalError, "failed to start reporter: %v", err)
+		}
+	}
+
+	// 4. 初始化链路追踪
+	if s.options.enableConfig {
+		if err := s.initTracing(); err != nil {
+			return errs.Newf(errors.InternalError, "failed to initialize tracing: %v", err)
+		}
+	}
+
 	// 5. 执行自定义初始化函数
 	for _, initFunc := range s.options.customInitFuncs {
 		if err := initFunc(); err != nil {
@@ -177,16 +264,40 @@ func (s *Serve) Run() error {
 		}
 	}
 
-	// 8. 执行命令（如果启用）
+	// 8. 初始化gRPC服务，命令模式不启动，避免与运行中的服务争用端口
+	enableGRPC := s.options.enableGRPC || (s.options.enableConfig && conf.AppConfig.System.Service.GRPC.Enable)
+	if enableGRPC && !s.options.enableCommand {
+		if err := s.initGRPC(); err != nil {
+			return errs.Newf(errors.InternalError, "failed to initialize grpc server: %v", err)
+		}
+	}
+
+	// 9. 启动DNS拦截解析服务，命令模式不启动，避免与运行中的服务争用端口
+	enableDNS := !s.options.enableCommand && (s.options.enableDNS || (s.options.enableConfig && conf.AppConfig.System.DNS.Enable))
+	if enableDNS {
+		if err := s.initDNS(); err != nil {
+			return errs.Newf(errors.InternalError, "failed to initialize dns server: %v", err)
+		}
+	}
+
+	// 10. 执行命令（如果启用）
 	if s.options.enableCommand {
 		return s.executeCommand()
 	}
 
-	// 9. 启动Web服务（如果启用）
+	// 收到退出信号时按顺序关闭各组件
+	iris.RegisterOnInterrupt(s.shutdown)
+
+	// 11. 启动Web服务（如果启用）
 	if s.options.enableWebServer {
 		return s.initWeb()
 	}
 
+	// 12. 仅启用DNS服务时，阻塞直到收到退出信号
+	if enableDNS {
+		s.waitForInterrupt()
+	}
+
 	return nil
 }
 
@@ -203,6 +314,27 @@ func (s *Serve) initLogger() error {
 	return logger.InitLogger(conf.AppConfig.System.Log)
 }
 
+func (s *Serve) initTracing() error {
+	shutdown, err := tracing.Init(conf.AppConfig.System.Tracing, conf.AppConfig.System.Service.Name)
+	if err != nil {
+		return err
+	}
+	s.shutdownTracing = shutdown
+	return nil
+}
+
+// closeTracing 导出剩余的span，未初始化时不做处理
+func (s *Serve) closeTracing() {
+	if s.shutdownTracing == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+	if err := s.shutdownTracing(ctx); err != nil {
+		logger.Warnf("Failed to shutdown tracing: %v", err)
+	}
+}
+
 func (s *Serve) initCache() error {
 	// 启动时加载,用于快速响应
 	go func() {
@@ -213,37 +345,161 @@ func (s *Serve) initCache() error {
 
 func (s *Serve) initController() error {
 	// 设置路由
-	controller.Routing(s.app)
-	return nil
+	return controller.Routing(s.app)
 }
 
 func (s *Serve) executeCommand() error {
 	// 注册所有命令
 	command.RegisterCommands()
 
-	// 执行命令
-	return command.Execute()
+	// 执行命令，结束前等待命令产生的Webhook事件投递完成、发送剩余上报并导出span
+	err := command.Execute()
+	service.CloseWebhooks(webhookCloseTimeout)
+	service.CloseReporter(reporterCloseTimeout)
+	s.closeTracing()
+	return err
 }
 
 func (s *Serve) initTask() error {
 	return task.InitTask()
 }
 
-func (s *Serve) initWeb() error {
-	var port int
-	if s.options.port > 0 {
-		port = s.options.port
-	} else {
-		port = conf.AppConfig.System.Service.Port
+func (s *Serve) initGRPC() error {
+	// 与/browserext接口使用相同的认证及限流配置
+	var options []rpc.ServerOption
+	if authConfig := conf.AppConfig.System.Auth; authConfig.Enable {
+		authenticator, err := auth.NewAuthenticator(authConfig)
+		if err != nil {
+			return err
+		}
+		options = append(options, rpc.WithAuthenticator(authenticator))
+	}
+	if rateLimitConfig := conf.AppConfig.System.RateLimit; rateLimitConfig.Enable {
+		limiter, err := ratelimit.NewLimiter(rateLimitConfig)
+		if err != nil {
+			return err
+		}
+		options = append(options, rpc.WithRateLimiter(limiter))
+	}
+	s.grpcServer = rpc.NewServer(options...)
+
+	port := s.grpcListenPort()
+	if port == 0 {
+		// 与Web服务共用端口，由initWeb通过h2c分发
+		logger.Infof("🚀 [gRPC] Sharing port with web server")
+		return nil
 	}
 
 	portStr := fmt.Sprintf(":%d", port)
+	listener, err := net.Listen("tcp", portStr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		logger.Infof("🚀 [gRPC] Server started. listening on %s.", portStr)
+		if err := s.grpcServer.Serve(listener); err != nil {
+			logger.Errorf("Error serving grpc: %v", err)
+		}
+	}()
+	return nil
+}
+
+// grpcListenPort gRPC独立端口，返回0表示与Web服务共用端口
+func (s *Serve) grpcListenPort() int {
+	port := s.options.grpcPort
+	if port == 0 && s.options.enableConfig {
+		port = conf.AppConfig.System.Service.GRPC.Port
+	}
+	if port == s.webPort() {
+		return 0
+	}
+	return port
+}
+
+// webPort Web服务端口
+func (s *Serve) webPort() int {
+	if s.options.port > 0 {
+		return s.options.port
+	}
+	if s.options.enableConfig {
+		return conf.AppConfig.System.Service.Port
+	}
+	return 0
+}
+
+func (s *Serve) initDNS() error {
+	s.dnsServer = sinkhole.NewServer(conf.AppConfig.System.DNS, service.NewPhishingSitesService())
+	if err := s.dnsServer.Start(); err != nil {
+		s.dnsServer = nil
+		return err
+	}
+	logger.Infof("🚀 [DNS] Sinkhole resolver started. listening on %s (udp/tcp), upstreams: %v",
+		conf.AppConfig.System.DNS.Listen, conf.AppConfig.System.DNS.Upstreams)
+	return nil
+}
+
+// waitForInterrupt 阻塞直到收到退出信号且退出流程执行完成
+func (s *Serve) waitForInterrupt() {
+	<-s.stopped
+}
+
+// shutdown 按顺序退出：先停止接收请求(HTTP、gRPC、DNS)及定时任务，再输出剩余的命中上报、Webhook事件及span，
+// 避免退出过程中产生的上报、事件及span写入已关闭的组件
+func (s *Serve) shutdown() {
+	defer close(s.stopped)
+
+	if s.options.enableWebServer {
+		// 先结束SSE及WebSocket订阅连接，否则Web服务需等待至关闭超时
+		api.CloseStreams()
+		ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		if err := s.app.Shutdown(ctx); err != nil {
+			logger.Warnf("Web server not stopped gracefully: %v", err)
+		}
+		cancel()
+		logger.Info("Web server stopped")
+	}
+	if s.grpcServer != nil && s.grpcListenPort() != 0 {
+		s.grpcServer.GracefulStop()
+		logger.Info("gRPC server stopped")
+	}
+	if s.dnsServer != nil {
+		s.dnsServer.Shutdown()
+		logger.Infof("DNS server stopped, verdicts: %v", s.dnsServer.Counters().Snapshot())
+	}
+	if s.options.enableTask {
+		task.StopTask(taskStopTimeout)
+	}
+
+	service.CloseReporter(reporterCloseTimeout)
+	service.CloseWebhooks(webhookCloseTimeout)
+	s.closeTracing()
+	logger.Info("Application stopped")
+}
+
+func (s *Serve) initWeb() error {
+	portStr := fmt.Sprintf(":%d", s.webPort())
 
 	logger.Infof("🚀 [Web] Application started. listening on %s. Press CTRL+C to shut down.", portStr)
 
-	if err := s.app.Listen(portStr); err != nil {
+	var err error
+	if s.grpcServer != nil && s.grpcListenPort() == 0 {
+		// gRPC与Web共用端口：HTTP/2且Content-Type为application/grpc的请求交给gRPC服务
+		srv := &http.Server{Addr: portStr, Handler: h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+				s.grpcServer.ServeHTTP(w, r)
+				return
+			}
+			s.app.ServeHTTP(w, r)
+		}), &http2.Server{})}
+		err = s.app.Run(iris.Server(srv))
+	} else {
+		err = s.app.Listen(portStr)
+	}
+	if err != nil && err != iris.ErrServerClosed {
 		logger.Fatalf("Error starting server: %v", err)
 		return err
 	}
+	// Web服务关闭后等待退出流程完成，避免进程在发送剩余上报前退出
+	<-s.stopped
 	return nil
 }
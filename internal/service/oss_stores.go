@@ -5,8 +5,14 @@ import (
 	"context"
 	"fmt"
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"godex/internal/conf"
+	"godex/pkg/metrics"
+	"godex/pkg/tracing"
 	"io"
+	"net/http"
+	"time"
 )
 
 // OssStoresService OSS存储服务
@@ -32,7 +38,12 @@ func NewOssStoresService() *OssStoresService {
 // Upload 上传文件
 func (s *OssStoresService) Upload(ctx context.Context, objectName string, data string) error {
 	fullObjectName := fmt.Sprintf("%s/%s", conf.AppConfig.System.Env, objectName)
-	err := s.bucket.PutObject(fullObjectName, bytes.NewReader([]byte(data)))
+	ctx, span := startOssSpan(ctx, "PutObject", fullObjectName)
+	start := time.Now()
+	err := s.bucket.PutObject(fullObjectName, bytes.NewReader([]byte(data)), oss.WithContext(ctx))
+	metrics.ObserveUpstream("oss", "put_object", start, err)
+	span.SetAttributes(attribute.Int("oss.size", len(data)))
+	tracing.End(span, err)
 	if err != nil {
 		return fmt.Errorf("上传文件失败: %v", err)
 	}
@@ -42,15 +53,52 @@ func (s *OssStoresService) Upload(ctx context.Context, objectName string, data s
 // Download 下载文件
 func (s *OssStoresService) Download(ctx context.Context, objectName string) (string, error) {
 	fullObjectName := fmt.Sprintf("%s/%s", conf.AppConfig.System.Env, objectName)
-	reader, err := s.bucket.GetObject(fullObjectName)
+	ctx, span := startOssSpan(ctx, "GetObject", fullObjectName)
+	start := time.Now()
+	reader, err := s.bucket.GetObject(fullObjectName, oss.WithContext(ctx))
 	if err != nil {
+		metrics.ObserveUpstream("oss", "get_object", start, err)
+		tracing.End(span, err)
 		return "", fmt.Errorf("获取文件失败: %v", err)
 	}
 	defer reader.Close()
 
 	data, err := io.ReadAll(reader)
+	metrics.ObserveUpstream("oss", "get_object", start, err)
+	span.SetAttributes(attribute.Int("oss.size", len(data)))
+	tracing.End(span, err)
 	if err != nil {
 		return "", fmt.Errorf("读取文件失败: %v", err)
 	}
 	return string(data), nil
 }
+
+// LastModified 获取文件最后修改时间
+func (s *OssStoresService) LastModified(ctx context.Context, objectName string) (time.Time, error) {
+	fullObjectName := fmt.Sprintf("%s/%s", conf.AppConfig.System.Env, objectName)
+	ctx, span := startOssSpan(ctx, "GetObjectMeta", fullObjectName)
+	start := time.Now()
+	header, err := s.bucket.GetObjectMeta(fullObjectName, oss.WithContext(ctx))
+	metrics.ObserveUpstream("oss", "get_object_meta", start, err)
+	tracing.End(span, err)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("获取文件元信息失败: %v", err)
+	}
+
+	lastModified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("解析文件修改时间失败: %v", err)
+	}
+	return lastModified, nil
+}
+
+// startOssSpan 开始一个OSS调用的client span
+func startOssSpan(ctx context.Context, operation, objectName string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "oss "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("peer.service", "oss"),
+			attribute.String("oss.bucket", conf.AppConfig.AppSetting.BucketName),
+			attribute.String("oss.object", objectName),
+		))
+}
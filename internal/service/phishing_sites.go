@@ -4,13 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"go.opentelemetry.io/otel/attribute"
 	"godex/internal/cache"
 	"godex/internal/conf"
 	"godex/internal/entity"
 	"godex/internal/resty"
+	"godex/pkg/auth"
+	"godex/pkg/blocklist"
+	"godex/pkg/hashprefix"
+	"godex/pkg/listsync"
 	"godex/pkg/logger"
+	"godex/pkg/metrics"
 	"godex/pkg/report"
+	"godex/pkg/tracing"
+	"io"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,22 +29,42 @@ const PhishingSitesSourceFixedSniffer = "fixed-sniffer"
 
 // PhishingSitesService 服务
 type PhishingSitesService struct {
-	reporter    *report.Reporter
 	ossStoreSvc *OssStoresService
 }
 
 // NewPhishingSitesService 创建服务实例
 func NewPhishingSitesService() *PhishingSitesService {
 	return &PhishingSitesService{
-		reporter:    report.NewReporter(conf.AppConfig.System.Report),
 		ossStoreSvc: NewOssStoresService(),
 	}
 }
 
+// loadMu 串行化缓存加载，避免并发加载(定时任务与启动加载等)交错写入及清理缓存
+var loadMu sync.Mutex
+
 // LoadPhishingSites2Cache 加载到cache
-func (s *PhishingSitesService) LoadPhishingSites2Cache(ctx context.Context) error {
+func (s *PhishingSitesService) LoadPhishingSites2Cache(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "PhishingSitesService.LoadPhishingSites2Cache")
+	defer func() { tracing.End(span, err) }()
+
+	// 清理、重建索引及提交快照也在锁内完成
+	loadMu.Lock()
+	defer loadMu.Unlock()
 	logger.Info("开始加载数据到内存")
 
+	// 记录加载统计，部分加载失败时也记录已写入的条数
+	start := time.Now()
+	sources := map[string]int{}
+	defer func() {
+		cache.PhishingSitesStats.RecordLoad(start, sources, err)
+		s.rebuildHashIndex()
+		if err == nil {
+			s.commitSnapshotHistory()
+		}
+	}()
+	// 本次加载写入的域名，全部加载成功后用于清理已下线的域名
+	loaded := map[string]struct{}{}
+
 	// 1. 先加载固定配置中的
 	fixedCount := 0
 	if conf.AppConfig.AppSetting.FixedSniffer != nil {
@@ -50,20 +80,33 @@ func (s *PhishingSitesService) LoadPhishingSites2Cache(ctx context.Context) erro
 				Source: PhishingSitesSourceFixedSniffer,
 			}
 			cache.PhishingSitesCache.Store(domainStd, cacheItem)
+			loaded[domainStd] = struct{}{}
 			fixedCount++
 		}
+		sources[PhishingSitesSourceFixedSniffer] = fixedCount
 		logger.Infof("Successfully loaded %d fixed phishing sites from config to cache", fixedCount)
 	}
 
 	// 2. 再从OSS加载数据
-	download, err := s.ossStoreSvc.Download(ctx, fmt.Sprintf("%s-domains.json", PhishingSitesSourceScamSniffer))
+	objectName := fmt.Sprintf("%s-domains.json", PhishingSitesSourceScamSniffer)
+	download, err := s.ossStoreSvc.Download(ctx, objectName)
 	if err != nil {
 		logger.Errorf("Download PhishingSites failed: %v", err)
 		return err
 	}
 
+	// 记录数据文件的更新时间，用于判断数据是否陈旧
+	if updatedAt, metaErr := s.ossStoreSvc.LastModified(ctx, objectName); metaErr != nil {
+		logger.Warnf("Get PhishingSites last modified failed: %v", metaErr)
+	} else {
+		cache.PhishingSitesStats.RecordDataUpdatedAt(updatedAt)
+	}
+
 	sites := []string{}
-	err = json.Unmarshal([]byte(download), &sites)
+	if err = json.Unmarshal([]byte(download), &sites); err != nil {
+		logger.Errorf("Unmarshal PhishingSites failed: %v", err)
+		return err
+	}
 
 	// 将数据库中的sites写入到缓存 - sync.Map是线程安全的
 	for _, site := range sites {
@@ -73,87 +116,369 @@ func (s *PhishingSitesService) LoadPhishingSites2Cache(ctx context.Context) erro
 		}
 		// 使用域名作为key，sync.Map的Store方法是线程安全的
 		cache.PhishingSitesCache.Store(site, cacheItem)
+		loaded[site] = struct{}{}
 	}
 
+	// 全部数据源加载成功后，清理本次未出现的域名，保证缓存与数据源一致
+	removedCount := 0
+	cache.PhishingSitesCache.Range(func(key, value any) bool {
+		if _, ok := loaded[key.(string)]; !ok {
+			cache.PhishingSitesCache.Delete(key)
+			removedCount++
+		}
+		return true
+	})
+
 	ossCount := len(sites)
+	sources[PhishingSitesSourceScamSniffer] = ossCount
 	logger.Infof("Successfully loaded %d phishing sites from oss to cache", ossCount)
 
-	logger.Infof("Total loaded %d phishing sites to cache (fixed-config: %d, database: %d)",
-		fixedCount+ossCount, fixedCount, ossCount)
+	logger.Infof("Total loaded %d phishing sites to cache (fixed-config: %d, database: %d, removed: %d)",
+		fixedCount+ossCount, fixedCount, ossCount, removedCount)
 	return nil
 }
 
+// Stats 获取缓存统计
+func (s *PhishingSitesService) Stats(ctx context.Context) (*entity.PhishingSitesStats, error) {
+	_, span := tracing.Start(ctx, "PhishingSitesService.Stats")
+	defer span.End()
+
+	// 统计当前缓存中各数据来源的条数
+	total := 0
+	sources := map[string]int{}
+	cache.PhishingSitesCache.Range(func(key, value any) bool {
+		phishingSite := value.(*entity.PhishingSite)
+		sources[phishingSite.Source]++
+		total++
+		return true
+	})
+
+	snapshot := cache.PhishingSitesStats.Snapshot()
+	recentDurations := make([]int64, 0, len(snapshot.RecentLoadDurations))
+	for _, duration := range snapshot.RecentLoadDurations {
+		recentDurations = append(recentDurations, duration.Milliseconds())
+	}
+
+	return &entity.PhishingSitesStats{
+		Total:               total,
+		Sources:             sources,
+		Version:             snapshot.Version,
+		LoadCount:           snapshot.LoadCount,
+		LoadFailCount:       snapshot.LoadFailCount,
+		LastLoadAt:          unixOrZero(snapshot.LastLoadAt),
+		LastLoadSuccessAt:   unixOrZero(snapshot.LastLoadSuccessAt),
+		LastLoadDurationMs:  snapshot.LastLoadDuration.Milliseconds(),
+		RecentLoadDurations: recentDurations,
+		LastLoadSources:     snapshot.LastLoadSources,
+		LastImportAt:        unixOrZero(snapshot.LastImportAt),
+		LastImportDuration:  snapshot.LastImportDuration.Milliseconds(),
+		DataUpdatedAt:       unixOrZero(snapshot.DataUpdatedAt),
+		LastError:           snapshot.LastError,
+		LastErrorAt:         unixOrZero(snapshot.LastErrorAt),
+	}, nil
+}
+
+// unixOrZero 转换为unix秒，零值时间返回0
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
 // CheckPhishingSitesWithCache 检查是否为
 func (s *PhishingSitesService) CheckPhishingSitesWithCache(ctx context.Context, sites []string) ([]*entity.PhishingSiteCheckRet, error) {
+	ctx, span := tracing.Start(ctx, "PhishingSitesService.CheckPhishingSitesWithCache", attribute.Int("sites", len(sites)))
+	defer span.End()
+
 	phishingSitesRet := []*entity.PhishingSiteCheckRet{}
+	allowList := newAllowList()
 
+	// 仅统计检查接口(HTTP及gRPC)的查询，DNS拦截及流式批量检查不计入
 	for _, site := range sites {
-		// 1. 将site转为小写并去除空格
-		siteStd := strings.ToLower(strings.TrimSpace(site))
-
-		// 2. 检查原始值是否存在于cache中
-		if val, exists := cache.PhishingSitesCache.Load(siteStd); exists {
-			phishingSite := val.(*entity.PhishingSite)
+		if strings.TrimSpace(site) != "" {
+			metrics.CheckLookups.Inc()
+		}
+		if phishingSite, exists := lookupSite(site, allowList); exists {
+			metrics.CheckHits.WithLabelValues(phishingSite.Source).Inc()
 			phishingSitesRet = append(phishingSitesRet, &entity.PhishingSiteCheckRet{
 				Query:  site,
 				Domain: phishingSite.Domain,
 				Source: phishingSite.Source,
 			})
-			continue // 如果原始值已找到，跳过后续检查避免重复
-		}
-
-		// 3. 如果site本身不带www，检查添加www.前缀的值是否存在于cache中
-		if !strings.HasPrefix(siteStd, "www.") {
-			wwwSite := "www." + siteStd
-			if val, exists := cache.PhishingSitesCache.Load(wwwSite); exists {
-				phishingSite := val.(*entity.PhishingSite)
-				phishingSitesRet = append(phishingSitesRet, &entity.PhishingSiteCheckRet{
-					Query:  site,
-					Domain: phishingSite.Domain,
-					Source: phishingSite.Source,
-				})
-			}
-		} else {
-			// 4. 如果site本身带有www，检查去掉www.前缀的值是否存在于cache中
-			nonWwwSite := strings.TrimPrefix(siteStd, "www.")
-			if val, exists := cache.PhishingSitesCache.Load(nonWwwSite); exists {
-				phishingSite := val.(*entity.PhishingSite)
-				phishingSitesRet = append(phishingSitesRet, &entity.PhishingSiteCheckRet{
-					Query:  site,
-					Domain: phishingSite.Domain,
-					Source: phishingSite.Source,
-				})
-			}
 		}
 	}
 
+	span.SetAttributes(attribute.Int("hits", len(phishingSitesRet)))
+
 	// 上报名中的到webbb平台
-	s.ReportWithPhishingSiteCheckRet(phishingSitesRet)
+	s.ReportWithPhishingSiteCheckRet(ctx, phishingSitesRet)
+	s.emitCheckHit(phishingSitesRet)
 	return phishingSitesRet, nil
 }
 
-// ReportWithPhishingSiteCheckRet 上报名中的到webbb平台
-func (s *PhishingSitesService) ReportWithPhishingSiteCheckRet(ret []*entity.PhishingSiteCheckRet) {
-	if conf.AppConfig.System.Report.Enable && len(ret) > 0 {
-		// 构建上报数据
-		nowMillis := time.Now().UnixMilli()
-		reportPayload := report.ReportPayload{}
-
-		for _, result := range ret {
-			reportItem := report.ReportPayloadItem{
-				OpRes: report.OpResOK, OpObjType: report.OpObjTypePhishing, // 31表示检测类型
-				OpObjValue: map[string]interface{}{"url": result.Query, "source": result.Source}, UserTimestamp: nowMillis, Timestamp: nowMillis,
+// MatchSite 检查单个域名是否命中缓存(遵循放行名单)，不上报，供DNS等高频路径使用
+func (s *PhishingSitesService) MatchSite(site string) (*entity.PhishingSite, bool) {
+	return lookupSite(site, newAllowList())
+}
+
+// IsAllowListed 判断域名是否在放行名单中
+func (s *PhishingSitesService) IsAllowListed(site string) bool {
+	return newAllowList().Contains(strings.ToLower(strings.TrimSpace(site)))
+}
+
+// lookupSite 在缓存中查找单个域名，同时匹配加/去www.前缀的值，放行名单中的域名不判定命中
+func lookupSite(site string, allowList allowList) (*entity.PhishingSite, bool) {
+	// 1. 将site转为小写并去除空格
+	siteStd := strings.ToLower(strings.TrimSpace(site))
+	if siteStd == "" {
+		return nil, false
+	}
+	if allowList.Contains(siteStd) {
+		return nil, false
+	}
+
+	// 2. 检查原始值是否存在于cache中
+	if val, exists := cache.PhishingSitesCache.Load(siteStd); exists {
+		return val.(*entity.PhishingSite), true
+	}
+
+	// 3. 如果site本身不带www，检查添加www.前缀的值是否存在于cache中
+	// 4. 如果site本身带有www，检查去掉www.前缀的值是否存在于cache中
+	variant := "www." + siteStd
+	if strings.HasPrefix(siteStd, "www.") {
+		variant = strings.TrimPrefix(siteStd, "www.")
+	}
+	if val, exists := cache.PhishingSitesCache.Load(variant); exists {
+		return val.(*entity.PhishingSite), true
+	}
+	return nil, false
+}
+
+// ExportPhishingSites 按指定格式将缓存中的数据流式写入w，跳过放行名单中的域名，返回写入的拦截条数
+func (s *PhishingSitesService) ExportPhishingSites(ctx context.Context, w io.Writer, format blocklist.Format, address string) (int, error) {
+	_, span := tracing.Start(ctx, "PhishingSitesService.ExportPhishingSites", attribute.String("format", string(format)))
+	defer span.End()
+
+	allowList := newAllowList()
+
+	// 收集并排序，保证输出稳定
+	domains := []string{}
+	blocked := map[string]struct{}{}
+	cache.PhishingSitesCache.Range(func(key, value any) bool {
+		domain := value.(*entity.PhishingSite).Domain
+		if allowList.Contains(domain) {
+			return true
+		}
+		domains = append(domains, domain)
+		blocked[domain] = struct{}{}
+		return true
+	})
+	sort.Strings(domains)
+
+	writer := blocklist.NewWriter(w, format, blocklist.Options{
+		Title:   strings.TrimSpace(conf.AppConfig.System.Service.Name + " phishing sites"),
+		Address: address,
+		Version: cache.PhishingSitesStats.Version(),
+	})
+	for i, domain := range domains {
+		if i%1000 == 0 && ctx.Err() != nil {
+			return writer.Count(), ctx.Err()
+		}
+		if err := writer.Block(domain); err != nil {
+			return writer.Count(), err
+		}
+	}
+
+	// 放行名单中的域名若其父域名被拦截，需要写入放行规则
+	for _, domain := range allowList.Domains() {
+		if hasBlockedParent(domain, blocked) {
+			if err := writer.Allow(domain); err != nil {
+				return writer.Count(), err
 			}
-			reportPayload = append(reportPayload, reportItem)
 		}
-		// 发送上报
-		s.reporter.SyncSend("/conf", report.ReportHead{UserId: 0}, reportPayload)
 	}
+
+	if err := writer.Close(); err != nil {
+		return writer.Count(), err
+	}
+	logger.Infof("Exported %d phishing sites in %s format", writer.Count(), format)
+	return writer.Count(), nil
+}
+
+// rebuildHashIndex 根据当前缓存重建哈希前缀索引，放行名单中的域名不进入索引
+func (s *PhishingSitesService) rebuildHashIndex() {
+	allowList := newAllowList()
+	entries := []hashprefix.Entry{}
+	cache.PhishingSitesCache.Range(func(key, value any) bool {
+		phishingSite := value.(*entity.PhishingSite)
+		if allowList.Contains(phishingSite.Domain) {
+			return true
+		}
+		entries = append(entries, hashprefix.Entry{Hash: hashprefix.Sum(phishingSite.Domain), Source: phishingSite.Source})
+		// 与检查逻辑一致，www.前缀的条目同时匹配去掉前缀的域名；子域名由客户端按父域名生成查询表达式匹配
+		if nonWwwSite := strings.TrimPrefix(phishingSite.Domain, "www."); nonWwwSite != phishingSite.Domain {
+			entries = append(entries, hashprefix.Entry{Hash: hashprefix.Sum(nonWwwSite), Source: phishingSite.Source})
+		}
+		return true
+	})
+
+	index := hashprefix.NewIndex(cache.PhishingSitesStats.Version(), entries)
+	cache.PhishingSitesHashIndex.Store(index)
+	logger.Infof("Rebuilt phishing sites hash index with %d hashes (version: %d)", index.Len(), index.Version())
+}
+
+// commitSnapshotHistory 将当前缓存(不含放行名单)提交为新版本快照，供增量同步使用
+func (s *PhishingSitesService) commitSnapshotHistory() {
+	allowList := newAllowList()
+	domains := map[string]struct{}{}
+	cache.PhishingSitesCache.Range(func(key, value any) bool {
+		domain := value.(*entity.PhishingSite).Domain
+		if !allowList.Contains(domain) {
+			domains[domain] = struct{}{}
+		}
+		return true
+	})
+
+	version := cache.PhishingSitesStats.Version()
+	first := cache.PhishingSitesHistory.Version() == 0
+	diff := cache.PhishingSitesHistory.Commit(version, domains, listsync.Limits{
+		MaxDiffs:   conf.AppConfig.AppSetting.SyncHistorySize,
+		MaxEntries: conf.AppConfig.AppSetting.SyncHistoryMaxEntries,
+	})
+	logger.Infof("Committed phishing sites snapshot %d with %d domains", version, len(domains))
+
+	// 首个版本没有差异，推送重置通知
+	update := listsync.Update{Epoch: cache.PhishingSitesHistory.Epoch(), Version: version, Reset: first}
+	if !first {
+		update.Adds, update.Removes = diff.Adds, diff.Removes
+	}
+	cache.PhishingSitesUpdates.Publish(update)
+	s.emitSitesLoaded(version, len(domains), first, diff)
+}
+
+// emitSitesLoaded 新版本有变化时产生sites.loaded事件，新增域名命中关注规则时产生watch.matched事件
+// 首个版本(服务启动后首次加载)没有差异，不检查关注规则，避免每次重启重复通知
+func (s *PhishingSitesService) emitSitesLoaded(version uint64, total int, first bool, diff listsync.Diff) {
+	if first {
+		emitWebhook(WebhookEventSitesLoaded, entity.SitesLoadedEvent{Version: version, Total: total})
+		return
+	}
+	if len(diff.Adds)+len(diff.Removes) == 0 {
+		return
+	}
+
+	emitWebhook(WebhookEventSitesLoaded, entity.SitesLoadedEvent{
+		Version:      version,
+		Total:        total,
+		AddedCount:   len(diff.Adds),
+		RemovedCount: len(diff.Removes),
+		Adds:         truncateDomains(diff.Adds),
+		Removes:      truncateDomains(diff.Removes),
+	})
+	if matches := matchWatchPatterns(diff.Adds); len(matches) > 0 {
+		emitWebhook(WebhookEventWatchMatched, entity.WatchMatchedEvent{Version: version, Matches: matches})
+	}
+}
+
+// emitCheckHit 检查命中高风险来源时产生check.hit事件
+func (s *PhishingSitesService) emitCheckHit(ret []*entity.PhishingSiteCheckRet) {
+	var hits []*entity.PhishingSiteCheckRet
+	for _, result := range ret {
+		if isHighSeveritySource(result.Source) {
+			hits = append(hits, result)
+		}
+	}
+	if len(hits) > 0 {
+		emitWebhook(WebhookEventCheckHit, entity.CheckHitEvent{Hits: hits})
+	}
+}
+
+// SubscribeUpdates 订阅快照版本更新，maxSubscribers>0时限制同时订阅的个数
+func (s *PhishingSitesService) SubscribeUpdates(ctx context.Context, maxSubscribers int) (*listsync.Subscription, error) {
+	return cache.PhishingSitesUpdates.Subscribe(maxSubscribers)
+}
+
+// SyncPhishingSites 计算客户端从指定版本同步到当前快照的差异
+func (s *PhishingSitesService) SyncPhishingSites(ctx context.Context, epoch string, version uint64, maxEntries int) (listsync.Delta, error) {
+	_, span := tracing.Start(ctx, "PhishingSitesService.SyncPhishingSites", attribute.Int64("version", int64(version)))
+	defer span.End()
+
+	if cache.PhishingSitesHistory.Version() == 0 {
+		return listsync.Delta{}, fmt.Errorf("snapshot is not ready")
+	}
+	return cache.PhishingSitesHistory.Delta(epoch, version, maxEntries), nil
+}
+
+// FindFullHashes 查找与哈希前缀匹配的完整哈希，返回索引版本号
+func (s *PhishingSitesService) FindFullHashes(ctx context.Context, prefixes [][]byte) (uint64, [][]hashprefix.Entry, error) {
+	_, span := tracing.Start(ctx, "PhishingSitesService.FindFullHashes", attribute.Int("prefixes", len(prefixes)))
+	defer span.End()
+
+	index := cache.PhishingSitesHashIndex.Load()
+	if index == nil {
+		return 0, nil, fmt.Errorf("hash index is not ready")
+	}
+
+	matches := make([][]hashprefix.Entry, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		entries, err := index.Find(prefix)
+		if err != nil {
+			return 0, nil, err
+		}
+		matches = append(matches, entries)
+	}
+	return index.Version(), matches, nil
+}
+
+// HashPrefixes 获取去重排序后拼接在一起的哈希前缀集合，返回索引版本号与前缀个数
+func (s *PhishingSitesService) HashPrefixes(ctx context.Context, prefixLen int) (uint64, []byte, int, error) {
+	_, span := tracing.Start(ctx, "PhishingSitesService.HashPrefixes", attribute.Int("prefix_len", prefixLen))
+	defer span.End()
+
+	index := cache.PhishingSitesHashIndex.Load()
+	if index == nil {
+		return 0, nil, 0, fmt.Errorf("hash index is not ready")
+	}
+
+	prefixes, count, err := index.Prefixes(prefixLen)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	return index.Version(), prefixes, count, nil
+}
+
+// ReportWithPhishingSiteCheckRet 上报名中的到webbb平台
+// 调用方信息、隐私策略及聚合由report.Recorder统一处理，高风险来源的命中不聚合、不采样
+func (s *PhishingSitesService) ReportWithPhishingSiteCheckRet(ctx context.Context, ret []*entity.PhishingSiteCheckRet) {
+	_, recorder := reporters()
+	if recorder == nil || len(ret) == 0 {
+		return
+	}
+
+	now := time.Now()
+	clientID := auth.ClientID(ctx)
+	hits := make([]report.Hit, 0, len(ret))
+	for _, result := range ret {
+		hits = append(hits, report.Hit{
+			URL: result.Query, Source: result.Source, ClientID: clientID, Time: now,
+			Immediate: isHighSeveritySource(result.Source),
+		})
+	}
+	recorder.Record(ctx, hits...)
 }
 
 // ImportPhishingSites 导入
-func (s *PhishingSitesService) ImportPhishingSites(ctx context.Context) error {
-	domains, err := resty.ScamsnifferResty.FetchDomains()
+func (s *PhishingSitesService) ImportPhishingSites(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "PhishingSitesService.ImportPhishingSites")
+	defer func() { tracing.End(span, err) }()
+
+	start := time.Now()
+	defer func() {
+		cache.PhishingSitesStats.RecordImport(start, err)
+	}()
+
+	domains, err := resty.ScamsnifferResty.FetchDomains(ctx)
 	if err != nil {
 		logger.Errorf("fetch scamsniffer failed: %v", err)
 		return err
@@ -165,12 +490,109 @@ func (s *PhishingSitesService) ImportPhishingSites(ctx context.Context) error {
 		return err
 	}
 
-	err = s.ossStoreSvc.Upload(ctx, fmt.Sprintf("%s-domains.json", PhishingSitesSourceScamSniffer), string(marshal))
+	// 上传前读取上一次导入的对象，用于计算新增域名(命令模式下进程内缓存为空，不能作为对比基准)
+	objectName := fmt.Sprintf("%s-domains.json", PhishingSitesSourceScamSniffer)
+	previous := s.previousImportedDomains(ctx, objectName)
+
+	err = s.ossStoreSvc.Upload(ctx, objectName, string(marshal))
 	if err != nil {
 		logger.Errorf("upload scam-sniffer failed: %v", err)
 		return err
 	}
 
 	logger.Infof("Successfully uploaded scamsniffer to config")
+	s.emitSitesImported(PhishingSitesSourceScamSniffer, domains, previous)
 	return nil
 }
+
+// previousImportedDomains 读取OSS中上一次导入的域名集合，读取失败(如首次导入)时返回空集合
+func (s *PhishingSitesService) previousImportedDomains(ctx context.Context, objectName string) map[string]struct{} {
+	previous := map[string]struct{}{}
+	download, err := s.ossStoreSvc.Download(ctx, objectName)
+	if err != nil {
+		logger.Warnf("Download previous %s failed, all imported domains are treated as added: %v", objectName, err)
+		return previous
+	}
+	var domains []string
+	if err = json.Unmarshal([]byte(download), &domains); err != nil {
+		logger.Warnf("Unmarshal previous %s failed, all imported domains are treated as added: %v", objectName, err)
+		return previous
+	}
+	for _, domain := range domains {
+		previous[strings.ToLower(strings.TrimSpace(domain))] = struct{}{}
+	}
+	return previous
+}
+
+// emitSitesImported 产生sites.imported事件，新增域名为上一次导入的对象中不存在的域名
+func (s *PhishingSitesService) emitSitesImported(source string, domains []string, previous map[string]struct{}) {
+	var added []string
+	for _, domain := range domains {
+		domainStd := strings.ToLower(strings.TrimSpace(domain))
+		if domainStd == "" {
+			continue
+		}
+		if _, exists := previous[domainStd]; !exists {
+			added = append(added, domainStd)
+		}
+	}
+	emitWebhook(WebhookEventSitesImported, entity.SitesImportedEvent{
+		Source:       source,
+		Total:        len(domains),
+		AddedCount:   len(added),
+		AddedDomains: truncateDomains(added),
+	})
+}
+
+// allowList 放行名单，域名本身及其子域名均放行
+type allowList map[string]struct{}
+
+// newAllowList 从当前配置构建放行名单(配置支持热更新，每次使用时构建)
+func newAllowList() allowList {
+	list := allowList{}
+	for _, domain := range conf.AppConfig.AppSetting.AllowList {
+		domainStd := strings.ToLower(strings.TrimSpace(domain))
+		if domainStd != "" {
+			list[domainStd] = struct{}{}
+		}
+	}
+	return list
+}
+
+// Contains 判断域名或其任一父域名是否在放行名单中
+func (l allowList) Contains(domain string) bool {
+	if len(l) == 0 {
+		return false
+	}
+	for {
+		if _, ok := l[domain]; ok {
+			return true
+		}
+		idx := strings.IndexByte(domain, '.')
+		if idx < 0 {
+			return false
+		}
+		domain = domain[idx+1:]
+	}
+}
+
+// Domains 放行名单中的域名(已排序)
+func (l allowList) Domains() []string {
+	domains := make([]string, 0, len(l))
+	for domain := range l {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// hasBlockedParent 判断域名的任一父域名是否被拦截
+func hasBlockedParent(domain string, blocked map[string]struct{}) bool {
+	for idx := strings.IndexByte(domain, '.'); idx >= 0; idx = strings.IndexByte(domain, '.') {
+		domain = domain[idx+1:]
+		if _, ok := blocked[domain]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"godex/pkg/tracing"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 流式检查默认限制
+const (
+	defaultStreamMaxLines      = 10000000
+	defaultStreamMaxLineBytes  = 4096
+	defaultStreamMaxConcurrent = 4
+	streamFlushLines           = 256
+	streamFlushInterval        = 200 * time.Millisecond
+)
+
+// activeStreams 当前正在处理的流式检查个数
+var activeStreams atomic.Int64
+
+// StreamCheckLimits 流式检查限制
+type StreamCheckLimits struct {
+	MaxLines      int // 单个流最多处理的行数
+	MaxLineBytes  int // 单行最大字节数
+	MaxConcurrent int // 同时处理的流个数上限
+}
+
+// StreamCheckSummary 流式检查结果汇总
+type StreamCheckSummary struct {
+	Lines  int
+	Hits   int
+	Errors int
+}
+
+// streamCheckQuery 流式检查的JSON输入行
+type streamCheckQuery struct {
+	ID   json.RawMessage `json:"id,omitempty"`
+	Host string          `json:"host"`
+}
+
+// streamCheckResult 流式检查的输出行
+type streamCheckResult struct {
+	Line   int             `json:"line"`
+	ID     json.RawMessage `json:"id,omitempty"`
+	Query  string          `json:"query,omitempty"`
+	Hit    bool            `json:"hit"`
+	Domain string          `json:"domain,omitempty"`
+	Source string          `json:"source,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// ErrStreamLimitExceeded 同时处理的流个数超过上限
+var ErrStreamLimitExceeded = fmt.Errorf("too many concurrent check streams")
+
+// AcquireCheckStream 占用一个流式检查名额，成功后需调用返回的release释放
+func AcquireCheckStream(limits StreamCheckLimits) (release func(), err error) {
+	maxConcurrent := limits.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultStreamMaxConcurrent
+	}
+	if activeStreams.Add(1) > int64(maxConcurrent) {
+		activeStreams.Add(-1)
+		return nil, ErrStreamLimitExceeded
+	}
+	return func() { activeStreams.Add(-1) }, nil
+}
+
+// CheckPhishingSitesStream 流式检查：从r逐行读取主机名或JSON对象({"id":..., "host":"..."})，
+// 每行输出一条NDJSON结果到w。读写同步进行，客户端读取变慢时写入阻塞、随之停止读取，形成背压。
+// 流式检查用于离线批量分析，命中结果不上报。
+func (s *PhishingSitesService) CheckPhishingSitesStream(ctx context.Context, r io.Reader, w io.Writer, limits StreamCheckLimits) (summary StreamCheckSummary, err error) {
+	_, span := tracing.Start(ctx, "PhishingSitesService.CheckPhishingSitesStream")
+	defer func() {
+		span.SetAttributes(attribute.Int("lines", summary.Lines), attribute.Int("hits", summary.Hits), attribute.Int("errors", summary.Errors))
+		tracing.End(span, err)
+	}()
+
+	if limits.MaxLines <= 0 {
+		limits.MaxLines = defaultStreamMaxLines
+	}
+	if limits.MaxLineBytes <= 0 {
+		limits.MaxLineBytes = defaultStreamMaxLineBytes
+	}
+
+	allowList := newAllowList()
+	scanner := bufio.NewScanner(r)
+	// 初始缓冲不能超过单行上限，否则上限小于初始缓冲时不生效
+	scanner.Buffer(make([]byte, 0, min(1024, limits.MaxLineBytes)), limits.MaxLineBytes)
+	bw := bufio.NewWriterSize(w, 32*1024)
+	encoder := json.NewEncoder(bw)
+	lastFlush := time.Now()
+
+	// flush 将缓冲写出到客户端
+	flush := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		lastFlush = time.Now()
+		return nil
+	}
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if summary.Lines >= limits.MaxLines {
+			summary.Errors++
+			_ = encoder.Encode(streamCheckResult{Line: lineNo, Error: fmt.Sprintf("max lines %d exceeded", limits.MaxLines)})
+			return summary, flush()
+		}
+		summary.Lines++
+
+		result := streamCheckResult{Line: lineNo, Query: line}
+		if strings.HasPrefix(line, "{") {
+			query := streamCheckQuery{}
+			if err := json.Unmarshal([]byte(line), &query); err != nil {
+				result = streamCheckResult{Line: lineNo, Error: fmt.Sprintf("invalid json: %v", err)}
+			} else if strings.TrimSpace(query.Host) == "" {
+				result = streamCheckResult{Line: lineNo, ID: query.ID, Error: "host is required"}
+			} else {
+				result.ID, result.Query = query.ID, query.Host
+			}
+		}
+
+		if result.Error == "" {
+			if phishingSite, exists := lookupSite(result.Query, allowList); exists {
+				result.Hit, result.Domain, result.Source = true, phishingSite.Domain, phishingSite.Source
+				summary.Hits++
+			}
+		} else {
+			summary.Errors++
+		}
+
+		if err := encoder.Encode(result); err != nil {
+			return summary, err
+		}
+		if summary.Lines%streamFlushLines == 0 || time.Since(lastFlush) >= streamFlushInterval {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		// 单行超长等读取错误，输出错误行后结束
+		summary.Errors++
+		_ = encoder.Encode(streamCheckResult{Line: lineNo + 1, Error: err.Error()})
+	}
+	return summary, flush()
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"godex/internal/cache"
+	"godex/internal/conf"
+	"godex/internal/entity"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setupStreamCache 缓存中放入evil.com，测试结束后清除
+func setupStreamCache(t *testing.T) {
+	originalConfig := conf.AppConfig
+	conf.AppConfig = &conf.Config{}
+	cache.PhishingSitesCache.Store("evil.com", &entity.PhishingSite{Domain: "evil.com", Source: "s1"})
+	t.Cleanup(func() {
+		cache.PhishingSitesCache.Delete("evil.com")
+		conf.AppConfig = originalConfig
+	})
+}
+
+func TestCheckPhishingSitesStream(t *testing.T) {
+	setupStreamCache(t)
+
+	tests := []struct {
+		name    string
+		input   string
+		limits  StreamCheckLimits
+		want    []streamCheckResult
+		summary StreamCheckSummary
+	}{
+		{
+			name:  "mixed plain and json lines",
+			input: "evil.com\n{\"id\":1,\"host\":\"WWW.evil.com\"}\n\ngood.com\n{bad\n{\"id\":\"x\"}\n",
+			want: []streamCheckResult{
+				{Line: 1, Query: "evil.com", Hit: true, Domain: "evil.com", Source: "s1"},
+				{Line: 2, ID: json.RawMessage(`1`), Query: "WWW.evil.com", Hit: true, Domain: "evil.com", Source: "s1"},
+				{Line: 4, Query: "good.com"},
+				{Line: 5, Error: "invalid json"},
+				{Line: 6, ID: json.RawMessage(`"x"`), Error: "host is required"},
+			},
+			summary: StreamCheckSummary{Lines: 5, Hits: 2, Errors: 2},
+		},
+		{
+			name:   "max lines",
+			input:  "a.com\nb.com\nevil.com\n",
+			limits: StreamCheckLimits{MaxLines: 2},
+			want: []streamCheckResult{
+				{Line: 1, Query: "a.com"},
+				{Line: 2, Query: "b.com"},
+				{Line: 3, Error: "max lines 2 exceeded"},
+			},
+			summary: StreamCheckSummary{Lines: 2, Errors: 1},
+		},
+		{
+			name:   "line too long",
+			input:  "evil.com\n" + strings.Repeat("a", 64) + ".com\nevil.com\n",
+			limits: StreamCheckLimits{MaxLineBytes: 32},
+			want: []streamCheckResult{
+				{Line: 1, Query: "evil.com", Hit: true, Domain: "evil.com", Source: "s1"},
+				{Line: 2, Error: "token too long"},
+			},
+			summary: StreamCheckSummary{Lines: 1, Hits: 1, Errors: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w := io.Pipe()
+			go func() {
+				_, _ = io.WriteString(w, tt.input)
+				_ = w.Close()
+			}()
+			var out bytes.Buffer
+			summary, err := (&PhishingSitesService{}).CheckPhishingSitesStream(context.Background(), r, &out, tt.limits)
+			if err != nil {
+				t.Fatalf("stream: %v", err)
+			}
+			if summary != tt.summary {
+				t.Errorf("summary = %+v, want %+v", summary, tt.summary)
+			}
+
+			decoder := json.NewDecoder(&out)
+			for i, want := range tt.want {
+				var got streamCheckResult
+				if err = decoder.Decode(&got); err != nil {
+					t.Fatalf("result %d: %v", i, err)
+				}
+				if !strings.Contains(got.Error, want.Error) || (want.Error == "" && got.Error != "") {
+					t.Fatalf("result %d error = %q, want %q", i, got.Error, want.Error)
+				}
+				got.Error, want.Error = "", ""
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("result %d = %+v, want %+v", i, got, want)
+				}
+			}
+			if decoder.More() {
+				t.Fatal("unexpected extra results")
+			}
+		})
+	}
+}
+
+func TestAcquireCheckStream(t *testing.T) {
+	limits := StreamCheckLimits{MaxConcurrent: 1}
+	release, err := AcquireCheckStream(limits)
+	if err != nil {
+		t.Fatalf("first stream: %v", err)
+	}
+	if _, err = AcquireCheckStream(limits); err != ErrStreamLimitExceeded {
+		t.Fatalf("second stream = %v, want ErrStreamLimitExceeded", err)
+	}
+	release()
+	release, err = AcquireCheckStream(limits)
+	if err != nil {
+		t.Fatalf("after release: %v", err)
+	}
+	release()
+}
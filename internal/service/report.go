@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"godex/internal/conf"
+	"godex/pkg/logger"
+	"godex/pkg/report"
+	"sync"
+	"time"
+)
+
+// reportPath 命中上报的路径
+const reportPath = "/conf"
+
+var (
+	reporterOnce   sync.Once
+	reporter       *report.Reporter
+	hitRecorder    *report.Recorder
+	reporterReplay bool // 由StartReporter设置，仅服务模式重新上报spool
+
+	// reporterErr 上报策略配置有误时创建失败的原因
+	reporterErr error
+)
+
+// reporters 获取报告器及命中上报入口，未启用或创建失败时返回nil，首次调用时按当前配置创建
+func reporters() (*report.Reporter, *report.Recorder) {
+	reporterOnce.Do(func() {
+		config := conf.AppConfig.System.Report
+		if !config.Enable {
+			return
+		}
+		var options []report.Option
+		if !reporterReplay {
+			options = append(options, report.WithoutReplay())
+		}
+		r := report.NewReporter(config, options...)
+		recorder, err := report.NewRecorder(r, reportPath, config)
+		if err != nil {
+			reporterErr = err
+			_ = r.Close(context.Background())
+			return
+		}
+		reporter, hitRecorder = r, recorder
+	})
+	return reporter, hitRecorder
+}
+
+// StartReporter 启动时按当前配置创建报告器，上报策略配置有误时返回错误；
+// replay为true时(仅服务模式)重新上报上次退出前写入spool的批次，命令模式的报告器只写入spool，不与服务同时重新上报同一目录
+func StartReporter(replay bool) error {
+	reporterReplay = replay
+	reporters()
+	return reporterErr
+}
+
+// CloseReporter 输出聚合中的命中并发送队列中剩余的上报，超时或失败的批次写入spool，服务退出及命令执行结束时调用
+func CloseReporter(timeout time.Duration) {
+	// 未创建时不再创建
+	reporterOnce.Do(func() {})
+	r, recorder := reporter, hitRecorder
+	if r == nil {
+		return
+	}
+	// 先输出聚合窗口内的命中，再发送队列中的上报
+	recorder.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := r.Close(ctx); err != nil {
+		logger.Warnf("Reports not flushed before exit: %v", err)
+	}
+}
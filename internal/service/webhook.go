@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"godex/internal/conf"
+	"godex/internal/entity"
+	"godex/pkg/logger"
+	"godex/pkg/webhook"
+	"path"
+	"sync"
+	"time"
+)
+
+// Webhook事件类型
+const (
+	WebhookEventSitesImported = "sites.imported" // 从外部数据源导入
+	WebhookEventSitesLoaded   = "sites.loaded"   // 加载到缓存并产生新版本
+	WebhookEventWatchMatched  = "watch.matched"  // 新增域名命中关注规则
+	WebhookEventCheckHit      = "check.hit"      // 检查命中高风险来源
+)
+
+// maxWebhookDomains 单个事件中最多携带的域名个数
+const maxWebhookDomains = 100
+
+var (
+	webhookOnce       sync.Once
+	webhookDispatcher *webhook.Dispatcher
+)
+
+// webhooks 获取投递器，未启用时返回nil，首次调用时按当前配置创建
+func webhooks() *webhook.Dispatcher {
+	webhookOnce.Do(func() {
+		config := conf.AppConfig.System.Webhook
+		if config.Enable && len(config.Endpoints) > 0 {
+			webhookDispatcher = webhook.NewDispatcher(config)
+		}
+	})
+	return webhookDispatcher
+}
+
+// emitWebhook 产生Webhook事件，未启用时忽略
+func emitWebhook(eventType string, data any) {
+	if dispatcher := webhooks(); dispatcher != nil {
+		dispatcher.Emit(eventType, data)
+	}
+}
+
+// CloseWebhooks 等待已产生的Webhook事件投递完成，服务退出及命令执行结束时调用
+func CloseWebhooks(timeout time.Duration) {
+	dispatcher := webhooks()
+	if dispatcher == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := dispatcher.Close(ctx); err != nil {
+		logger.Warnf("Webhook deliveries not finished before exit: %v", err)
+	}
+}
+
+// matchWatchPatterns 查找命中关注规则(path.Match通配符，如*binance*)的域名，每个域名只记录首个命中的规则
+func matchWatchPatterns(domains []string) []entity.WatchMatch {
+	patterns := conf.AppConfig.AppSetting.WatchPatterns
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	var matches []entity.WatchMatch
+	for _, domain := range domains {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, domain); ok {
+				matches = append(matches, entity.WatchMatch{Domain: domain, Pattern: pattern})
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// isHighSeveritySource 判断来源是否为高风险来源
+func isHighSeveritySource(source string) bool {
+	for _, highSeverity := range conf.AppConfig.AppSetting.HighSeveritySources {
+		if highSeverity == source {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateDomains 截取最多maxWebhookDomains个域名
+func truncateDomains(domains []string) []string {
+	if len(domains) > maxWebhookDomains {
+		return domains[:maxWebhookDomains]
+	}
+	return domains
+}
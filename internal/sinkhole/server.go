@@ -0,0 +1,272 @@
+package sinkhole
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+	"godex/internal/conf"
+	"godex/internal/entity"
+	"godex/pkg/constant"
+	"godex/pkg/logger"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认配置
+const (
+	defaultListen  = ":5353"
+	defaultTTL     = 60
+	defaultTimeout = 2000
+)
+
+// Matcher 域名匹配，由service.PhishingSitesService实现
+type Matcher interface {
+	// MatchSite 检查域名是否命中缓存(遵循放行名单)
+	MatchSite(site string) (*entity.PhishingSite, bool)
+	// IsAllowListed 判断域名是否在放行名单中
+	IsAllowListed(site string) bool
+}
+
+// Server DNS拦截解析服务：命中缓存的域名返回NXDOMAIN或拦截地址，其余转发到上游
+type Server struct {
+	config   conf.DNSConfig
+	matcher  Matcher
+	client   *dns.Client
+	counters *Counters
+	servers  []*dns.Server
+
+	sinkholeIPv4 net.IP // 由Start解析的拦截地址，未配置时为nil
+	sinkholeIPv6 net.IP
+}
+
+// NewServer 创建DNS拦截解析服务
+func NewServer(config conf.DNSConfig, matcher Matcher) *Server {
+	if config.Listen == "" {
+		config.Listen = defaultListen
+	}
+	if config.TTL == 0 {
+		config.TTL = defaultTTL
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	return &Server{
+		config:   config,
+		matcher:  matcher,
+		client:   &dns.Client{Timeout: time.Duration(config.Timeout) * time.Millisecond},
+		counters: NewCounters(),
+	}
+}
+
+// Counters 各判定结果的计数器
+func (s *Server) Counters() *Counters {
+	return s.counters
+}
+
+// Start 在UDP与TCP上启动监听，监听成功后返回，服务在后台运行
+func (s *Server) Start() error {
+	if len(s.config.Upstreams) == 0 {
+		return fmt.Errorf("dns upstreams are not configured")
+	}
+	var err error
+	if s.sinkholeIPv4, err = parseSinkholeIP(s.config.SinkholeIPv4, false); err != nil {
+		return err
+	}
+	if s.sinkholeIPv6, err = parseSinkholeIP(s.config.SinkholeIPv6, true); err != nil {
+		return err
+	}
+
+	for _, network := range []string{"udp", "tcp"} {
+		started := make(chan struct{})
+		server := &dns.Server{
+			Addr:              s.config.Listen,
+			Net:               network,
+			Handler:           s,
+			NotifyStartedFunc: func() { close(started) },
+		}
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- server.ListenAndServe()
+		}()
+
+		select {
+		case <-started:
+			s.servers = append(s.servers, server)
+		case err := <-errCh:
+			s.Shutdown()
+			return fmt.Errorf("failed to listen dns on %s/%s: %v", s.config.Listen, network, err)
+		}
+	}
+	return nil
+}
+
+// Shutdown 停止所有监听
+func (s *Server) Shutdown() {
+	for _, server := range s.servers {
+		logger.IgnoreError(server.Shutdown())
+	}
+	s.servers = nil
+}
+
+// ServeDNS 实现dns.Handler接口
+func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	start := time.Now()
+	if len(r.Question) == 0 {
+		reply := new(dns.Msg)
+		reply.SetRcode(r, dns.RcodeFormatError)
+		logger.IgnoreError(w.WriteMsg(reply))
+		return
+	}
+
+	question := r.Question[0]
+	name := strings.TrimSuffix(strings.ToLower(question.Name), ".")
+	network := "udp"
+	if _, ok := w.RemoteAddr().(*net.TCPAddr); ok {
+		network = "tcp"
+	}
+
+	var reply *dns.Msg
+	var verdict Verdict
+	source := ""
+	if phishingSite, exists := s.matcher.MatchSite(name); exists {
+		verdict, source = VerdictBlocked, phishingSite.Source
+		reply = s.blockReply(r, question)
+	} else {
+		verdict = VerdictForwarded
+		if s.matcher.IsAllowListed(name) {
+			verdict = VerdictAllowed
+		}
+		var err error
+		if reply, err = s.forward(r, network); err != nil {
+			logger.Warnf("DNS forward %s failed: %v", name, err)
+			verdict = VerdictFailed
+			reply = new(dns.Msg)
+			reply.SetRcode(r, dns.RcodeServerFailure)
+		}
+	}
+
+	s.counters.Inc(verdict)
+	if s.config.LogQueries {
+		s.logQuery(w, question, name, verdict, source, start)
+	}
+	logger.IgnoreError(w.WriteMsg(reply))
+}
+
+// blockReply 构造拦截应答：配置了对应地址族的拦截地址时返回该地址，其他类型返回空应答，未配置拦截地址时返回NXDOMAIN
+func (s *Server) blockReply(r *dns.Msg, question dns.Question) *dns.Msg {
+	reply := new(dns.Msg)
+	reply.SetReply(r)
+	reply.Authoritative = true
+	reply.RecursionAvailable = true
+
+	if s.sinkholeIPv4 == nil && s.sinkholeIPv6 == nil {
+		reply.Rcode = dns.RcodeNameError
+		return reply
+	}
+
+	header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: dns.ClassINET, Ttl: s.config.TTL}
+	switch {
+	case question.Qtype == dns.TypeA && s.sinkholeIPv4 != nil:
+		reply.Answer = append(reply.Answer, &dns.A{Hdr: header, A: s.sinkholeIPv4})
+	case question.Qtype == dns.TypeAAAA && s.sinkholeIPv6 != nil:
+		reply.Answer = append(reply.Answer, &dns.AAAA{Hdr: header, AAAA: s.sinkholeIPv6})
+	}
+	return reply
+}
+
+// parseSinkholeIP 解析拦截地址，为空时返回nil，格式错误或地址族不符时返回错误
+func parseSinkholeIP(value string, ipv6 bool) (net.IP, error) {
+	if value == "" {
+		return nil, nil
+	}
+	ip := net.ParseIP(value)
+	switch {
+	case ip == nil:
+		return nil, fmt.Errorf("invalid dns sinkhole address %q", value)
+	case !ipv6 && ip.To4() == nil:
+		return nil, fmt.Errorf("dns sinkhole-ipv4 %q is not an IPv4 address", value)
+	case ipv6 && ip.To4() != nil:
+		return nil, fmt.Errorf("dns sinkhole-ipv6 %q is not an IPv6 address", value)
+	}
+	if !ipv6 {
+		return ip.To4(), nil
+	}
+	return ip, nil
+}
+
+// forward 按顺序转发到上游，UDP应答被截断时改用TCP重试
+func (s *Server) forward(r *dns.Msg, network string) (*dns.Msg, error) {
+	var lastErr error
+	for _, upstream := range s.config.Upstreams {
+		client := *s.client
+		client.Net = network
+		reply, _, err := client.Exchange(r, upstream)
+		if err == nil && reply.Truncated && network == "udp" {
+			client.Net = "tcp"
+			reply, _, err = client.Exchange(r, upstream)
+		}
+		if err == nil {
+			return reply, nil
+		}
+		lastErr = fmt.Errorf("upstream %s: %v", upstream, err)
+	}
+	return nil, lastErr
+}
+
+// logQuery 记录查询日志，字段与HTTP日志中间件保持一致
+func (s *Server) logQuery(w dns.ResponseWriter, question dns.Question, name string, verdict Verdict, source string, start time.Time) {
+	latency := math.Round(time.Since(start).Seconds()*10000) / 10000
+	jsonData, _ := json.Marshal(logrus.Fields{
+		constant.IPKey:      w.RemoteAddr().String(),
+		constant.MethodKey:  "DNS " + dns.TypeToString[question.Qtype],
+		constant.QueryKey:   name,
+		constant.StatusKey:  string(verdict),
+		"source":            source,
+		constant.LatencyKey: latency,
+	})
+	logger.Info("DNS query: " + string(jsonData))
+}
+
+// Verdict 查询判定结果
+type Verdict string
+
+// 判定结果
+const (
+	VerdictBlocked   Verdict = "blocked"   // 命中缓存，已拦截
+	VerdictAllowed   Verdict = "allowed"   // 在放行名单中，已转发
+	VerdictForwarded Verdict = "forwarded" // 未命中，已转发
+	VerdictFailed    Verdict = "failed"    // 转发上游失败
+)
+
+// Counters 判定结果计数器，线程安全
+type Counters struct {
+	mu     sync.Mutex
+	counts map[Verdict]uint64
+}
+
+// NewCounters 创建计数器
+func NewCounters() *Counters {
+	return &Counters{counts: map[Verdict]uint64{}}
+}
+
+// Inc 计数加一
+func (c *Counters) Inc(verdict Verdict) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[verdict]++
+}
+
+// Snapshot 获取计数快照
+func (c *Counters) Snapshot() map[Verdict]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot := make(map[Verdict]uint64, len(c.counts))
+	for verdict, count := range c.counts {
+		snapshot[verdict] = count
+	}
+	return snapshot
+}
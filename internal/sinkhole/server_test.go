@@ -0,0 +1,144 @@
+package sinkhole
+
+import (
+	"github.com/miekg/dns"
+	"godex/internal/conf"
+	"godex/internal/entity"
+	"net"
+	"testing"
+)
+
+// stubMatcher 固定的匹配结果
+type stubMatcher struct {
+	blocked map[string]string
+	allowed map[string]bool
+}
+
+func (m *stubMatcher) MatchSite(site string) (*entity.PhishingSite, bool) {
+	source, ok := m.blocked[site]
+	if !ok {
+		return nil, false
+	}
+	return &entity.PhishingSite{Domain: site, Source: source}, true
+}
+
+func (m *stubMatcher) IsAllowListed(site string) bool {
+	return m.allowed[site]
+}
+
+// startStubUpstream 启动本地上游，所有A查询应答1.2.3.4
+func startStubUpstream(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen stub upstream: %v", err)
+	}
+	server := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		reply := new(dns.Msg)
+		reply.SetReply(r)
+		reply.Answer = append(reply.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+			A:   net.ParseIP("1.2.3.4"),
+		})
+		_ = w.WriteMsg(reply)
+	})}
+	go func() { _ = server.ActivateAndServe() }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+// startSinkhole 启动拦截解析服务，返回监听地址
+func startSinkhole(t *testing.T, config conf.DNSConfig) (*Server, string) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("reserve port: %v", err)
+	}
+	config.Listen = pc.LocalAddr().String()
+	_ = pc.Close()
+
+	server := NewServer(config, &stubMatcher{
+		blocked: map[string]string{"evil.com": "scam-sniffer"},
+		allowed: map[string]bool{"good.com": true},
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(server.Shutdown)
+	return server, config.Listen
+}
+
+func query(t *testing.T, addr, name string, qtype uint16) *dns.Msg {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	reply, _, err := new(dns.Client).Exchange(msg, addr)
+	if err != nil {
+		t.Fatalf("query %s: %v", name, err)
+	}
+	return reply
+}
+
+func TestServerNXDOMAIN(t *testing.T) {
+	upstream := startStubUpstream(t)
+	server, addr := startSinkhole(t, conf.DNSConfig{Upstreams: []string{upstream}})
+
+	if reply := query(t, addr, "evil.com", dns.TypeA); reply.Rcode != dns.RcodeNameError {
+		t.Errorf("blocked domain rcode = %s, want NXDOMAIN", dns.RcodeToString[reply.Rcode])
+	}
+
+	reply := query(t, addr, "example.com", dns.TypeA)
+	if reply.Rcode != dns.RcodeSuccess || len(reply.Answer) != 1 || reply.Answer[0].(*dns.A).A.String() != "1.2.3.4" {
+		t.Errorf("forwarded reply = %v", reply)
+	}
+	query(t, addr, "good.com", dns.TypeA)
+
+	counts := server.Counters().Snapshot()
+	if counts[VerdictBlocked] != 1 || counts[VerdictForwarded] != 1 || counts[VerdictAllowed] != 1 {
+		t.Errorf("counters = %v", counts)
+	}
+}
+
+func TestServerSinkholeAddress(t *testing.T) {
+	upstream := startStubUpstream(t)
+	_, addr := startSinkhole(t, conf.DNSConfig{Upstreams: []string{upstream}, SinkholeIPv4: "10.0.0.1", TTL: 120})
+
+	reply := query(t, addr, "Evil.com", dns.TypeA)
+	if len(reply.Answer) != 1 {
+		t.Fatalf("sinkhole reply = %v", reply)
+	}
+	if a := reply.Answer[0].(*dns.A); a.A.String() != "10.0.0.1" || a.Hdr.Ttl != 120 {
+		t.Errorf("sinkhole answer = %v", a)
+	}
+
+	// 未配置IPv6拦截地址时AAAA返回空应答
+	if reply := query(t, addr, "evil.com", dns.TypeAAAA); reply.Rcode != dns.RcodeSuccess || len(reply.Answer) != 0 {
+		t.Errorf("AAAA reply = %v", reply)
+	}
+}
+
+func TestServerUpstreamFailure(t *testing.T) {
+	pc, _ := net.ListenPacket("udp", "127.0.0.1:0")
+	deadUpstream := pc.LocalAddr().String()
+	_ = pc.Close()
+
+	server, addr := startSinkhole(t, conf.DNSConfig{Upstreams: []string{deadUpstream}, Timeout: 200})
+	if reply := query(t, addr, "example.com", dns.TypeA); reply.Rcode != dns.RcodeServerFailure {
+		t.Errorf("rcode = %s, want SERVFAIL", dns.RcodeToString[reply.Rcode])
+	}
+	if counts := server.Counters().Snapshot(); counts[VerdictFailed] != 1 {
+		t.Errorf("counters = %v", counts)
+	}
+}
+
+func TestServerInvalidSinkholeAddress(t *testing.T) {
+	for _, config := range []conf.DNSConfig{
+		{Upstreams: []string{"127.0.0.1:53"}, SinkholeIPv4: "0.0.0.O"},
+		{Upstreams: []string{"127.0.0.1:53"}, SinkholeIPv4: "::1"},
+		{Upstreams: []string{"127.0.0.1:53"}, SinkholeIPv6: "127.0.0.1"},
+	} {
+		config.Listen = "127.0.0.1:0"
+		server := NewServer(config, &stubMatcher{})
+		if err := server.Start(); err == nil {
+			server.Shutdown()
+			t.Fatalf("Start(%+v) succeeded, want error", config)
+		}
+	}
+}
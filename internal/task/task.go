@@ -6,6 +6,7 @@ import (
 	"godex/internal/service"
 	"godex/pkg/logger"
 	"godex/pkg/task"
+	"time"
 )
 
 var (
@@ -15,8 +16,12 @@ var (
 
 // InitTask 初始化任务调度器
 func InitTask() error {
-	// 创建任务调度器
-	scheduler = task.NewTaskScheduler()
+	// 创建任务调度器，持久化文件读取失败时从空记录开始
+	history, err := task.NewHistory(conf.AppConfig.System.TaskHistory)
+	if err != nil {
+		logger.Warnf("Failed to load task history: %v", err)
+	}
+	scheduler = task.NewTaskScheduler(task.WithHistory(history))
 
 	// 注册所有业务任务
 	registerBusinessTasks()
@@ -32,19 +37,56 @@ func InitTask() error {
 	return scheduler.Start()
 }
 
+// Status 获取已配置任务的状态，未启动调度器时(如命令模式)读取持久化的执行记录
+func Status() []task.TaskStatus {
+	if scheduler == nil {
+		return task.Statuses(conf.AppConfig.System.Tasks, savedHistory(), time.Now())
+	}
+	return scheduler.Status()
+}
+
+// Runs 获取任务执行记录，按开始时间从晚到早，name为空时返回所有任务
+func Runs(name string, limit int) []task.Run {
+	if scheduler == nil {
+		return savedHistory().Runs(name, limit)
+	}
+	return scheduler.History().Runs(name, limit)
+}
+
+// StopTask 停止任务调度器，取消执行中任务的ctx并最多等待timeout使其结束
+func StopTask(timeout time.Duration) {
+	if scheduler == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := scheduler.StopAndWait(ctx); err != nil {
+		logger.Warnf("Failed to wait for running tasks: %v", err)
+	}
+}
+
+// savedHistory 读取持久化的执行记录，未配置持久化文件时为空
+func savedHistory() *task.History {
+	history, err := task.NewHistory(conf.AppConfig.System.TaskHistory)
+	if err != nil {
+		logger.Warnf("Failed to load task history: %v", err)
+	}
+	return history
+}
+
 // registerBusinessTasks 注册业务任务函数
 func registerBusinessTasks() {
-	scheduler.RegisterTask(CronTestTask)
+	scheduler.RegisterSimpleTask(CronTestTask)
 	scheduler.RegisterTask(LoadPhishingSites2CacheTask)
-	scheduler.RegisterTask(OnceTestTask)
+	scheduler.RegisterSimpleTask(OnceTestTask)
 }
 
 // LoadPhishingSites2CacheTask 加载到cache
-func LoadPhishingSites2CacheTask() error {
+func LoadPhishingSites2CacheTask(ctx context.Context) error {
 	logger.Info("开始执行任务: 导入数据")
 
 	phishingSitesService := service.NewPhishingSitesService()
-	err := phishingSitesService.LoadPhishingSites2Cache(context.Background())
+	err := phishingSitesService.LoadPhishingSites2Cache(ctx)
 	if err != nil {
 		logger.Errorf("导入数据失败: %v", err)
 		return err
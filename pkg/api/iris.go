@@ -6,6 +6,9 @@ import (
 	"github.com/kataras/iris/v12"
 	"godex/pkg/errs"
 	"godex/pkg/logger"
+	"godex/pkg/tracing"
+	"io"
+	"net/http"
 )
 
 // OK 返回成功响应
@@ -24,6 +27,11 @@ func OK(ctx iris.Context, data interface{}, message string) {
 
 // Error 返回错误响应
 func Error(ctx iris.Context, err error) {
+	ErrorWithStatus(ctx, iris.StatusBadRequest, err)
+}
+
+// ErrorWithStatus 以指定的HTTP状态码返回错误响应，如认证失败(401)
+func ErrorWithStatus(ctx iris.Context, status int, err error) {
 	resp := APIResponse{
 		Code:    errs.Code(err),
 		Message: errs.Msg(err),
@@ -32,7 +40,7 @@ func Error(ctx iris.Context, err error) {
 	if err != nil {
 		resp.Message = fmt.Sprintf("%+v(%+v)", resp.Message, err.Error())
 	}
-	ctx.StatusCode(400)
+	ctx.StatusCode(status)
 	logger.IgnoreError(ctx.JSON(resp))
 }
 
@@ -46,16 +54,16 @@ func Handler[TReq any, TRsp any](handler func(ctx context.Context, req TReq) (TR
 
 		// 尝试自动绑定请求参数，失败时使用零值
 		// 这样既支持POST/PUT等带请求体的请求，也支持GET等不带请求体的请求
-		if err := ctx.ReadJSON(&req); err != nil {
-			// 如果读取JSON失败，可能是GET请求或空请求体，使用零值
-			// 对于GET请求或其他不需要请求体的情况，这是正常行为
+		if err := bindRequest(ctx, &req); err != nil {
 			Error(ctx, errs.NewFrameError(errs.RetClientEncodeFail, err.Error()))
 			logger.Errorf("request parse json fail, err: %+v", err)
 			return
 		}
 
 		// 调用业务处理函数
+		endSpan := startSpan(ctx, "api.Handler")
 		rsp, err := handler(ctx, req)
+		endSpan(err)
 		if err != nil {
 			Error(ctx, err)
 			logger.Errorf("handler error: %+v", err)
@@ -67,6 +75,101 @@ func Handler[TReq any, TRsp any](handler func(ctx context.Context, req TReq) (TR
 	}
 }
 
+// StreamHandler 泛型流式处理器，请求绑定与Handler一致，响应由业务函数直接写入w
+// 业务函数在写入任何数据前返回错误时，按统一的APIResponse格式返回错误；写入后出错只能记录日志
+func StreamHandler[TReq any](handler func(ctx context.Context, req TReq, w io.Writer) error) iris.Handler {
+	return func(ctx iris.Context) {
+		var req TReq
+
+		if err := bindRequest(ctx, &req); err != nil {
+			Error(ctx, errs.NewFrameError(errs.RetClientEncodeFail, err.Error()))
+			logger.Errorf("request parse json fail, err: %+v", err)
+			return
+		}
+
+		w := &countingWriter{w: ctx.ResponseWriter()}
+		endSpan := startSpan(ctx, "api.StreamHandler")
+		err := handler(ctx, req, w)
+		endSpan(err)
+		if err != nil {
+			if w.n == 0 {
+				Error(ctx, err)
+			}
+			logger.Errorf("stream handler error after %d bytes: %+v", w.n, err)
+		}
+	}
+}
+
+// BodyStreamHandler 泛型请求体流式处理器，请求参数仅从URL query绑定，请求体不做缓冲直接交给业务函数读取
+// 错误处理与StreamHandler一致
+func BodyStreamHandler[TReq any](handler func(ctx context.Context, req TReq, r io.Reader, w io.Writer) error) iris.Handler {
+	return func(ctx iris.Context) {
+		var req TReq
+
+		if err := ctx.ReadQuery(&req); err != nil {
+			Error(ctx, errs.NewFrameError(errs.RetClientEncodeFail, err.Error()))
+			logger.Errorf("request parse query fail, err: %+v", err)
+			return
+		}
+
+		w := &countingWriter{w: ctx.ResponseWriter()}
+		endSpan := startSpan(ctx, "api.BodyStreamHandler")
+		err := handler(ctx, req, ctx.Request().Body, w)
+		endSpan(err)
+		if err != nil {
+			if w.n == 0 {
+				Error(ctx, err)
+			}
+			logger.Errorf("stream handler error after %d bytes: %+v", w.n, err)
+		}
+	}
+}
+
+// startSpan 为业务处理函数创建span并放入请求的ctx，业务函数通过iris.Context即可获取，返回结束span的函数
+func startSpan(ctx iris.Context, name string) func(err error) {
+	if route := ctx.GetCurrentRoute(); route != nil {
+		name += " " + route.Path()
+	}
+	spanCtx, span := tracing.Start(ctx.Request().Context(), name)
+	ctx.ResetRequest(ctx.Request().WithContext(spanCtx))
+	return func(err error) {
+		tracing.End(span, err)
+	}
+}
+
+// countingWriter 统计已写入的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write 实现io.Writer接口
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Flush 实现http.Flusher接口，将已写入的数据立即发送给客户端
+func (c *countingWriter) Flush() {
+	if flusher, ok := c.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// bindRequest 绑定请求参数
+// GET/DELETE等请求从URL query绑定(使用`url`标签)，其余请求读取JSON请求体，请求体为空或无法解析时返回错误
+func bindRequest(ctx iris.Context, req interface{}) error {
+	switch ctx.Method() {
+	case iris.MethodGet, iris.MethodHead, iris.MethodDelete:
+		if ctx.Request().URL.RawQuery == "" {
+			return nil
+		}
+		return ctx.ReadQuery(req)
+	}
+	return ctx.ReadJSON(req)
+}
+
 // ToIrisContext 安全地将context.Context转换为iris.Context
 // 如果ctx本身就是iris.Context或者包含iris.Context，则返回它
 // 否则返回nil和false
@@ -0,0 +1,419 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/kataras/iris/v12"
+	"godex/pkg/errs"
+	"godex/pkg/logger"
+	"html"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpenAPIVersion 生成的文档遵循的OpenAPI版本
+const OpenAPIVersion = "3.0.3"
+
+// routeKind 路由类型，决定请求与响应在文档中的描述方式
+type routeKind int
+
+const (
+	kindJSON       routeKind = iota // Handler: 请求绑定query或JSON请求体，响应为APIResponse
+	kindStream                      // StreamHandler: 响应直接写出
+	kindBodyStream                  // BodyStreamHandler: 请求参数来自query，请求体与响应均为流
+	kindSSE                         // SSEHandler
+	kindWebSocket                   // WebSocketHandler
+)
+
+// Operation 单个路由的文档信息
+type Operation struct {
+	Method              string
+	Path                string
+	Summary             string
+	Tag                 string
+	Request             reflect.Type
+	Response            reflect.Type // 仅kindJSON使用
+	RequestContentType  string       // 仅kindBodyStream使用
+	ResponseContentType string       // 流式响应的Content-Type
+
+	ErrorResponses []ErrorResponse // 中间件返回的400以外的错误响应，见Router.Responds
+
+	kind routeKind
+}
+
+// ErrorResponse 中间件(认证、限流等)以统一响应格式返回的错误，在文档中声明为对应的HTTP状态码
+type ErrorResponse struct {
+	Status      int
+	Description string
+	Codes       []int             // 可能返回的错误码
+	Headers     map[string]string // 响应头名称 -> 说明，值均为整数
+}
+
+// UnauthorizedResponse 认证失败时返回的401响应，codes为可能返回的错误码
+func UnauthorizedResponse(codes ...int) ErrorResponse {
+	return ErrorResponse{Status: iris.StatusUnauthorized, Description: "认证失败，缺少凭证或凭证无效", Codes: codes}
+}
+
+// RateLimitResponse 限流时返回的429响应，带RateLimit-*及Retry-After响应头，codes为可能返回的错误码
+func RateLimitResponse(codes ...int) ErrorResponse {
+	return ErrorResponse{
+		Status:      iris.StatusTooManyRequests,
+		Description: "请求频率超过限额，按Retry-After等待后重试",
+		Codes:       codes,
+		Headers: map[string]string{
+			"RateLimit-Limit":     "令牌桶容量(burst)",
+			"RateLimit-Remaining": "剩余可用的请求数",
+			"RateLimit-Reset":     "令牌桶补满所需的秒数",
+			"Retry-After":         "可重试前需等待的秒数",
+		},
+	}
+}
+
+// Spec 路由文档集合，由Router在注册路由时收集，线程安全
+type Spec struct {
+	Title       string
+	Version     string
+	Description string
+
+	mu         sync.RWMutex
+	operations []Operation
+	errorCodes []errs.CodeInfo
+}
+
+// NewSpec 创建路由文档集合
+func NewSpec(title, version string) *Spec {
+	return &Spec{Title: title, Version: version}
+}
+
+// Add 添加路由文档
+func (s *Spec) Add(operation Operation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.operations = append(s.operations, operation)
+}
+
+// AddErrorCodes 添加错误码文档
+func (s *Spec) AddErrorCodes(codes ...errs.CodeInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errorCodes = append(s.errorCodes, codes...)
+}
+
+// Operations 已收集的路由文档
+func (s *Spec) Operations() []Operation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Operation(nil), s.operations...)
+}
+
+// OpenAPI 生成OpenAPI 3文档
+func (s *Spec) OpenAPI() map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	builder := newSchemaBuilder()
+	builder.components["APIResponse"] = builder.structSchema(reflect.TypeFor[APIResponse]())
+
+	paths := map[string]map[string]any{}
+	tags := []map[string]any{}
+	seenTags := map[string]bool{}
+	for _, operation := range s.operations {
+		path := irisPathToOpenAPI(operation.Path)
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(operation.Method)] = builder.operation(operation)
+		if operation.Tag != "" && !seenTags[operation.Tag] {
+			seenTags[operation.Tag] = true
+			tags = append(tags, map[string]any{"name": operation.Tag})
+		}
+	}
+
+	return map[string]any{
+		"openapi": OpenAPIVersion,
+		"info": map[string]any{
+			"title":       s.Title,
+			"version":     s.Version,
+			"description": s.description(),
+		},
+		"tags":          tags,
+		"paths":         paths,
+		"components":    map[string]any{"schemas": builder.components},
+		"x-error-codes": s.errorCodes,
+	}
+}
+
+// description 文档说明，附带响应格式与错误码清单
+func (s *Spec) description() string {
+	var b strings.Builder
+	if s.Description != "" {
+		b.WriteString(s.Description + "\n\n")
+	}
+	b.WriteString("除流式接口外，所有接口均返回统一的`APIResponse`格式：成功时`code`为0且`data`为响应数据，失败时`code`为错误码。" +
+		"失败时HTTP状态码一般为400，认证失败、限流等由中间件返回的错误使用各接口声明的状态码(401、429)，响应体格式相同。\n\n")
+	if len(s.errorCodes) > 0 {
+		b.WriteString("| 错误码 | 名称 | 说明 |\n| --- | --- | --- |\n")
+		for _, code := range s.errorCodes {
+			fmt.Fprintf(&b, "| %d | %s | %s |\n", code.Code, code.Name, code.Description)
+		}
+	}
+	return b.String()
+}
+
+// Handler 以JSON格式返回OpenAPI文档
+func (s *Spec) Handler() iris.Handler {
+	return func(ctx iris.Context) {
+		logger.IgnoreError(ctx.JSON(s.OpenAPI()))
+	}
+}
+
+//go:embed docs.html
+var docsHTML string
+
+// DefaultRedocScriptURL 文档页面默认加载的Redoc脚本(官方CDN)
+const DefaultRedocScriptURL = "https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"
+
+// DocsHandler 返回文档页面(Redoc)，specURL为OpenAPI文档地址，scriptURL为Redoc脚本地址，为空时使用DefaultRedocScriptURL
+func DocsHandler(specURL, scriptURL string) iris.Handler {
+	if scriptURL == "" {
+		scriptURL = DefaultRedocScriptURL
+	}
+	page := strings.NewReplacer("{{SPEC_URL}}", html.EscapeString(specURL), "{{SCRIPT_URL}}", html.EscapeString(scriptURL)).Replace(docsHTML)
+	return func(ctx iris.Context) {
+		ctx.ContentType("text/html")
+		_, err := ctx.WriteString(page)
+		logger.IgnoreError(err)
+	}
+}
+
+// irisPathRegexp iris路径参数，如{id:uint64}
+var irisPathRegexp = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// irisPathToOpenAPI 将iris路径参数转换为OpenAPI格式
+func irisPathToOpenAPI(path string) string {
+	return irisPathRegexp.ReplaceAllString(path, "{$1}")
+}
+
+// operationID 由方法与路径生成operationId
+func operationID(method, path string) string {
+	id := strings.ToLower(method) + strings.NewReplacer("/", "_", "{", "", "}", "", "-", "_", ".", "_").Replace(path)
+	return strings.TrimSuffix(id, "_")
+}
+
+// schemaBuilder 由Go类型生成JSON Schema，具名结构体放入components
+type schemaBuilder struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{components: map[string]any{}, names: map[reflect.Type]string{}}
+}
+
+// operation 生成单个路由的文档
+func (b *schemaBuilder) operation(operation Operation) map[string]any {
+	doc := map[string]any{
+		"summary":     operation.Summary,
+		"operationId": operationID(operation.Method, operation.Path),
+	}
+	if operation.Tag != "" {
+		doc["tags"] = []string{operation.Tag}
+	}
+
+	// 请求参数：GET等请求及流式请求从query绑定，其余JSON请求读取请求体
+	queryOnly := operation.kind != kindJSON || operation.Method == iris.MethodGet || operation.Method == iris.MethodHead || operation.Method == iris.MethodDelete
+	if queryOnly {
+		if parameters := b.queryParameters(operation.Request); len(parameters) > 0 {
+			doc["parameters"] = parameters
+		}
+	} else if !isEmptyStruct(operation.Request) {
+		doc["requestBody"] = map[string]any{
+			"content": map[string]any{"application/json": map[string]any{"schema": b.schema(operation.Request)}},
+		}
+	}
+	if operation.kind == kindBodyStream {
+		doc["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{operation.RequestContentType: map[string]any{"schema": map[string]any{"type": "string"}}},
+		}
+	}
+
+	envelope := map[string]any{"$ref": "#/components/schemas/APIResponse"}
+	errorResponse := map[string]any{
+		"description": "请求失败，code为错误码",
+		"content":     map[string]any{"application/json": map[string]any{"schema": envelope}},
+	}
+	responses := map[string]any{"400": errorResponse}
+	for _, response := range operation.ErrorResponses {
+		description := response.Description
+		if len(response.Codes) > 0 {
+			codes := make([]string, 0, len(response.Codes))
+			for _, code := range response.Codes {
+				codes = append(codes, strconv.Itoa(code))
+			}
+			description += "，code为" + strings.Join(codes, "、")
+		}
+		responseDoc := map[string]any{
+			"description": description,
+			"content":     map[string]any{"application/json": map[string]any{"schema": envelope}},
+		}
+		if len(response.Headers) > 0 {
+			headers := map[string]any{}
+			for name, headerDescription := range response.Headers {
+				headers[name] = map[string]any{"description": headerDescription, "schema": map[string]any{"type": "integer"}}
+			}
+			responseDoc["headers"] = headers
+		}
+		responses[strconv.Itoa(response.Status)] = responseDoc
+	}
+	switch operation.kind {
+	case kindJSON:
+		responses["200"] = map[string]any{
+			"description": "成功",
+			"content": map[string]any{"application/json": map[string]any{"schema": map[string]any{
+				"allOf": []any{envelope, map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"data": b.schema(operation.Response)},
+				}},
+			}}},
+		}
+	case kindStream, kindBodyStream, kindSSE:
+		responses["200"] = map[string]any{
+			"description": "流式响应，写出数据后出错时连接直接断开",
+			"content":     map[string]any{operation.ResponseContentType: map[string]any{"schema": map[string]any{"type": "string"}}},
+		}
+	case kindWebSocket:
+		responses["101"] = map[string]any{"description": "升级为WebSocket连接，每条消息为一个JSON文本帧"}
+	}
+	doc["responses"] = responses
+	return doc
+}
+
+// queryParameters 由`url`标签生成query参数
+func (b *schemaBuilder) queryParameters(t reflect.Type) []map[string]any {
+	t = indirect(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var parameters []map[string]any
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("url"), ",")[0]
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		parameters = append(parameters, map[string]any{
+			"name":   name,
+			"in":     "query",
+			"schema": b.schema(field.Type),
+		})
+	}
+	return parameters
+}
+
+// schema 生成类型的JSON Schema
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	if t == nil {
+		return map[string]any{}
+	}
+	switch t {
+	case reflect.TypeFor[json.RawMessage]():
+		return map[string]any{}
+	case reflect.TypeFor[time.Time]():
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schema(t.Elem())
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + b.component(t)}
+	}
+	return map[string]any{}
+}
+
+// component 将具名结构体放入components，返回其名称，不同包的同名类型加包名前缀区分
+func (b *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, exists := b.components[name]; exists {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	b.names[t] = name
+	b.components[name] = map[string]any{} // 占位，避免递归类型死循环
+	b.components[name] = b.structSchema(t)
+	return name
+}
+
+// structSchema 按json标签生成结构体的Schema，匿名嵌入的结构体字段展开
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	b.collectFields(t, properties)
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+func (b *schemaBuilder) collectFields(t reflect.Type, properties map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			b.collectFields(indirect(field.Type), properties)
+			continue
+		}
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schema(field.Type)
+	}
+}
+
+// indirect 去除指针
+func indirect(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isEmptyStruct 是否为没有字段的结构体(无请求参数)
+func isEmptyStruct(t reflect.Type) bool {
+	t = indirect(t)
+	return t == nil || (t.Kind() == reflect.Struct && t.NumField() == 0)
+}
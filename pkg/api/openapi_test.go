@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"godex/pkg/errs"
+)
+
+type docItem struct {
+	Name  string   `json:"name"`
+	Inner *docItem `json:"inner,omitempty"`
+}
+
+type docReq struct {
+	Limit int    `url:"limit"`
+	Query string `url:"q"`
+}
+
+func TestSpecOpenAPI(t *testing.T) {
+	app := iris.New()
+	spec := NewSpec("test", "1.0.0")
+	spec.AddErrorCodes(errs.CodeInfo{Code: 1001, Name: "Bad", Description: "bad request"})
+	router := NewRouter(app, spec).Party("/v1", "items")
+	Get(router, "/items/{id:uint64}", "get item", func(ctx context.Context, req docReq) ([]docItem, error) { return nil, nil })
+	Post(router, "/items", "create item", func(ctx context.Context, req docItem) (docItem, error) { return req, nil })
+	secured := router.Party("/secured", "").Responds(RateLimitResponse(1008), UnauthorizedResponse(1007))
+	Get(secured, "/items", "list items", func(ctx context.Context, req docReq) ([]docItem, error) { return nil, nil })
+
+	responses := func(path, method string) map[string]any {
+		paths := spec.OpenAPI()["paths"].(map[string]map[string]any)
+		return paths[path][method].(map[string]any)["responses"].(map[string]any)
+	}
+	if _, ok := responses("/v1/items", "post")["401"]; ok {
+		t.Error("unsecured route declares 401")
+	}
+	unauthorized, ok := responses("/v1/secured/items", "get")["401"].(map[string]any)
+	if !ok || !strings.Contains(unauthorized["description"].(string), "1007") {
+		t.Errorf("secured route 401 = %v", unauthorized)
+	}
+	limited, ok := responses("/v1/secured/items", "get")["429"].(map[string]any)
+	if !ok || limited["headers"].(map[string]any)["Retry-After"] == nil || limited["headers"].(map[string]any)["RateLimit-Remaining"] == nil {
+		t.Errorf("secured route 429 = %v", limited)
+	}
+
+	data, err := json.Marshal(spec.OpenAPI())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	doc := string(data)
+	for _, want := range []string{
+		`"/v1/items/{id}"`,
+		`"name":"limit"`,
+		`"$ref":"#/components/schemas/docItem"`,
+		`"inner":{"$ref":"#/components/schemas/docItem"}`,
+		`"APIResponse"`,
+		"| 1001 | Bad | bad request |",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document missing %s", want)
+		}
+	}
+}
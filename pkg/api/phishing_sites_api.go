@@ -9,3 +9,117 @@ type CheckSitesRsp = []struct {
 	Domain string `json:"domain"` // 匹配到的
 	Source string `json:"source"` // 数据来源
 }
+
+// PhishingSitesStatsReq 缓存统计请求体 - GET请求无参数
+type PhishingSitesStatsReq struct{}
+
+// PhishingSitesStatsRsp 缓存统计响应体，时间为unix秒(0表示未发生)，耗时为毫秒
+type PhishingSitesStatsRsp struct {
+	Total               int            `json:"total"`                    // 缓存总条数
+	Sources             map[string]int `json:"sources"`                  // 当前缓存中各数据来源条数
+	Version             uint64         `json:"version"`                  // 快照版本号，每次成功加载加一
+	LoadCount           int            `json:"load_count"`               // 加载次数
+	LoadFailCount       int            `json:"load_fail_count"`          // 加载失败次数
+	LastLoadAt          int64          `json:"last_load_at"`             // 最近一次加载开始时间
+	LastLoadSuccessAt   int64          `json:"last_load_success_at"`     // 最近一次成功加载完成时间
+	LastLoadDurationMs  int64          `json:"last_load_duration_ms"`    // 最近一次加载耗时
+	RecentLoadDurations []int64        `json:"recent_load_durations_ms"` // 最近若干次加载耗时
+	LastLoadSources     map[string]int `json:"last_load_sources"`        // 最近一次加载中各数据来源写入条数
+	LastImportAt        int64          `json:"last_import_at"`           // 本进程最近一次成功导入时间
+	LastImportDuration  int64          `json:"last_import_duration_ms"`  // 本进程最近一次导入耗时
+	DataUpdatedAt       int64          `json:"data_updated_at"`          // 远端数据文件最后修改时间
+	LastError           string         `json:"last_error"`               // 最近一次加载/导入错误
+	LastErrorAt         int64          `json:"last_error_at"`            // 最近一次错误时间
+}
+
+// ExportSitesReq 导出请求参数(URL query)
+type ExportSitesReq struct {
+	Format  string `url:"format"`  // 导出格式: hosts/dnsmasq/unbound/rpz/adblock/plain，默认plain
+	Address string `url:"address"` // 拦截地址，为空时hosts使用0.0.0.0，dnsmasq/unbound返回NXDOMAIN
+}
+
+// HashFindReq 哈希前缀查询请求体，客户端对主机名及其父域名计算SHA-256，仅发送前缀
+type HashFindReq struct {
+	Prefixes []string `json:"prefixes"` // 十六进制编码的哈希前缀，长度4~32字节
+}
+
+// HashFindRsp 哈希前缀查询响应体
+type HashFindRsp struct {
+	Version uint64      `json:"version"` // 索引对应的快照版本号
+	Matches []HashMatch `json:"matches"` // 与请求前缀一一对应
+}
+
+// HashMatch 单个前缀的匹配结果
+type HashMatch struct {
+	Prefix     string     `json:"prefix"`      // 请求的前缀
+	FullHashes []FullHash `json:"full_hashes"` // 以该前缀开头的完整哈希，客户端自行与本地完整哈希比对
+}
+
+// FullHash 完整哈希
+type FullHash struct {
+	Hash   string `json:"hash"`   // 十六进制编码的SHA-256
+	Source string `json:"source"` // 数据来源
+}
+
+// HashPrefixesReq 哈希前缀集合下载请求参数(URL query)
+type HashPrefixesReq struct {
+	PrefixLen int `url:"prefix_len"` // 前缀长度(字节)，默认取配置hash-prefix-len
+}
+
+// HashPrefixesRsp 哈希前缀集合下载响应体
+type HashPrefixesRsp struct {
+	Version   uint64 `json:"version"`    // 索引对应的快照版本号
+	PrefixLen int    `json:"prefix_len"` // 前缀长度(字节)
+	Count     int    `json:"count"`      // 前缀个数
+	Prefixes  string `json:"prefixes"`   // 排序后拼接在一起的前缀，base64编码
+}
+
+// SyncSitesReq 增量同步请求体
+type SyncSitesReq struct {
+	Epoch      string `json:"epoch"`       // 上次同步返回的epoch，首次同步为空
+	Version    uint64 `json:"version"`     // 上次同步返回的快照版本号，首次同步为0
+	Encoding   int    `json:"encoding"`    // 客户端支持的最高编码版本: 1-JSON数组 2-按行gzip+base64，默认1
+	MaxEntries int    `json:"max_entries"` // 增量条目上限，超过则全量重置，0表示使用服务端上限
+}
+
+// SyncSitesRsp 增量同步响应体
+// reset为true时adds为完整列表，客户端需丢弃本地数据后写入；否则按adds/removes增量更新
+type SyncSitesRsp struct {
+	Epoch       string   `json:"epoch"`                  // 快照历史标识，服务重启后变化
+	Version     uint64   `json:"version"`                // 同步后的快照版本号
+	Reset       bool     `json:"reset"`                  // 是否全量重置
+	Encoding    int      `json:"encoding"`               // 实际使用的编码版本
+	Adds        []string `json:"adds,omitempty"`         // 新增域名(encoding=1)
+	Removes     []string `json:"removes,omitempty"`      // 删除域名(encoding=1)
+	AddsData    string   `json:"adds_data,omitempty"`    // 新增域名(encoding=2)
+	RemovesData string   `json:"removes_data,omitempty"` // 删除域名(encoding=2)
+}
+
+// CheckStreamReq 流式检查请求参数(URL query)，请求体为NDJSON: 每行一个主机名或{"id":..., "host":"..."}
+// 响应为NDJSON，每个非空输入行对应一行: {"line":1,"id":...,"query":"...","hit":true,"domain":"...","source":"..."}
+// 单行出错时输出{"line":1,"error":"..."}
+type CheckStreamReq struct{}
+
+// SubscribeUpdatesReq 订阅列表更新请求参数(URL query)
+// 带上次收到的epoch与version时从该版本续传，SSE断线重连时也可通过Last-Event-ID(格式"epoch:version")续传
+type SubscribeUpdatesReq struct {
+	Epoch       string `url:"epoch"`         // 上次收到的epoch
+	Version     uint64 `url:"version"`       // 上次收到的快照版本号
+	LastEventID string `url:"last_event_id"` // 上次收到的事件ID，优先级低于epoch/version
+}
+
+// 列表更新事件类型
+const (
+	UpdateEventHello  = "hello"  // 连接建立，告知当前版本，客户端版本落后时应先调用增量同步接口
+	UpdateEventDelta  = "delta"  // 增量更新，按adds/removes更新本地数据
+	UpdateEventResync = "resync" // 差异不可用或过大，客户端需调用增量同步接口重新同步
+)
+
+// UpdateEvent 列表更新事件，SSE事件名与WebSocket消息中的type一致
+type UpdateEvent struct {
+	Type    string   `json:"type"`              // 事件类型
+	Epoch   string   `json:"epoch"`             // 快照历史标识
+	Version uint64   `json:"version"`           // 快照版本号
+	Adds    []string `json:"adds,omitempty"`    // 新增域名
+	Removes []string `json:"removes,omitempty"` // 删除域名
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/kataras/iris/v12"
+	"godex/pkg/errs"
+	"godex/pkg/logger"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 推送默认参数
+const (
+	DefaultHeartbeat = 25 * time.Second
+	pushWriteTimeout = 10 * time.Second
+	maxCloseReason   = 123 // WebSocket关闭帧中原因的最大字节数
+)
+
+// Event 推送事件
+type Event struct {
+	ID   string      // 事件ID，SSE中作为id字段，客户端重连时通过Last-Event-ID带回
+	Name string      // 事件名，SSE中作为event字段
+	Data interface{} // 事件数据，序列化为JSON
+}
+
+// EventSender 发送推送事件，连接已断开时返回错误
+type EventSender func(event Event) error
+
+// streams 推送连接的生命周期，CloseStreams时取消，所有推送连接随之结束
+var streams, closeStreams = context.WithCancel(context.Background())
+
+// CloseStreams 结束所有SSE及WebSocket推送连接(业务函数的ctx被取消)，服务退出时在关闭Web服务前调用，
+// 避免长连接使Web服务等待至关闭超时；之后建立的推送连接立即结束
+func CloseStreams() {
+	closeStreams()
+}
+
+// streamContext 推送连接的ctx，客户端断开或CloseStreams时取消
+func streamContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(streams, cancel)
+	return streamCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// upgrader WebSocket升级，跨域策略与全局CORS中间件一致
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// SSEHandler 泛型Server-Sent Events处理器，请求绑定与Handler一致，业务函数通过send推送事件
+// 请求头Last-Event-ID在URL query未指定last_event_id时作为last_event_id绑定
+// 响应头在首次发送事件时写出，此前业务函数返回错误时按统一的APIResponse格式返回错误
+// 连接建立后每隔heartbeat发送一次注释行作为心跳，心跳写入失败或CloseStreams时取消传给业务函数的ctx
+func SSEHandler[TReq any](heartbeat time.Duration, handler func(ctx context.Context, req TReq, send EventSender) error) iris.Handler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+	return func(ctx iris.Context) {
+		var req TReq
+
+		if lastEventID := ctx.GetHeader("Last-Event-ID"); lastEventID != "" {
+			query := ctx.Request().URL.Query()
+			if !query.Has("last_event_id") {
+				query.Set("last_event_id", lastEventID)
+				ctx.Request().URL.RawQuery = query.Encode()
+			}
+		}
+		if err := bindRequest(ctx, &req); err != nil {
+			Error(ctx, errs.NewFrameError(errs.RetClientEncodeFail, err.Error()))
+			logger.Errorf("request parse json fail, err: %+v", err)
+			return
+		}
+
+		disableCompression(ctx)
+		endSpan := startSpan(ctx, "api.SSEHandler")
+		streamCtx, cancel := streamContext(ctx)
+		defer cancel()
+
+		var mu sync.Mutex
+		started := false
+		write := func(p []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if !started {
+				ctx.ContentType("text/event-stream")
+				ctx.Header("Cache-Control", "no-cache")
+				ctx.Header("X-Accel-Buffering", "no")
+				started = true
+			}
+			if _, err := ctx.ResponseWriter().Write(p); err != nil {
+				cancel()
+				return err
+			}
+			// 通过底层ResponseWriter刷新以获取写入错误，及时感知客户端断开
+			ctx.ResponseWriter().Flush()
+			if err := http.NewResponseController(ctx.ResponseWriter().Naive()).Flush(); err != nil {
+				cancel()
+				return err
+			}
+			return nil
+		}
+		send := func(event Event) error {
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				return err
+			}
+			var buf bytes.Buffer
+			if event.ID != "" {
+				fmt.Fprintf(&buf, "id: %s\n", event.ID)
+			}
+			if event.Name != "" {
+				fmt.Fprintf(&buf, "event: %s\n", event.Name)
+			}
+			fmt.Fprintf(&buf, "data: %s\n\n", data)
+			return write(buf.Bytes())
+		}
+
+		// 心跳，仅在响应头写出后发送
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-streamCtx.Done():
+					return
+				case <-ticker.C:
+					mu.Lock()
+					ready := started
+					mu.Unlock()
+					if ready {
+						logger.IgnoreError(write([]byte(": ping\n\n")))
+					}
+				}
+			}
+		}()
+
+		err := handler(streamCtx, req, send)
+		close(done)
+		wg.Wait()
+		endSpan(err)
+		if err != nil {
+			if !started {
+				Error(ctx, err)
+			}
+			logger.Errorf("sse handler error: %+v", err)
+		}
+	}
+}
+
+// WebSocketHandler 泛型WebSocket处理器，请求参数从URL query绑定，业务函数通过send推送事件，每个事件的Data作为一条JSON文本消息
+// 连接在首次发送事件时升级，此前业务函数返回错误时按统一的APIResponse格式返回错误
+// 连接建立后每隔heartbeat发送一次Ping，客户端断开、超过两个心跳周期未响应或CloseStreams时取消传给业务函数的ctx
+func WebSocketHandler[TReq any](heartbeat time.Duration, handler func(ctx context.Context, req TReq, send EventSender) error) iris.Handler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+	return func(ctx iris.Context) {
+		var req TReq
+
+		if err := bindRequest(ctx, &req); err != nil {
+			Error(ctx, errs.NewFrameError(errs.RetClientEncodeFail, err.Error()))
+			logger.Errorf("request parse json fail, err: %+v", err)
+			return
+		}
+
+		disableCompression(ctx)
+		endSpan := startSpan(ctx, "api.WebSocketHandler")
+		streamCtx, cancel := streamContext(ctx)
+		defer cancel()
+
+		var mu sync.Mutex
+		var conn *websocket.Conn
+		upgraded := false
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+
+		// upgrade 升级连接并启动读取与心跳，调用方需持有锁
+		upgrade := func() error {
+			upgraded = true
+			c, err := upgrader.Upgrade(ctx.ResponseWriter(), ctx.Request(), nil)
+			if err != nil {
+				return err
+			}
+			conn = c
+			ctx.StatusCode(http.StatusSwitchingProtocols)
+
+			// 读取客户端消息(仅处理控制帧)，用于感知断开及Pong
+			pongWait := 2 * heartbeat
+			logger.IgnoreError(conn.SetReadDeadline(time.Now().Add(pongWait)))
+			conn.SetPongHandler(func(string) error {
+				return conn.SetReadDeadline(time.Now().Add(pongWait))
+			})
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for {
+					if _, _, err := conn.NextReader(); err != nil {
+						cancel()
+						return
+					}
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				ticker := time.NewTicker(heartbeat)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-done:
+						return
+					case <-streamCtx.Done():
+						return
+					case <-ticker.C:
+						if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pushWriteTimeout)); err != nil {
+							cancel()
+							return
+						}
+					}
+				}
+			}()
+			return nil
+		}
+		send := func(event Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if conn == nil {
+				if upgraded {
+					return fmt.Errorf("websocket upgrade failed")
+				}
+				if err := upgrade(); err != nil {
+					return err
+				}
+			}
+			logger.IgnoreError(conn.SetWriteDeadline(time.Now().Add(pushWriteTimeout)))
+			if err := conn.WriteJSON(event.Data); err != nil {
+				cancel()
+				return err
+			}
+			return nil
+		}
+
+		err := handler(streamCtx, req, send)
+		close(done)
+		endSpan(err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if conn == nil {
+			// 升级失败时已由upgrader返回HTTP错误
+			if err != nil && !upgraded {
+				Error(ctx, err)
+			}
+			if err != nil {
+				logger.Errorf("websocket handler error: %+v", err)
+			}
+			return
+		}
+
+		closeCode, reason := websocket.CloseNormalClosure, ""
+		if streams.Err() != nil {
+			// 服务退出，客户端应重新连接
+			closeCode = websocket.CloseGoingAway
+		}
+		if err != nil {
+			closeCode, reason = websocket.CloseInternalServerErr, err.Error()
+			if len(reason) > maxCloseReason {
+				reason = reason[:maxCloseReason]
+			}
+			logger.Errorf("websocket handler error: %+v", err)
+		}
+		logger.IgnoreError(conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, reason), time.Now().Add(pushWriteTimeout)))
+		logger.IgnoreError(conn.Close())
+		wg.Wait()
+	}
+}
+
+// disableCompression 关闭响应压缩，推送的数据需要逐条发送
+func disableCompression(ctx iris.Context) {
+	logger.IgnoreError(ctx.CompressWriter(false))
+	ctx.ResponseWriter().Header().Del("Content-Encoding")
+	ctx.ResponseWriter().Header().Del("Vary")
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"io"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// Router 路由注册器，注册路由的同时将请求/响应类型收集到Spec中用于生成接口文档
+type Router struct {
+	party iris.Party
+	spec  *Spec
+	tag   string
+
+	errorResponses []ErrorResponse // 之后注册的路由在文档中声明的错误响应
+}
+
+// NewRouter 创建路由注册器
+func NewRouter(party iris.Party, spec *Spec) *Router {
+	return &Router{party: party, spec: spec}
+}
+
+// Party 创建子路由，tag为文档中的分组名称，为空时沿用上级分组
+func (r *Router) Party(relativePath, tag string) *Router {
+	if tag == "" {
+		tag = r.tag
+	}
+	return &Router{party: r.party.Party(relativePath), spec: r.spec, tag: tag, errorResponses: r.errorResponses}
+}
+
+// Use 为子路由注册中间件，仅对之后注册的路由生效
+func (r *Router) Use(handlers ...iris.Handler) *Router {
+	r.party.Use(handlers...)
+	return r
+}
+
+// Responds 声明之后注册的路由(含子路由)可能由中间件返回的错误响应，仅影响文档，与Use配合使用
+func (r *Router) Responds(responses ...ErrorResponse) *Router {
+	r.errorResponses = append(slices.Clip(r.errorResponses), responses...)
+	return r
+}
+
+// Spec 路由文档集合
+func (r *Router) Spec() *Spec {
+	return r.spec
+}
+
+// add 注册路由并记录文档
+func (r *Router) add(method, path string, handler iris.Handler, operation Operation) *router.Route {
+	route := r.party.Handle(method, path, handler)
+	operation.Method, operation.Path, operation.Tag = route.Method, route.Tmpl().Src, r.tag
+	operation.ErrorResponses = r.errorResponses
+	r.spec.Add(operation)
+	return route
+}
+
+// Get 注册GET JSON接口，见Handler
+func Get[TReq any, TRsp any](r *Router, path, summary string, handler func(ctx context.Context, req TReq) (TRsp, error)) *router.Route {
+	return r.add(iris.MethodGet, path, Handler(handler), jsonOperation[TReq, TRsp](summary))
+}
+
+// Post 注册POST JSON接口，见Handler
+func Post[TReq any, TRsp any](r *Router, path, summary string, handler func(ctx context.Context, req TReq) (TRsp, error)) *router.Route {
+	return r.add(iris.MethodPost, path, Handler(handler), jsonOperation[TReq, TRsp](summary))
+}
+
+// GetStream 注册GET流式响应接口，contentType为响应类型，见StreamHandler
+func GetStream[TReq any](r *Router, path, summary, contentType string, handler func(ctx context.Context, req TReq, w io.Writer) error) *router.Route {
+	return r.add(iris.MethodGet, path, StreamHandler(handler), Operation{
+		Summary:             summary,
+		Request:             reflect.TypeFor[TReq](),
+		ResponseContentType: contentType,
+		kind:                kindStream,
+	})
+}
+
+// PostBodyStream 注册POST请求体流式接口，见BodyStreamHandler
+func PostBodyStream[TReq any](r *Router, path, summary, requestContentType, contentType string, handler func(ctx context.Context, req TReq, r io.Reader, w io.Writer) error) *router.Route {
+	return r.add(iris.MethodPost, path, BodyStreamHandler(handler), Operation{
+		Summary:             summary,
+		Request:             reflect.TypeFor[TReq](),
+		RequestContentType:  requestContentType,
+		ResponseContentType: contentType,
+		kind:                kindBodyStream,
+	})
+}
+
+// GetSSE 注册Server-Sent Events接口，见SSEHandler
+func GetSSE[TReq any](r *Router, path, summary string, heartbeat time.Duration, handler func(ctx context.Context, req TReq, send EventSender) error) *router.Route {
+	return r.add(iris.MethodGet, path, SSEHandler(heartbeat, handler), Operation{
+		Summary:             summary,
+		Request:             reflect.TypeFor[TReq](),
+		ResponseContentType: "text/event-stream",
+		kind:                kindSSE,
+	})
+}
+
+// GetWebSocket 注册WebSocket接口，见WebSocketHandler
+func GetWebSocket[TReq any](r *Router, path, summary string, heartbeat time.Duration, handler func(ctx context.Context, req TReq, send EventSender) error) *router.Route {
+	return r.add(iris.MethodGet, path, WebSocketHandler(heartbeat, handler), Operation{
+		Summary: summary,
+		Request: reflect.TypeFor[TReq](),
+		kind:    kindWebSocket,
+	})
+}
+
+// jsonOperation JSON接口的文档信息
+func jsonOperation[TReq any, TRsp any](summary string) Operation {
+	return Operation{
+		Summary:  summary,
+		Request:  reflect.TypeFor[TReq](),
+		Response: reflect.TypeFor[TRsp](),
+		kind:     kindJSON,
+	}
+}
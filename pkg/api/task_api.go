@@ -0,0 +1,39 @@
+package api
+
+// TasksReq 任务状态请求体 - GET请求无参数
+type TasksReq struct{}
+
+// TasksRsp 任务状态响应体，按配置顺序
+type TasksRsp = []TaskStatus
+
+// TaskStatus 任务状态，时间为unix秒(0表示无)
+type TaskStatus struct {
+	Name        string   `json:"name"`
+	Function    string   `json:"function"`
+	Cron        string   `json:"cron"`
+	Description string   `json:"description"`
+	Enable      bool     `json:"enable"`
+	LastRun     *TaskRun `json:"last_run"`    // 最近一次执行，未执行过时为null
+	NextRunAt   int64    `json:"next_run_at"` // 下次执行时间，未启用及@once任务为0
+	Error       string   `json:"error"`       // 配置错误，如cron表达式无效或任务函数未注册
+}
+
+// TaskRunsReq 任务执行记录请求参数(URL query)
+type TaskRunsReq struct {
+	Task  string `url:"task"`  // 任务名称，为空时返回所有任务
+	Limit int    `url:"limit"` // 最多返回条数，默认50
+}
+
+// TaskRunsRsp 任务执行记录响应体，按开始时间从晚到早
+type TaskRunsRsp = []TaskRun
+
+// TaskRun 一次任务执行记录
+type TaskRun struct {
+	Task       string `json:"task"`
+	Function   string `json:"function"`
+	Trigger    string `json:"trigger"`     // cron或once
+	StartedAt  int64  `json:"started_at"`  // 开始时间
+	DurationMs int64  `json:"duration_ms"` // 耗时(毫秒)
+	Result     string `json:"result"`      // success、failure、skipped或timeout
+	Error      string `json:"error"`
+}
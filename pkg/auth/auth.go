@@ -0,0 +1,358 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认配置
+const (
+	defaultReplayWindow = 300
+	defaultMaxBodyBytes = 1 << 20
+	defaultMaxNonces    = 100000
+	maxNonceLength      = 128
+)
+
+// 请求头
+const (
+	HeaderAPIKey        = "X-Api-Key"              // 静态API Key
+	HeaderClient        = "X-Godex-Client"         // 签名请求的客户端ID
+	HeaderTimestamp     = "X-Godex-Timestamp"      // 签名时间(Unix秒)
+	HeaderNonce         = "X-Godex-Nonce"          // 随机串，同一客户端在重放窗口内不可重复
+	HeaderContentSHA256 = "X-Godex-Content-Sha256" // 请求体的hex(SHA-256)，NDJSON流式请求可为UNSIGNED-PAYLOAD
+	HeaderSignature     = "X-Godex-Signature"      // 签名: sha256=hex(HMAC-SHA256(secret, StringToSign))
+)
+
+// QueryAPIKey 浏览器EventSource/WebSocket无法设置请求头，订阅接口可通过该查询参数传递API Key，见AuthenticateQuery
+const QueryAPIKey = "api_key"
+
+// UnsignedPayload 请求体不参与签名，仅允许用于NDJSON流式请求，避免服务端缓冲整个流
+const UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// 认证方式
+const (
+	MethodAPIKey = "api-key"
+	MethodHMAC   = "hmac"
+)
+
+// 认证失败原因
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrUnknownClient      = errors.New("unknown client")
+	ErrInvalidTimestamp   = errors.New("invalid or expired timestamp")
+	ErrInvalidNonce       = errors.New("invalid nonce")
+	ErrReplayedRequest    = errors.New("replayed request")
+	ErrBodyHashMismatch   = errors.New("content hash mismatch")
+	ErrBodyTooLarge       = errors.New("request body too large to verify")
+	ErrInvalidSignature   = errors.New("invalid signature")
+)
+
+// Config 接口认证配置
+type Config struct {
+	Enable       bool           `yaml:"enable" json:"enable"`
+	Clients      []ClientConfig `yaml:"clients" json:"clients"`
+	ReplayWindow int            `yaml:"replay-window" json:"replay-window"`   // 签名时间允许的偏差(秒)，同一nonce在窗口内只能使用一次，默认300
+	MaxBodyBytes int            `yaml:"max-body-bytes" json:"max-body-bytes"` // 校验签名时读取请求体的上限(字节)，默认1048576
+
+	// AdminClients 管理接口(/admin)的客户端，与clients相互独立且不受enable影响，未配置时不提供管理接口
+	AdminClients []ClientConfig `yaml:"admin-clients" json:"admin-clients"`
+}
+
+// Admin 管理接口的认证配置，客户端为AdminClients，其余配置相同
+func (c Config) Admin() Config {
+	c.Clients, c.AdminClients = c.AdminClients, nil
+	return c
+}
+
+// ClientConfig 客户端配置，APIKey与Secret至少配置一个
+type ClientConfig struct {
+	ID     string `yaml:"id" json:"id"`
+	APIKey string `yaml:"api-key" json:"api-key"` // 静态API Key，为空时不允许使用API Key认证
+	Secret string `yaml:"secret" json:"secret"`   // HMAC签名密钥，为空时不允许使用签名认证
+}
+
+// Identity 认证通过的调用方
+type Identity struct {
+	ClientID string `json:"client_id"`
+	Method   string `json:"method"` // 认证方式，见Method*
+}
+
+// identityKey context中保存调用方的键
+type identityKey struct{}
+
+// WithIdentity 将调用方放入ctx
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom 获取ctx中的调用方，未认证时返回false
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// ClientID 获取ctx中调用方的客户端ID，未认证时为空
+func ClientID(ctx context.Context) string {
+	identity, _ := IdentityFrom(ctx)
+	return identity.ClientID
+}
+
+// StringToSign 待签名串: METHOD\nPATH\nRAW_QUERY\nTIMESTAMP\nNONCE\nCONTENT_SHA256
+func StringToSign(method, path, rawQuery, timestamp, nonce, contentHash string) string {
+	return strings.Join([]string{strings.ToUpper(method), path, rawQuery, timestamp, nonce, contentHash}, "\n")
+}
+
+// Sign 计算签名
+func Sign(secret, stringToSign string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashBody 计算请求体的hex(SHA-256)
+func HashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// SignRequest 为请求添加签名头，供Go客户端使用
+// NDJSON请求及无法重放的请求体(GetBody为nil)使用UNSIGNED-PAYLOAD，其余请求体通过GetBody读取后计算哈希
+func SignRequest(req *http.Request, clientID, secret string, nonce string) error {
+	contentHash, err := requestContentHash(req)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(HeaderClient, clientID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderContentSHA256, contentHash)
+	req.Header.Set(HeaderSignature, Sign(secret, StringToSign(req.Method, req.URL.Path, req.URL.RawQuery, timestamp, nonce, contentHash)))
+	return nil
+}
+
+// requestContentHash 计算客户端请求体的哈希
+func requestContentHash(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return HashBody(nil), nil
+	}
+	if isNDJSON(req) || req.GetBody == nil {
+		return UnsignedPayload, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", fmt.Errorf("read request body failed: %v", err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("read request body failed: %v", err)
+	}
+	return HashBody(data), nil
+}
+
+// isNDJSON 是否为NDJSON流式请求
+func isNDJSON(req *http.Request) bool {
+	return strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-ndjson")
+}
+
+// Authenticator 校验请求的API Key或HMAC签名，线程安全
+type Authenticator struct {
+	keys         map[[sha256.Size]byte]string // API Key的SHA-256 -> 客户端ID，按哈希查找避免逐字节比较泄露时序
+	secrets      map[string]string            // 客户端ID -> 签名密钥
+	replayWindow time.Duration
+	maxBodyBytes int64
+	nonces       *nonceCache
+}
+
+// NewAuthenticator 创建认证器，客户端ID为空、重复或未配置任何凭证时返回错误
+func NewAuthenticator(config Config) (*Authenticator, error) {
+	if config.ReplayWindow <= 0 {
+		config.ReplayWindow = defaultReplayWindow
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaultMaxBodyBytes
+	}
+
+	a := &Authenticator{
+		keys:         map[[sha256.Size]byte]string{},
+		secrets:      map[string]string{},
+		replayWindow: time.Duration(config.ReplayWindow) * time.Second,
+		maxBodyBytes: int64(config.MaxBodyBytes),
+		nonces:       newNonceCache(defaultMaxNonces),
+	}
+	seen := map[string]bool{}
+	for _, client := range config.Clients {
+		switch {
+		case client.ID == "":
+			return nil, fmt.Errorf("auth client id is empty")
+		case seen[client.ID]:
+			return nil, fmt.Errorf("auth client %q is duplicated", client.ID)
+		case client.APIKey == "" && client.Secret == "":
+			return nil, fmt.Errorf("auth client %q has neither api-key nor secret", client.ID)
+		}
+		seen[client.ID] = true
+
+		if client.APIKey != "" {
+			hash := sha256.Sum256([]byte(client.APIKey))
+			if owner, ok := a.keys[hash]; ok {
+				return nil, fmt.Errorf("auth client %q reuses the api-key of %q", client.ID, owner)
+			}
+			a.keys[hash] = client.ID
+		}
+		if client.Secret != "" {
+			a.secrets[client.ID] = client.Secret
+		}
+	}
+	return a, nil
+}
+
+// Authenticate 校验请求，携带X-Api-Key时按API Key认证，否则按HMAC签名认证
+// 签名认证会读取请求体计算哈希，读取后重置req.Body供后续处理函数使用
+func (a *Authenticator) Authenticate(req *http.Request) (Identity, error) {
+	if key := req.Header.Get(HeaderAPIKey); key != "" {
+		return a.AuthenticateAPIKey(key)
+	}
+	if req.Header.Get(HeaderSignature) != "" {
+		return a.verifySignature(req)
+	}
+	return Identity{}, ErrMissingCredentials
+}
+
+// AuthenticateQuery 与Authenticate相同，请求头中没有凭证时再校验查询参数QueryAPIKey中的API Key
+// 查询参数可能出现在访问日志及代理日志中，仅用于无法设置请求头的订阅接口
+func (a *Authenticator) AuthenticateQuery(req *http.Request) (Identity, error) {
+	identity, err := a.Authenticate(req)
+	if !errors.Is(err, ErrMissingCredentials) {
+		return identity, err
+	}
+	if key := req.URL.Query().Get(QueryAPIKey); key != "" {
+		return a.AuthenticateAPIKey(key)
+	}
+	return Identity{}, ErrMissingCredentials
+}
+
+// AuthenticateAPIKey 校验静态API Key，供不经过HTTP请求头传递凭证的调用方(如gRPC元数据)使用
+func (a *Authenticator) AuthenticateAPIKey(key string) (Identity, error) {
+	if key == "" {
+		return Identity{}, ErrMissingCredentials
+	}
+	clientID, ok := a.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return Identity{}, ErrInvalidAPIKey
+	}
+	return Identity{ClientID: clientID, Method: MethodAPIKey}, nil
+}
+
+// verifySignature 校验HMAC签名，签名通过后才记录nonce，避免伪造请求占满nonce缓存
+func (a *Authenticator) verifySignature(req *http.Request) (Identity, error) {
+	clientID := req.Header.Get(HeaderClient)
+	secret, ok := a.secrets[clientID]
+	if !ok {
+		return Identity{}, ErrUnknownClient
+	}
+
+	timestamp := req.Header.Get(HeaderTimestamp)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return Identity{}, ErrInvalidTimestamp
+	}
+	signedAt := time.Unix(unix, 0)
+	if skew := time.Since(signedAt); skew > a.replayWindow || skew < -a.replayWindow {
+		return Identity{}, ErrInvalidTimestamp
+	}
+
+	nonce := req.Header.Get(HeaderNonce)
+	if nonce == "" || len(nonce) > maxNonceLength {
+		return Identity{}, ErrInvalidNonce
+	}
+
+	contentHash := req.Header.Get(HeaderContentSHA256)
+	if err = a.verifyBody(req, contentHash); err != nil {
+		return Identity{}, err
+	}
+
+	expected := Sign(secret, StringToSign(req.Method, req.URL.Path, req.URL.RawQuery, timestamp, nonce, contentHash))
+	if !hmac.Equal([]byte(expected), []byte(req.Header.Get(HeaderSignature))) {
+		return Identity{}, ErrInvalidSignature
+	}
+
+	// nonce记录到签名时间加一个窗口，之后时间戳校验已能拒绝重放；缓存已满时同样拒绝
+	if !a.nonces.add(clientID+"\n"+nonce, signedAt.Add(a.replayWindow)) {
+		return Identity{}, ErrReplayedRequest
+	}
+	return Identity{ClientID: clientID, Method: MethodHMAC}, nil
+}
+
+// verifyBody 校验请求体哈希，UNSIGNED-PAYLOAD仅允许用于NDJSON请求
+func (a *Authenticator) verifyBody(req *http.Request, contentHash string) error {
+	if contentHash == UnsignedPayload {
+		if !isNDJSON(req) {
+			return ErrBodyHashMismatch
+		}
+		return nil
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(req.Body, a.maxBodyBytes+1))
+		if err != nil {
+			return fmt.Errorf("read request body failed: %v", err)
+		}
+		if int64(len(body)) > a.maxBodyBytes {
+			return ErrBodyTooLarge
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if !hmac.Equal([]byte(HashBody(body)), []byte(strings.ToLower(contentHash))) {
+		return ErrBodyHashMismatch
+	}
+	return nil
+}
+
+// nonceCache 记录重放窗口内已使用的nonce，条目数达到上限时先清理过期条目
+type nonceCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	max     int
+}
+
+func newNonceCache(max int) *nonceCache {
+	return &nonceCache{entries: map[string]time.Time{}, max: max}
+}
+
+// add 记录nonce，已存在且未过期或缓存已满时返回false
+func (c *nonceCache) add(nonce string, expireAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := c.entries[nonce]; ok && existing.After(now) {
+		return false
+	}
+	if len(c.entries) >= c.max {
+		for key, existing := range c.entries {
+			if !existing.After(now) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= c.max {
+			return false
+		}
+	}
+	c.entries[nonce] = expireAt
+	return true
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	a, err := NewAuthenticator(Config{Clients: []ClientConfig{
+		{ID: "ext", APIKey: "key-1"},
+		{ID: "partner", Secret: "secret-1"},
+	}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	return a
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/browserext/phishing_sites/stats", nil)
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrMissingCredentials) {
+		t.Fatalf("no credentials: got %v", err)
+	}
+	req.Header.Set(HeaderAPIKey, "wrong")
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("wrong key: got %v", err)
+	}
+	req.Header.Set(HeaderAPIKey, "key-1")
+	identity, err := a.Authenticate(req)
+	if err != nil || identity != (Identity{ClientID: "ext", Method: MethodAPIKey}) {
+		t.Fatalf("valid key: got %+v, %v", identity, err)
+	}
+}
+
+func TestAuthenticateSignature(t *testing.T) {
+	a := newTestAuthenticator(t)
+	body := `{"domains":["a.com"]}`
+	newRequest := func(nonce string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "http://godex/browserext/phishing_sites/check?x=1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if err := SignRequest(req, "partner", "secret-1", nonce); err != nil {
+			t.Fatalf("SignRequest: %v", err)
+		}
+		return req
+	}
+
+	req := newRequest("n1")
+	identity, err := a.Authenticate(req)
+	if err != nil || identity != (Identity{ClientID: "partner", Method: MethodHMAC}) {
+		t.Fatalf("valid signature: got %+v, %v", identity, err)
+	}
+	if data, _ := io.ReadAll(req.Body); string(data) != body {
+		t.Fatalf("body not restored: %q", data)
+	}
+
+	if _, err = a.Authenticate(newRequest("n1")); !errors.Is(err, ErrReplayedRequest) {
+		t.Fatalf("replayed nonce: got %v", err)
+	}
+
+	tampered := newRequest("n2")
+	tampered.Body = io.NopCloser(bytes.NewReader([]byte(`{"domains":["b.com"]}`)))
+	if _, err = a.Authenticate(tampered); !errors.Is(err, ErrBodyHashMismatch) {
+		t.Fatalf("tampered body: got %v", err)
+	}
+
+	expired := newRequest("n3")
+	expired.Header.Set(HeaderTimestamp, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+	if _, err = a.Authenticate(expired); !errors.Is(err, ErrInvalidTimestamp) {
+		t.Fatalf("expired timestamp: got %v", err)
+	}
+
+	wrongPath := newRequest("n4")
+	wrongPath.URL.Path = "/browserext/phishing_sites/sync"
+	if _, err = a.Authenticate(wrongPath); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("wrong path: got %v", err)
+	}
+}
+
+func TestAuthenticateUnsignedPayload(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	// 无法重放的NDJSON请求体不参与签名
+	req, _ := http.NewRequest(http.MethodPost, "http://godex/browserext/phishing_sites/check/stream", io.NopCloser(strings.NewReader("a.com\n")))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if err := SignRequest(req, "partner", "secret-1", "s1"); err != nil {
+		t.Fatalf("SignRequest: %v", err)
+	}
+	if got := req.Header.Get(HeaderContentSHA256); got != UnsignedPayload {
+		t.Fatalf("content hash = %q", got)
+	}
+	if _, err := a.Authenticate(req); err != nil {
+		t.Fatalf("unsigned ndjson: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderNonce, "s2")
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrBodyHashMismatch) {
+		t.Fatalf("unsigned json: got %v", err)
+	}
+}
+
+func TestNewAuthenticatorValidate(t *testing.T) {
+	cases := map[string][]ClientConfig{
+		"empty id":       {{APIKey: "k"}},
+		"duplicate id":   {{ID: "a", APIKey: "k1"}, {ID: "a", APIKey: "k2"}},
+		"no credentials": {{ID: "a"}},
+		"shared key":     {{ID: "a", APIKey: "k"}, {ID: "b", APIKey: "k"}},
+	}
+	for name, clients := range cases {
+		if _, err := NewAuthenticator(Config{Clients: clients}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAdminConfig(t *testing.T) {
+	a, err := NewAuthenticator(Config{
+		Clients:      []ClientConfig{{ID: "ext", APIKey: "key-1"}},
+		AdminClients: []ClientConfig{{ID: "ops", APIKey: "admin-1"}},
+	}.Admin())
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/tasks", nil)
+	req.Header.Set(HeaderAPIKey, "key-1")
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("browserext key: got %v", err)
+	}
+	req.Header.Set(HeaderAPIKey, "admin-1")
+	identity, err := a.Authenticate(req)
+	if err != nil || identity.ClientID != "ops" {
+		t.Fatalf("admin key: got %+v, %v", identity, err)
+	}
+}
+
+func TestAuthenticateQuery(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/browserext/phishing_sites/updates/sse?"+QueryAPIKey+"=key-1", nil)
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrMissingCredentials) {
+		t.Fatalf("Authenticate ignores query key: got %v", err)
+	}
+	identity, err := a.AuthenticateQuery(req)
+	if err != nil || identity != (Identity{ClientID: "ext", Method: MethodAPIKey}) {
+		t.Fatalf("query key: got %+v, %v", identity, err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/browserext/phishing_sites/updates/ws?"+QueryAPIKey+"=wrong", nil)
+	if _, err = a.AuthenticateQuery(req); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("wrong query key: got %v", err)
+	}
+	// 请求头中的凭证优先
+	req.Header.Set(HeaderAPIKey, "key-1")
+	if _, err = a.AuthenticateQuery(req); err != nil {
+		t.Fatalf("header key: got %v", err)
+	}
+}
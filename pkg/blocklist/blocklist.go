@@ -0,0 +1,199 @@
+package blocklist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format 导出格式
+type Format string
+
+// 支持的导出格式
+const (
+	FormatHosts   Format = "hosts"   // hosts文件: 0.0.0.0 example.com
+	FormatDnsmasq Format = "dnsmasq" // dnsmasq: address=/example.com/
+	FormatUnbound Format = "unbound" // Unbound: local-zone: "example.com." always_nxdomain
+	FormatRPZ     Format = "rpz"     // BIND RPZ zone: example.com CNAME .
+	FormatAdblock Format = "adblock" // AdGuard/uBlock过滤规则: ||example.com^
+	FormatPlain   Format = "plain"   // 纯文本，每行一个域名
+)
+
+// Formats 所有支持的导出格式
+var Formats = []Format{FormatHosts, FormatDnsmasq, FormatUnbound, FormatRPZ, FormatAdblock, FormatPlain}
+
+// ParseFormat 解析导出格式，空字符串默认为纯文本
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return FormatPlain, nil
+	}
+	for _, format := range Formats {
+		if strings.EqualFold(s, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported format %q, supported: %v", s, Formats)
+}
+
+// ContentType 导出格式对应的Content-Type
+func (f Format) ContentType() string {
+	if f == FormatRPZ {
+		return "text/dns; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// FileName 导出格式对应的默认文件名
+func (f Format) FileName(name string) string {
+	switch f {
+	case FormatHosts:
+		return name + ".hosts"
+	case FormatDnsmasq, FormatUnbound:
+		return name + "." + string(f) + ".conf"
+	case FormatRPZ:
+		return name + ".rpz.zone"
+	default:
+		return name + "." + string(f) + ".txt"
+	}
+}
+
+// Options 导出选项
+type Options struct {
+	Title       string    // 列表标题，写入文件头注释
+	Address     string    // 拦截地址，为空时hosts使用0.0.0.0，dnsmasq/unbound返回NXDOMAIN
+	Version     uint64    // 快照版本号，写入文件头注释
+	GeneratedAt time.Time // 生成时间，RPZ的SOA序列号基于该时间
+}
+
+// Writer 流式写入黑名单，先调用Block/Allow逐条写入，最后调用Close刷新缓冲
+type Writer struct {
+	w       *bufio.Writer
+	format  Format
+	opts    Options
+	started bool
+	count   int
+}
+
+// NewWriter 创建黑名单写入器
+func NewWriter(w io.Writer, format Format, opts Options) *Writer {
+	if opts.GeneratedAt.IsZero() {
+		opts.GeneratedAt = time.Now()
+	}
+	return &Writer{
+		w:      bufio.NewWriterSize(w, 32*1024),
+		format: format,
+		opts:   opts,
+	}
+}
+
+// Count 已写入的拦截条数
+func (w *Writer) Count() int {
+	return w.count
+}
+
+// Block 写入一条拦截规则
+func (w *Writer) Block(domain string) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	var err error
+	switch w.format {
+	case FormatHosts:
+		address := w.opts.Address
+		if address == "" {
+			address = "0.0.0.0"
+		}
+		_, err = fmt.Fprintf(w.w, "%s %s\n", address, domain)
+	case FormatDnsmasq:
+		_, err = fmt.Fprintf(w.w, "address=/%s/%s\n", domain, w.opts.Address)
+	case FormatUnbound:
+		if w.opts.Address == "" {
+			_, err = fmt.Fprintf(w.w, "local-zone: \"%s.\" always_nxdomain\n", domain)
+		} else {
+			_, err = fmt.Fprintf(w.w, "local-zone: \"%s.\" redirect\nlocal-data: \"%s. %s %s\"\n",
+				domain, domain, addressRecordType(w.opts.Address), w.opts.Address)
+		}
+	case FormatRPZ:
+		_, err = fmt.Fprintf(w.w, "%s CNAME .\n*.%s CNAME .\n", domain, domain)
+	case FormatAdblock:
+		_, err = fmt.Fprintf(w.w, "||%s^\n", domain)
+	default:
+		_, err = fmt.Fprintf(w.w, "%s\n", domain)
+	}
+	if err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Allow 写入一条放行规则
+// 仅对会连带拦截子域名的格式生效(dnsmasq/unbound/rpz/adblock)，hosts与纯文本按精确匹配无需放行规则
+func (w *Writer) Allow(domain string) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	var err error
+	switch w.format {
+	case FormatDnsmasq:
+		_, err = fmt.Fprintf(w.w, "server=/%s/#\n", domain)
+	case FormatUnbound:
+		_, err = fmt.Fprintf(w.w, "local-zone: \"%s.\" transparent\n", domain)
+	case FormatRPZ:
+		_, err = fmt.Fprintf(w.w, "%s CNAME rpz-passthru.\n*.%s CNAME rpz-passthru.\n", domain, domain)
+	case FormatAdblock:
+		_, err = fmt.Fprintf(w.w, "@@||%s^\n", domain)
+	}
+	return err
+}
+
+// Close 写入文件尾并刷新缓冲，不会关闭底层io.Writer
+func (w *Writer) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// writeHeader 写入文件头(仅一次)
+func (w *Writer) writeHeader() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	title := w.opts.Title
+	if title == "" {
+		title = "blocklist"
+	}
+	generatedAt := w.opts.GeneratedAt.UTC().Format(time.RFC3339)
+
+	var err error
+	switch w.format {
+	case FormatHosts, FormatDnsmasq:
+		_, err = fmt.Fprintf(w.w, "# Title: %s\n# Version: %d\n# Generated: %s\n\n", title, w.opts.Version, generatedAt)
+	case FormatUnbound:
+		_, err = fmt.Fprintf(w.w, "# Title: %s\n# Version: %d\n# Generated: %s\n\nserver:\n", title, w.opts.Version, generatedAt)
+	case FormatRPZ:
+		serial := uint32(w.opts.GeneratedAt.Unix())
+		_, err = fmt.Fprintf(w.w, "; Title: %s\n; Version: %d\n; Generated: %s\n"+
+			"$TTL 300\n@ SOA localhost. root.localhost. %d 3600 600 86400 300\n  NS localhost.\n\n",
+			title, w.opts.Version, generatedAt, serial)
+	case FormatAdblock:
+		_, err = fmt.Fprintf(w.w, "! Title: %s\n! Version: %d\n! Last modified: %s\n! Expires: 1 day\n\n",
+			title, w.opts.Version, generatedAt)
+	}
+	return err
+}
+
+// addressRecordType 根据地址判断记录类型
+func addressRecordType(address string) string {
+	if strings.Contains(address, ":") {
+		return "AAAA"
+	}
+	return "A"
+}
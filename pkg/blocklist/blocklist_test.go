@@ -0,0 +1,72 @@
+package blocklist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	generatedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		format  Format
+		address string
+		want    []string
+	}{
+		{FormatHosts, "", []string{"0.0.0.0 evil.com\n"}},
+		{FormatHosts, "10.0.0.1", []string{"10.0.0.1 evil.com\n"}},
+		{FormatDnsmasq, "", []string{"address=/evil.com/\n", "server=/ok.evil.com/#\n"}},
+		{FormatUnbound, "", []string{"server:\n", "local-zone: \"evil.com.\" always_nxdomain\n", "local-zone: \"ok.evil.com.\" transparent\n"}},
+		{FormatUnbound, "::1", []string{"local-zone: \"evil.com.\" redirect\nlocal-data: \"evil.com. AAAA ::1\"\n"}},
+		{FormatRPZ, "", []string{"$TTL 300\n", "evil.com CNAME .\n*.evil.com CNAME .\n", "ok.evil.com CNAME rpz-passthru.\n"}},
+		{FormatAdblock, "", []string{"! Title: test\n", "||evil.com^\n", "@@||ok.evil.com^\n"}},
+		{FormatPlain, "", []string{"evil.com\n"}},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, tt.format, Options{Title: "test", Address: tt.address, Version: 3, GeneratedAt: generatedAt})
+		if err := w.Block("evil.com"); err != nil {
+			t.Fatalf("%s: Block() error = %v", tt.format, err)
+		}
+		if err := w.Allow("ok.evil.com"); err != nil {
+			t.Fatalf("%s: Allow() error = %v", tt.format, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: Close() error = %v", tt.format, err)
+		}
+		if w.Count() != 1 {
+			t.Errorf("%s: Count() = %d, want 1", tt.format, w.Count())
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s: output missing %q, got:\n%s", tt.format, want, buf.String())
+			}
+		}
+	}
+}
+
+func TestWriterAllowIgnoredForExactFormats(t *testing.T) {
+	for _, format := range []Format{FormatHosts, FormatPlain} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, format, Options{})
+		_ = w.Allow("ok.evil.com")
+		_ = w.Close()
+		if strings.Contains(buf.String(), "ok.evil.com") {
+			t.Errorf("%s: allow rule should not be written, got:\n%s", format, buf.String())
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(""); err != nil || f != FormatPlain {
+		t.Errorf("ParseFormat(\"\") = %v, %v", f, err)
+	}
+	if f, err := ParseFormat("RPZ"); err != nil || f != FormatRPZ {
+		t.Errorf("ParseFormat(\"RPZ\") = %v, %v", f, err)
+	}
+	if _, err := ParseFormat("bogus"); err == nil {
+		t.Error("ParseFormat(\"bogus\") expected error")
+	}
+}
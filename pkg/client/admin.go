@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+	"godex/pkg/api"
+	"net/http"
+)
+
+// 管理接口路径
+const (
+	pathAdminTasks    = "/admin/tasks"
+	pathAdminTaskRuns = "/admin/tasks/runs"
+)
+
+// Tasks 获取定时任务状态(最近一次及下次执行)
+func (c *Client) Tasks(ctx context.Context, req api.TasksReq) (api.TasksRsp, error) {
+	var rsp api.TasksRsp
+	err := c.call(ctx, http.MethodGet, pathAdminTasks, nil, nil, &rsp)
+	return rsp, err
+}
+
+// TaskRuns 获取定时任务执行记录
+func (c *Client) TaskRuns(ctx context.Context, req api.TaskRunsReq) (api.TaskRunsRsp, error) {
+	var rsp api.TaskRunsRsp
+	err := c.call(ctx, http.MethodGet, pathAdminTaskRuns, queryOf("task", req.Task, "limit", req.Limit), nil, &rsp)
+	return rsp, err
+}
@@ -0,0 +1,347 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"godex/pkg/auth"
+	"godex/pkg/errs"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 默认配置
+const (
+	DefaultTimeout    = 5 * time.Second
+	DefaultRetries    = 2
+	DefaultRetryWait  = 200 * time.Millisecond
+	maxRetryWait      = 5 * time.Second
+	maxErrorBodyBytes = 4096
+)
+
+// HeaderRequestID 请求ID，服务端日志及响应中的trace-id与之一致
+const HeaderRequestID = "X-Request-Id"
+
+// requestIDKey context中保存请求ID的键
+type requestIDKey struct{}
+
+// WithRequestID 在ctx中设置请求ID，同一ctx发起的请求(含重试)均携带该ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 获取ctx中的请求ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Option 客户端配置选项函数类型
+type Option func(*Client)
+
+// WithTimeout 设置非流式接口单次请求的超时，流式接口的时长由ctx控制
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries 设置失败重试次数及首次重试间隔(之后每次翻倍)，网络错误、5xx及429时重试
+// 有副作用的POST请求(如CheckSites会产生命中上报及事件)默认仅在429时重试，见WithRetryNonIdempotent
+func WithRetries(retries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.retryWait = wait
+	}
+}
+
+// WithRetryNonIdempotent 有副作用的POST请求同样在网络错误及5xx时重试，服务端可能重复处理同一请求
+func WithRetryNonIdempotent() Option {
+	return func(c *Client) {
+		c.retryAll = true
+	}
+}
+
+// WithHTTPClient 使用自定义的http.Client，如需配置代理或TLS
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader 为所有请求添加请求头
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithAPIKey 使用静态API Key认证，见auth.HeaderAPIKey
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.header.Set(auth.HeaderAPIKey, key)
+	}
+}
+
+// WithHMAC 使用HMAC签名认证，每次请求(含重试)重新签名；NDJSON流式请求的请求体不参与签名
+func WithHMAC(clientID, secret string) Option {
+	return func(c *Client) {
+		c.clientID, c.secret = clientID, secret
+	}
+}
+
+// Client godex接口客户端，线程安全
+// 接口返回的错误码解码为*errs.Error，其中Desc为服务端返回的trace-id；
+// 客户端自身的错误(超时、网络错误、解码失败等)为框架错误，错误码见errs.RetClient*
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	timeout    time.Duration
+	retries    int
+	retryWait  time.Duration
+	header     http.Header
+	clientID   string
+	secret     string
+	retryAll   bool
+}
+
+// New 创建客户端，baseURL如http://127.0.0.1:8000
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{},
+		timeout:    DefaultTimeout,
+		retries:    DefaultRetries,
+		retryWait:  DefaultRetryWait,
+		header:     http.Header{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// envelope 统一响应格式，与api.APIResponse一致
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"msg"`
+	Data    json.RawMessage `json:"data"`
+	TraceId string          `json:"trace-id"`
+}
+
+// call 调用JSON接口，GET请求的参数编码为query，其余请求编码为JSON请求体，out为响应数据
+// 非幂等请求仅在429(服务端未处理)时重试，除非设置了WithRetryNonIdempotent
+func (c *Client) call(ctx context.Context, method, path string, query url.Values, in any, out any) error {
+	idempotent := c.retryAll || method == http.MethodGet || readOnlyPaths[path]
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return clientError(errs.RetClientEncodeFail, "encode request failed: %v", err)
+		}
+	}
+
+	ctx = ensureRequestID(ctx)
+	return c.retry(ctx, func() (bool, error) {
+		reqCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		resp, err := c.send(reqCtx, method, path, query, reader, "application/json")
+		if err != nil {
+			return idempotent, err
+		}
+		defer resp.Body.Close()
+
+		if err = decodeEnvelope(resp, out); err != nil {
+			if !idempotent {
+				return resp.StatusCode == http.StatusTooManyRequests, err
+			}
+			return retryableStatus(resp.StatusCode), err
+		}
+		return false, nil
+	})
+}
+
+// stream 调用流式接口，成功时返回响应由调用方读取并关闭
+// body不为nil时请求体不可重放，不重试
+func (c *Client) stream(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	ctx = ensureRequestID(ctx)
+
+	var resp *http.Response
+	attempt := func() (bool, error) {
+		var err error
+		if resp, err = c.send(ctx, method, path, query, body, contentType); err != nil {
+			return body == nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return false, nil
+		}
+		failed := resp
+		resp = nil
+		defer failed.Body.Close()
+		return body == nil && retryableStatus(failed.StatusCode), decodeEnvelope(failed, nil)
+	}
+	if err := c.retry(ctx, attempt); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// send 发送一次请求，传输层错误转换为客户端错误
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, clientError(errs.RetClientEncodeFail, "build request failed: %v", err)
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set(HeaderRequestID, RequestID(ctx))
+	if c.secret != "" {
+		if err = auth.SignRequest(req, c.clientID, c.secret, uuid.NewString()); err != nil {
+			return nil, clientError(errs.RetClientEncodeFail, "sign request failed: %v", err)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, transportError(err)
+	}
+	return resp, nil
+}
+
+// retry 按退避间隔重试，fn返回是否可重试
+func (c *Client) retry(ctx context.Context, fn func() (bool, error)) error {
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		retryable, err := fn()
+		if err == nil || !retryable || attempt >= c.retries || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return transportError(ctx.Err())
+		case <-timer.C:
+		}
+		wait = min(wait*2, maxRetryWait)
+	}
+}
+
+// decodeEnvelope 解析统一响应格式，code不为0时返回*errs.Error
+func decodeEnvelope(resp *http.Response, out any) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return transportError(err)
+	}
+
+	var env envelope
+	if err = json.Unmarshal(data, &env); err != nil || (env.Code == 0 && resp.StatusCode != http.StatusOK) {
+		// 非统一响应格式，如网关返回的错误页
+		if resp.StatusCode != http.StatusOK {
+			return clientError(errs.RetClientNetErr, "HTTP error: status %d, body: %s", resp.StatusCode, truncate(data))
+		}
+		return clientError(errs.RetClientDecodeFail, "decode response failed: %v", err)
+	}
+	if env.Code != 0 {
+		return calleeError(env)
+	}
+	if out != nil && len(env.Data) > 0 {
+		if err = json.Unmarshal(env.Data, out); err != nil {
+			return clientError(errs.RetClientDecodeFail, "decode response data failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// calleeError 将服务端错误码转换为*errs.Error，小于1000的为服务端框架错误码
+func calleeError(env envelope) error {
+	errorType := errs.ErrorTypeBusiness
+	if env.Code < 1000 {
+		errorType = errs.ErrorTypeCalleeFramework
+	}
+	return &errs.Error{Type: errorType, Code: int32(env.Code), Msg: env.Message, Desc: env.TraceId}
+}
+
+// clientError 客户端错误
+func clientError(code int, format string, params ...interface{}) error {
+	return &errs.Error{Type: errs.ErrorTypeFramework, Code: int32(code), Msg: fmt.Sprintf(format, params...), Desc: "client"}
+}
+
+// transportError 将传输层错误转换为客户端错误
+func transportError(err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return clientError(errs.RetClientCanceled, "request canceled: %v", err)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return clientError(errs.RetClientTimeout, "request timeout: %v", err)
+	default:
+		return clientError(errs.RetClientNetErr, "request failed: %v", err)
+	}
+}
+
+// retryableStatus 5xx及429可重试
+func retryableStatus(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+// ensureRequestID ctx中没有请求ID时生成一个
+func ensureRequestID(ctx context.Context) context.Context {
+	if RequestID(ctx) != "" {
+		return ctx
+	}
+	return WithRequestID(ctx, uuid.NewString())
+}
+
+// truncate 截断错误响应体
+func truncate(data []byte) string {
+	if len(data) > maxErrorBodyBytes {
+		data = data[:maxErrorBodyBytes]
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// queryOf 将键值对编码为query，零值省略
+func queryOf(pairs ...any) url.Values {
+	query := url.Values{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key := pairs[i].(string)
+		switch value := pairs[i+1].(type) {
+		case string:
+			if value != "" {
+				query.Set(key, value)
+			}
+		case int:
+			if value != 0 {
+				query.Set(key, strconv.Itoa(value))
+			}
+		case uint64:
+			if value != 0 {
+				query.Set(key, strconv.FormatUint(value, 10))
+			}
+		}
+	}
+	return query
+}
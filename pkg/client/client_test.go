@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"godex/pkg/api"
+	"godex/pkg/errs"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetryAndRequestID(t *testing.T) {
+	var calls atomic.Int32
+	requestIDs := make(chan string, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIDs <- r.Header.Get(HeaderRequestID)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"msg":"success","data":[{"query":"Evil.com","domain":"evil.com","source":"s1"}],"trace-id":"t1"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetries(1, time.Millisecond), WithRetryNonIdempotent())
+	rsp, err := c.CheckSites(WithRequestID(context.Background(), "req-1"), api.CheckSitesReq{"Evil.com"})
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(rsp) != 1 || rsp[0].Domain != "evil.com" {
+		t.Fatalf("unexpected response %+v", rsp)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("calls = %d, want 2", calls.Load())
+	}
+	for i := 0; i < 2; i++ {
+		if id := <-requestIDs; id != "req-1" {
+			t.Fatalf("request id = %q, want req-1", id)
+		}
+	}
+}
+
+func TestClientNonIdempotentRetry(t *testing.T) {
+	var calls, status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	// 检查接口有副作用，5xx时不重试
+	c := New(server.URL, WithRetries(2, time.Millisecond))
+	if _, err := c.CheckSites(context.Background(), api.CheckSitesReq{"evil.com"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1", calls.Load())
+	}
+
+	// 无副作用的POST接口及429照常重试
+	calls.Store(0)
+	if _, err := c.SyncSites(context.Background(), api.SyncSitesReq{}); err == nil || calls.Load() != 3 {
+		t.Fatalf("sync calls = %d, err = %v", calls.Load(), err)
+	}
+	calls.Store(0)
+	status.Store(http.StatusTooManyRequests)
+	if _, err := c.CheckSites(context.Background(), api.CheckSitesReq{"evil.com"}); err == nil || calls.Load() != 3 {
+		t.Fatalf("check calls = %d, err = %v", calls.Load(), err)
+	}
+}
+
+func TestClientDecodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":101002005,"msg":"msg(data not ready)","data":null,"trace-id":"t2"}`))
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).Stats(context.Background(), api.PhishingSitesStatsReq{})
+	var e *errs.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("error %v is not *errs.Error", err)
+	}
+	if e.Code != 101002005 || e.Type != errs.ErrorTypeBusiness || e.Desc != "t2" {
+		t.Fatalf("unexpected error %+v", e)
+	}
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake()
+	fake.AddSite("www.Evil.com", "s1")
+
+	var c API = fake
+	rsp, err := c.CheckSites(context.Background(), api.CheckSitesReq{"evil.com", "login.evil.com", "good.com"})
+	if err != nil || len(rsp) != 1 || rsp[0].Domain != "www.evil.com" {
+		t.Fatalf("check = %+v, %v", rsp, err)
+	}
+
+	injected := errs.New(101002005, "data not ready")
+	fake.SetError(MethodStats, injected)
+	if _, err = c.Stats(context.Background(), api.PhishingSitesStatsReq{}); err != injected {
+		t.Fatalf("stats error = %v, want injected", err)
+	}
+
+	fake.AddTaskRun(api.TaskRun{Task: "load", Result: "success"})
+	fake.AddTaskRun(api.TaskRun{Task: "test", Result: "success"})
+	if runs, err := c.TaskRuns(context.Background(), api.TaskRunsReq{Task: "load"}); err != nil || len(runs) != 1 || runs[0].Task != "load" {
+		t.Fatalf("task runs = %+v, %v", runs, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan api.UpdateEvent, 2)
+	go func() {
+		_ = c.SubscribeUpdates(ctx, api.SubscribeUpdatesReq{}, func(event api.UpdateEvent) error {
+			events <- event
+			return nil
+		})
+	}()
+	if event := <-events; event.Type != api.UpdateEventHello || event.Version != 1 {
+		t.Fatalf("first event = %+v", event)
+	}
+	fake.RemoveSite("www.evil.com")
+	if event := <-events; event.Type != api.UpdateEventDelta || event.Version != 2 || len(event.Removes) != 1 {
+		t.Fatalf("second event = %+v", event)
+	}
+}
@@ -0,0 +1,424 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"godex/pkg/api"
+	"godex/pkg/blocklist"
+	"godex/pkg/errs"
+	"godex/pkg/hashprefix"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FakeEpoch Fake返回的快照历史标识
+const FakeEpoch = "fake"
+
+// defaultTaskRunsLimit TaskRuns未指定limit时的默认条数，与服务端一致
+const defaultTaskRunsLimit = 50
+
+// 可通过Fake.SetError注入错误的方法名
+const (
+	MethodWorking          = "Working"
+	MethodCheckSites       = "CheckSites"
+	MethodCheckStream      = "CheckStream"
+	MethodStats            = "Stats"
+	MethodExportSites      = "ExportSites"
+	MethodHashFind         = "HashFind"
+	MethodHashPrefixes     = "HashPrefixes"
+	MethodSyncSites        = "SyncSites"
+	MethodSubscribeUpdates = "SubscribeUpdates"
+	MethodTasks            = "Tasks"
+	MethodTaskRuns         = "TaskRuns"
+)
+
+// 编译时检查接口实现
+var _ API = (*Fake)(nil)
+
+// Fake 进程内的API实现，供调用方单元测试使用，无需启动服务
+// 匹配规则与服务端一致：忽略大小写及首尾空格，同时匹配加/去www.前缀的值；不支持放行名单
+// 每次AddSite/RemoveSite产生一个新版本并推送给订阅者，SyncSites总是全量重置
+type Fake struct {
+	mu          sync.Mutex
+	sites       map[string]string // 域名 -> 数据来源
+	version     uint64
+	errors      map[string]error
+	subscribers map[chan api.UpdateEvent]struct{}
+	tasks       api.TasksRsp
+	taskRuns    api.TaskRunsRsp // 按开始时间从晚到早
+}
+
+// NewFake 创建Fake
+func NewFake() *Fake {
+	return &Fake{
+		sites:       map[string]string{},
+		errors:      map[string]error{},
+		subscribers: map[chan api.UpdateEvent]struct{}{},
+	}
+}
+
+// AddSite 添加钓鱼网站
+func (f *Fake) AddSite(domain, source string) {
+	domain = normalizeDomain(domain)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sites[domain] = source
+	f.publish(api.UpdateEvent{Adds: []string{domain}})
+}
+
+// RemoveSite 删除钓鱼网站
+func (f *Fake) RemoveSite(domain string) {
+	domain = normalizeDomain(domain)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.sites[domain]; !exists {
+		return
+	}
+	delete(f.sites, domain)
+	f.publish(api.UpdateEvent{Removes: []string{domain}})
+}
+
+// SetTasks 设置Tasks返回的任务状态
+func (f *Fake) SetTasks(tasks api.TasksRsp) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tasks = append(api.TasksRsp(nil), tasks...)
+}
+
+// AddTaskRun 添加一条任务执行记录，TaskRuns按添加顺序从晚到早返回
+func (f *Fake) AddTaskRun(run api.TaskRun) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.taskRuns = append(api.TaskRunsRsp{run}, f.taskRuns...)
+}
+
+// SetError 设置方法调用时返回的错误，err为nil时清除，method见Method*常量
+// 可使用errs.New构造与服务端一致的*errs.Error
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errors, method)
+		return
+	}
+	f.errors[method] = err
+}
+
+// Working 检查服务状态
+func (f *Fake) Working(ctx context.Context, req api.WorkingReq) (api.WorkingRsp, error) {
+	if err := f.injected(ctx, MethodWorking); err != nil {
+		return nil, err
+	}
+	return "working", nil
+}
+
+// CheckSites 批量检查域名是否命中
+func (f *Fake) CheckSites(ctx context.Context, req api.CheckSitesReq) (api.CheckSitesRsp, error) {
+	if err := f.injected(ctx, MethodCheckSites); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rsp := api.CheckSitesRsp{}
+	for _, site := range req {
+		if domain, source, ok := f.lookup(site); ok {
+			rsp = append(rsp, struct {
+				Query  string `json:"query"`
+				Domain string `json:"domain"`
+				Source string `json:"source"`
+			}{Query: site, Domain: domain, Source: source})
+		}
+	}
+	return rsp, nil
+}
+
+// fakeStreamResult 流式检查的输出行，与服务端一致
+type fakeStreamResult struct {
+	Line   int             `json:"line"`
+	ID     json.RawMessage `json:"id,omitempty"`
+	Query  string          `json:"query,omitempty"`
+	Hit    bool            `json:"hit"`
+	Domain string          `json:"domain,omitempty"`
+	Source string          `json:"source,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// CheckStream 流式批量检查
+func (f *Fake) CheckStream(ctx context.Context, req api.CheckStreamReq, r io.Reader, w io.Writer) error {
+	if err := f.injected(ctx, MethodCheckStream); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(r)
+	encoder := json.NewEncoder(w)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		result := fakeStreamResult{Line: lineNo, Query: line}
+		if strings.HasPrefix(line, "{") {
+			var query struct {
+				ID   json.RawMessage `json:"id,omitempty"`
+				Host string          `json:"host"`
+			}
+			if err := json.Unmarshal([]byte(line), &query); err != nil {
+				result = fakeStreamResult{Line: lineNo, Error: "invalid json: " + err.Error()}
+			} else if strings.TrimSpace(query.Host) == "" {
+				result = fakeStreamResult{Line: lineNo, ID: query.ID, Error: "host is required"}
+			} else {
+				result.ID, result.Query = query.ID, query.Host
+			}
+		}
+		if result.Error == "" {
+			f.mu.Lock()
+			result.Domain, result.Source, result.Hit = f.lookup(result.Query)
+			f.mu.Unlock()
+		}
+		if err := encoder.Encode(result); err != nil {
+			return transportError(err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return transportError(err)
+	}
+	return nil
+}
+
+// Stats 获取缓存统计，仅填充条数、来源及版本号
+func (f *Fake) Stats(ctx context.Context, req api.PhishingSitesStatsReq) (api.PhishingSitesStatsRsp, error) {
+	if err := f.injected(ctx, MethodStats); err != nil {
+		return api.PhishingSitesStatsRsp{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sources := map[string]int{}
+	for _, source := range f.sites {
+		sources[source]++
+	}
+	return api.PhishingSitesStatsRsp{Total: len(f.sites), Sources: sources, Version: f.version}, nil
+}
+
+// ExportSites 按指定格式导出列表
+func (f *Fake) ExportSites(ctx context.Context, req api.ExportSitesReq, w io.Writer) error {
+	if err := f.injected(ctx, MethodExportSites); err != nil {
+		return err
+	}
+	format, err := blocklist.ParseFormat(req.Format)
+	if err != nil {
+		return clientError(errs.RetClientValidateFail, "%v", err)
+	}
+	f.mu.Lock()
+	domains, version := f.domains(), f.version
+	f.mu.Unlock()
+
+	writer := blocklist.NewWriter(w, format, blocklist.Options{Title: "godex phishing sites", Address: req.Address, Version: version})
+	for _, domain := range domains {
+		if err = writer.Block(domain); err != nil {
+			return transportError(err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		return transportError(err)
+	}
+	return nil
+}
+
+// HashFind 按哈希前缀查找完整哈希
+func (f *Fake) HashFind(ctx context.Context, req api.HashFindReq) (api.HashFindRsp, error) {
+	if err := f.injected(ctx, MethodHashFind); err != nil {
+		return api.HashFindRsp{}, err
+	}
+	index := f.hashIndex()
+	rsp := api.HashFindRsp{Version: index.Version(), Matches: make([]api.HashMatch, 0, len(req.Prefixes))}
+	for _, prefix := range req.Prefixes {
+		raw, err := hex.DecodeString(prefix)
+		if err != nil {
+			return api.HashFindRsp{}, clientError(errs.RetClientValidateFail, "invalid prefix %q: %v", prefix, err)
+		}
+		entries, err := index.Find(raw)
+		if err != nil {
+			return api.HashFindRsp{}, clientError(errs.RetClientValidateFail, "%v", err)
+		}
+		match := api.HashMatch{Prefix: prefix, FullHashes: make([]api.FullHash, 0, len(entries))}
+		for _, entry := range entries {
+			match.FullHashes = append(match.FullHashes, api.FullHash{Hash: hex.EncodeToString(entry.Hash[:]), Source: entry.Source})
+		}
+		rsp.Matches = append(rsp.Matches, match)
+	}
+	return rsp, nil
+}
+
+// HashPrefixes 下载哈希前缀集合
+func (f *Fake) HashPrefixes(ctx context.Context, req api.HashPrefixesReq) (api.HashPrefixesRsp, error) {
+	if err := f.injected(ctx, MethodHashPrefixes); err != nil {
+		return api.HashPrefixesRsp{}, err
+	}
+	prefixLen := req.PrefixLen
+	if prefixLen == 0 {
+		prefixLen = hashprefix.DefaultPrefixLen
+	}
+	index := f.hashIndex()
+	prefixes, count, err := index.Prefixes(prefixLen)
+	if err != nil {
+		return api.HashPrefixesRsp{}, clientError(errs.RetClientValidateFail, "%v", err)
+	}
+	return api.HashPrefixesRsp{
+		Version:   index.Version(),
+		PrefixLen: prefixLen,
+		Count:     count,
+		Prefixes:  base64.StdEncoding.EncodeToString(prefixes),
+	}, nil
+}
+
+// SyncSites 同步列表，总是全量重置，仅支持encoding=1
+func (f *Fake) SyncSites(ctx context.Context, req api.SyncSitesReq) (api.SyncSitesRsp, error) {
+	if err := f.injected(ctx, MethodSyncSites); err != nil {
+		return api.SyncSitesRsp{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return api.SyncSitesRsp{Epoch: FakeEpoch, Version: f.version, Reset: true, Encoding: 1, Adds: f.domains()}, nil
+}
+
+// SubscribeUpdates 订阅列表更新，先发送hello，之后每次AddSite/RemoveSite推送一个delta，阻塞直到ctx结束或handler返回错误
+func (f *Fake) SubscribeUpdates(ctx context.Context, req api.SubscribeUpdatesReq, handler func(event api.UpdateEvent) error) error {
+	if err := f.injected(ctx, MethodSubscribeUpdates); err != nil {
+		return err
+	}
+	ch := make(chan api.UpdateEvent, 64)
+	f.mu.Lock()
+	f.subscribers[ch] = struct{}{}
+	hello := api.UpdateEvent{Type: api.UpdateEventHello, Epoch: FakeEpoch, Version: f.version}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		delete(f.subscribers, ch)
+		f.mu.Unlock()
+	}()
+
+	if err := handler(hello); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return transportError(ctx.Err())
+		case event := <-ch:
+			if err := handler(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Tasks 获取定时任务状态，返回SetTasks设置的值
+func (f *Fake) Tasks(ctx context.Context, req api.TasksReq) (api.TasksRsp, error) {
+	if err := f.injected(ctx, MethodTasks); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append(api.TasksRsp{}, f.tasks...), nil
+}
+
+// TaskRuns 获取任务执行记录，与服务端一致按任务名称过滤，limit默认50
+func (f *Fake) TaskRuns(ctx context.Context, req api.TaskRunsReq) (api.TaskRunsRsp, error) {
+	if err := f.injected(ctx, MethodTaskRuns); err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultTaskRunsLimit
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rsp := api.TaskRunsRsp{}
+	for _, run := range f.taskRuns {
+		if len(rsp) >= limit {
+			break
+		}
+		if req.Task == "" || run.Task == req.Task {
+			rsp = append(rsp, run)
+		}
+	}
+	return rsp, nil
+}
+
+// injected 返回注入的错误，ctx已结束时返回对应的客户端错误
+func (f *Fake) injected(ctx context.Context, method string) error {
+	if err := ctx.Err(); err != nil {
+		return transportError(err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.errors[method]
+}
+
+// publish 产生新版本并推送给订阅者，订阅者缓冲已满时丢弃，调用方需持有锁
+func (f *Fake) publish(event api.UpdateEvent) {
+	f.version++
+	event.Type, event.Epoch, event.Version = api.UpdateEventDelta, FakeEpoch, f.version
+	for ch := range f.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// lookup 查找域名，同时匹配加/去www.前缀的值，调用方需持有锁
+func (f *Fake) lookup(site string) (string, string, bool) {
+	site = normalizeDomain(site)
+	if site == "" {
+		return "", "", false
+	}
+	candidates := []string{site}
+	if strings.HasPrefix(site, "www.") {
+		candidates = append(candidates, strings.TrimPrefix(site, "www."))
+	} else {
+		candidates = append(candidates, "www."+site)
+	}
+	for _, candidate := range candidates {
+		if source, exists := f.sites[candidate]; exists {
+			return candidate, source, true
+		}
+	}
+	return "", "", false
+}
+
+// domains 排序后的域名列表，调用方需持有锁
+func (f *Fake) domains() []string {
+	domains := make([]string, 0, len(f.sites))
+	for domain := range f.sites {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// hashIndex 构建哈希前缀索引
+func (f *Fake) hashIndex() *hashprefix.Index {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := make([]hashprefix.Entry, 0, len(f.sites))
+	for domain, source := range f.sites {
+		entries = append(entries, hashprefix.Entry{Hash: hashprefix.Sum(domain), Source: source})
+		// 与服务端一致，www.前缀的条目同时匹配去掉前缀的域名
+		if nonWwwSite := strings.TrimPrefix(domain, "www."); nonWwwSite != domain {
+			entries = append(entries, hashprefix.Entry{Hash: hashprefix.Sum(nonWwwSite), Source: source})
+		}
+	}
+	return hashprefix.NewIndex(f.version, entries)
+}
+
+// normalizeDomain 转小写并去除首尾空格
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSpace(domain))
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"godex/pkg/api"
+	"godex/pkg/errs"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// 接口路径
+const (
+	pathWorking       = "/working"
+	pathCheck         = "/browserext/phishing_sites/check"
+	pathCheckStream   = "/browserext/phishing_sites/check/stream"
+	pathStats         = "/browserext/phishing_sites/stats"
+	pathExport        = "/browserext/phishing_sites/export"
+	pathHashFind      = "/browserext/phishing_sites/hash/find"
+	pathHashPrefixes  = "/browserext/phishing_sites/hash/prefixes"
+	pathSync          = "/browserext/phishing_sites/sync"
+	pathUpdatesSSE    = "/browserext/phishing_sites/updates/sse"
+	maxSSELineBytes   = 16 * 1024 * 1024
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// readOnlyPaths 无副作用的POST接口，与GET请求一样可安全重试；检查接口会产生命中上报及事件，不在其中
+var readOnlyPaths = map[string]bool{
+	pathHashFind: true,
+	pathSync:     true,
+}
+
+// API godex接口，Client与Fake均实现，调用方依赖该接口以便在测试中替换为Fake
+type API interface {
+	// Working 检查服务状态
+	Working(ctx context.Context, req api.WorkingReq) (api.WorkingRsp, error)
+
+	// CheckSites 批量检查域名是否命中
+	CheckSites(ctx context.Context, req api.CheckSitesReq) (api.CheckSitesRsp, error)
+
+	// CheckStream 流式批量检查，r为NDJSON请求体，结果逐行写入w
+	CheckStream(ctx context.Context, req api.CheckStreamReq, r io.Reader, w io.Writer) error
+
+	// Stats 获取缓存统计及数据新鲜度
+	Stats(ctx context.Context, req api.PhishingSitesStatsReq) (api.PhishingSitesStatsRsp, error)
+
+	// ExportSites 按指定格式导出列表，写入w
+	ExportSites(ctx context.Context, req api.ExportSitesReq, w io.Writer) error
+
+	// HashFind 按哈希前缀查找完整哈希
+	HashFind(ctx context.Context, req api.HashFindReq) (api.HashFindRsp, error)
+
+	// HashPrefixes 下载哈希前缀集合
+	HashPrefixes(ctx context.Context, req api.HashPrefixesReq) (api.HashPrefixesRsp, error)
+
+	// SyncSites 增量同步列表
+	SyncSites(ctx context.Context, req api.SyncSitesReq) (api.SyncSitesRsp, error)
+
+	// SubscribeUpdates 订阅列表更新(SSE)，每收到一个事件调用一次handler，阻塞直到ctx结束、连接断开或handler返回错误
+	// 连接断开时返回错误，调用方可使用最后收到的epoch/version重新订阅
+	SubscribeUpdates(ctx context.Context, req api.SubscribeUpdatesReq, handler func(event api.UpdateEvent) error) error
+
+	// Tasks 获取定时任务状态，需使用/admin接口的凭证
+	Tasks(ctx context.Context, req api.TasksReq) (api.TasksRsp, error)
+
+	// TaskRuns 获取任务执行记录，需使用/admin接口的凭证
+	TaskRuns(ctx context.Context, req api.TaskRunsReq) (api.TaskRunsRsp, error)
+}
+
+// 编译时检查接口实现
+var _ API = (*Client)(nil)
+
+// Working 检查服务状态
+func (c *Client) Working(ctx context.Context, req api.WorkingReq) (api.WorkingRsp, error) {
+	var rsp api.WorkingRsp
+	err := c.call(ctx, http.MethodGet, pathWorking, nil, nil, &rsp)
+	return rsp, err
+}
+
+// CheckSites 批量检查域名是否命中
+func (c *Client) CheckSites(ctx context.Context, req api.CheckSitesReq) (api.CheckSitesRsp, error) {
+	var rsp api.CheckSitesRsp
+	err := c.call(ctx, http.MethodPost, pathCheck, nil, req, &rsp)
+	return rsp, err
+}
+
+// CheckStream 流式批量检查，请求体不可重放，不重试
+func (c *Client) CheckStream(ctx context.Context, req api.CheckStreamReq, r io.Reader, w io.Writer) error {
+	resp, err := c.stream(ctx, http.MethodPost, pathCheckStream, nil, r, ndjsonContentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return copyBody(w, resp.Body)
+}
+
+// Stats 获取缓存统计及数据新鲜度
+func (c *Client) Stats(ctx context.Context, req api.PhishingSitesStatsReq) (api.PhishingSitesStatsRsp, error) {
+	var rsp api.PhishingSitesStatsRsp
+	err := c.call(ctx, http.MethodGet, pathStats, nil, nil, &rsp)
+	return rsp, err
+}
+
+// ExportSites 按指定格式导出列表，开始写入w之前失败时重试
+func (c *Client) ExportSites(ctx context.Context, req api.ExportSitesReq, w io.Writer) error {
+	resp, err := c.stream(ctx, http.MethodGet, pathExport, queryOf("format", req.Format, "address", req.Address), nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return copyBody(w, resp.Body)
+}
+
+// HashFind 按哈希前缀查找完整哈希
+func (c *Client) HashFind(ctx context.Context, req api.HashFindReq) (api.HashFindRsp, error) {
+	var rsp api.HashFindRsp
+	err := c.call(ctx, http.MethodPost, pathHashFind, nil, req, &rsp)
+	return rsp, err
+}
+
+// HashPrefixes 下载哈希前缀集合
+func (c *Client) HashPrefixes(ctx context.Context, req api.HashPrefixesReq) (api.HashPrefixesRsp, error) {
+	var rsp api.HashPrefixesRsp
+	err := c.call(ctx, http.MethodGet, pathHashPrefixes, queryOf("prefix_len", req.PrefixLen), nil, &rsp)
+	return rsp, err
+}
+
+// SyncSites 增量同步列表
+func (c *Client) SyncSites(ctx context.Context, req api.SyncSitesReq) (api.SyncSitesRsp, error) {
+	var rsp api.SyncSitesRsp
+	err := c.call(ctx, http.MethodPost, pathSync, nil, req, &rsp)
+	return rsp, err
+}
+
+// SubscribeUpdates 订阅列表更新(SSE)
+func (c *Client) SubscribeUpdates(ctx context.Context, req api.SubscribeUpdatesReq, handler func(event api.UpdateEvent) error) error {
+	query := queryOf("epoch", req.Epoch, "version", req.Version, "last_event_id", req.LastEventID)
+	resp, err := c.stream(ctx, http.MethodGet, pathUpdatesSSE, query, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 按SSE格式解析：以空行分隔事件，只关心data字段，注释行(心跳)忽略
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxSSELineBytes)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() == 0 {
+				continue
+			}
+			var event api.UpdateEvent
+			if err = json.Unmarshal([]byte(data.String()), &event); err != nil {
+				return clientError(errs.RetClientDecodeFail, "decode update event failed: %v", err)
+			}
+			data.Reset()
+			if err = handler(event); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return transportError(err)
+	}
+	return clientError(errs.RetClientNetErr, "update stream closed by server")
+}
+
+// copyBody 将响应体写入w
+func copyBody(w io.Writer, body io.Reader) error {
+	if _, err := io.Copy(w, body); err != nil {
+		return transportError(err)
+	}
+	return nil
+}
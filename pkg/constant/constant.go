@@ -9,6 +9,7 @@ const (
 	IPKey            = "ip"
 	HostNameKey      = "host_name"
 	UserIDKey        = "user_id"
+	ClientIDKey      = "client_id"
 	MethodKey        = "method"
 	PathKey          = "path"
 	QueryKey         = "query"
@@ -69,6 +69,13 @@ const (
 	Success = "Success"
 )
 
+// CodeInfo 错误码说明，用于生成接口文档
+type CodeInfo struct {
+	Code        int    `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
 // Error 错误码结构 包含 错误码类型 错误码 错误信息
 type Error struct {
 	Type int
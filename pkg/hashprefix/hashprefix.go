@@ -0,0 +1,123 @@
+package hashprefix
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// 前缀长度限制(字节)
+const (
+	MinPrefixLen     = 4
+	MaxPrefixLen     = sha256.Size
+	DefaultPrefixLen = 4
+)
+
+// maxExpressions 每个主机名最多生成的表达式个数(主机名本身+父域名)
+const maxExpressions = 5
+
+// Hash 完整哈希
+type Hash = [sha256.Size]byte
+
+// Sum 计算表达式的SHA-256
+func Sum(expression string) Hash {
+	return sha256.Sum256([]byte(expression))
+}
+
+// Expressions 生成主机名的查询表达式：主机名本身及其父域名(不含顶级域名)，最多5个
+// 例如 a.b.evil.com -> [a.b.evil.com b.evil.com evil.com]
+// IP地址只生成其本身
+func Expressions(host string) []string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" {
+		return nil
+	}
+	if net.ParseIP(host) != nil {
+		return []string{host}
+	}
+
+	labels := strings.Split(host, ".")
+	expressions := []string{host}
+	// 父域名从最短的开始取(至少两级)，与主机名本身合计不超过maxExpressions个
+	for i := len(labels) - 2; i >= 1 && len(expressions) < maxExpressions; i-- {
+		expressions = append(expressions, strings.Join(labels[i:], "."))
+	}
+	return expressions
+}
+
+// Entry 索引条目
+type Entry struct {
+	Hash   Hash
+	Source string
+}
+
+// Index 按哈希排序的只读索引，构建后可并发读取
+type Index struct {
+	version uint64
+	entries []Entry
+}
+
+// NewIndex 构建索引，相同哈希只保留第一条
+func NewIndex(version uint64, entries []Entry) *Index {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Hash[:], entries[j].Hash[:]) < 0
+	})
+	deduped := entries[:0]
+	for i, entry := range entries {
+		if i > 0 && entry.Hash == entries[i-1].Hash {
+			continue
+		}
+		deduped = append(deduped, entry)
+	}
+	return &Index{version: version, entries: deduped}
+}
+
+// Version 索引对应的快照版本号
+func (idx *Index) Version() uint64 {
+	return idx.version
+}
+
+// Len 索引条数
+func (idx *Index) Len() int {
+	return len(idx.entries)
+}
+
+// Find 查找以prefix开头的所有完整哈希
+func (idx *Index) Find(prefix []byte) ([]Entry, error) {
+	if len(prefix) < MinPrefixLen || len(prefix) > MaxPrefixLen {
+		return nil, fmt.Errorf("prefix length must be between %d and %d bytes, got %d", MinPrefixLen, MaxPrefixLen, len(prefix))
+	}
+
+	start := sort.Search(len(idx.entries), func(i int) bool {
+		return bytes.Compare(idx.entries[i].Hash[:len(prefix)], prefix) >= 0
+	})
+	var matches []Entry
+	for i := start; i < len(idx.entries) && bytes.HasPrefix(idx.entries[i].Hash[:], prefix); i++ {
+		matches = append(matches, idx.entries[i])
+	}
+	return matches, nil
+}
+
+// Prefixes 返回去重排序后拼接在一起的前缀，每个前缀prefixLen字节
+func (idx *Index) Prefixes(prefixLen int) ([]byte, int, error) {
+	if prefixLen < MinPrefixLen || prefixLen > MaxPrefixLen {
+		return nil, 0, fmt.Errorf("prefix length must be between %d and %d bytes, got %d", MinPrefixLen, MaxPrefixLen, prefixLen)
+	}
+
+	buf := make([]byte, 0, len(idx.entries)*prefixLen)
+	count := 0
+	var last []byte
+	for i := range idx.entries {
+		prefix := idx.entries[i].Hash[:prefixLen]
+		if last != nil && bytes.Equal(last, prefix) {
+			continue
+		}
+		buf = append(buf, prefix...)
+		last = prefix
+		count++
+	}
+	return buf, count, nil
+}
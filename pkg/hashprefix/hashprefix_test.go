@@ -0,0 +1,53 @@
+package hashprefix
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpressions(t *testing.T) {
+	tests := []struct {
+		host string
+		want []string
+	}{
+		{"Evil.COM.", []string{"evil.com"}},
+		{"a.b.evil.com", []string{"a.b.evil.com", "evil.com", "b.evil.com"}},
+		{"a.b.c.d.e.evil.com", []string{"a.b.c.d.e.evil.com", "evil.com", "e.evil.com", "d.e.evil.com", "c.d.e.evil.com"}},
+		{"10.0.0.1", []string{"10.0.0.1"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Expressions(tt.host); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Expressions(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	evil := Sum("evil.com")
+	index := NewIndex(7, []Entry{
+		{Hash: Sum("foo.com"), Source: "a"},
+		{Hash: evil, Source: "a"},
+		{Hash: evil, Source: "b"},
+	})
+	if index.Len() != 2 || index.Version() != 7 {
+		t.Fatalf("Len() = %d, Version() = %d", index.Len(), index.Version())
+	}
+
+	matches, err := index.Find(evil[:4])
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if len(matches) != 1 || matches[0].Hash != evil || matches[0].Source != "a" {
+		t.Errorf("Find() = %+v", matches)
+	}
+
+	if _, err := index.Find(evil[:3]); err == nil {
+		t.Error("Find() with 3 byte prefix expected error")
+	}
+
+	prefixes, count, err := index.Prefixes(4)
+	if err != nil || count != 2 || len(prefixes) != 8 {
+		t.Errorf("Prefixes(4) = %d bytes, %d, %v", len(prefixes), count, err)
+	}
+}
@@ -0,0 +1,106 @@
+package listsync
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DefaultSubscriberBuffer 每个订阅者缓冲的更新个数
+const DefaultSubscriberBuffer = 16
+
+// ErrTooManySubscribers 订阅者个数超过上限
+var ErrTooManySubscribers = fmt.Errorf("too many update subscribers")
+
+// Update 推送给订阅者的版本更新
+// Reset为true时表示差异不可用(首个版本或差异过大)，订阅者需通过增量同步接口重新同步，此时Adds与Removes为空
+type Update struct {
+	Epoch   string
+	Version uint64
+	Reset   bool
+	Adds    []string
+	Removes []string
+}
+
+// Subscription 订阅，从C中读取更新，C被关闭表示订阅已结束(Broker关闭或订阅者消费过慢被丢弃)
+type Subscription struct {
+	C <-chan Update
+
+	broker  *Broker
+	ch      chan Update
+	dropped bool
+}
+
+// Dropped 订阅是否因消费过慢被丢弃，需在C被关闭后调用
+func (s *Subscription) Dropped() bool {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.dropped
+}
+
+// Close 取消订阅，可重复调用
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+// Broker 版本更新广播，线程安全
+// 发布不阻塞：订阅者缓冲已满时直接关闭其订阅，由订阅者按已收到的版本重新同步
+type Broker struct {
+	mu     sync.Mutex
+	buffer int
+	subs   map[*Subscription]struct{}
+}
+
+// NewBroker 创建版本更新广播
+func NewBroker() *Broker {
+	return &Broker{
+		buffer: DefaultSubscriberBuffer,
+		subs:   map[*Subscription]struct{}{},
+	}
+}
+
+// Subscribe 订阅更新，maxSubscribers>0时订阅者个数超过上限返回ErrTooManySubscribers
+func (b *Broker) Subscribe(maxSubscribers int) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if maxSubscribers > 0 && len(b.subs) >= maxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+	ch := make(chan Update, b.buffer)
+	sub := &Subscription{C: ch, broker: b, ch: ch}
+	b.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish 向所有订阅者发布更新
+func (b *Broker) Publish(update Update) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		select {
+		case sub.ch <- update:
+		default:
+			sub.dropped = true
+			b.remove(sub)
+		}
+	}
+}
+
+// Len 当前订阅者个数
+func (b *Broker) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// remove 移除订阅并关闭其通道，调用方需持有锁
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
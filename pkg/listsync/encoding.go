@@ -0,0 +1,74 @@
+package listsync
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Encoding 同步数据编码版本
+type Encoding int
+
+// 支持的编码版本，新增编码时版本号递增，服务端按客户端支持的最高版本返回
+const (
+	EncodingJSON      Encoding = 1 // 域名以JSON数组返回
+	EncodingGzipLines Encoding = 2 // 域名按行拼接后gzip压缩，再base64编码
+
+	LatestEncoding = EncodingGzipLines
+)
+
+// Negotiate 协商编码版本，客户端未指定时使用EncodingJSON，超过服务端支持的最高版本时降级
+func Negotiate(requested Encoding) Encoding {
+	if requested <= 0 {
+		return EncodingJSON
+	}
+	if requested > LatestEncoding {
+		return LatestEncoding
+	}
+	return requested
+}
+
+// EncodeLines 按EncodingGzipLines编码域名列表
+func EncodeLines(domains []string) (string, error) {
+	if len(domains) == 0 {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, domain := range domains {
+		if _, err := io.WriteString(zw, domain+"\n"); err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeLines 解码EncodingGzipLines编码的域名列表
+func DecodeLines(data string) ([]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip: %v", err)
+	}
+	defer zr.Close()
+
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("read gzip failed: %v", err)
+	}
+	return strings.Fields(string(plain)), nil
+}
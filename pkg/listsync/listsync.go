@@ -0,0 +1,190 @@
+package listsync
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 默认的历史保留限制
+const (
+	DefaultMaxDiffs   = 24
+	DefaultMaxEntries = 500000
+)
+
+// Limits 历史保留限制
+type Limits struct {
+	MaxDiffs   int // 最多保留的版本差异个数
+	MaxEntries int // 所有版本差异合计最多保留的条目数
+}
+
+// Diff 相邻两个版本之间的差异，Version为变更后的版本号
+type Diff struct {
+	Version uint64
+	Adds    []string
+	Removes []string
+}
+
+// Delta 客户端版本到当前版本的同步结果
+// Reset为true时Adds为当前完整列表，客户端需丢弃本地数据
+type Delta struct {
+	Epoch   string
+	Version uint64
+	Reset   bool
+	Adds    []string
+	Removes []string
+}
+
+// History 快照历史，保存当前完整集合及最近若干个版本差异，线程安全
+// Epoch在创建时随机生成，进程重启后版本号重新计数，客户端Epoch不一致时需全量重置
+type History struct {
+	mu      sync.RWMutex
+	epoch   string
+	version uint64
+	current map[string]struct{}
+	sorted  []string
+	diffs   []Diff
+	entries int
+}
+
+// NewHistory 创建快照历史
+func NewHistory() *History {
+	return &History{
+		epoch:   newEpoch(),
+		current: map[string]struct{}{},
+	}
+}
+
+// Epoch 当前历史的标识
+func (h *History) Epoch() string {
+	return h.epoch
+}
+
+// Version 当前版本号
+func (h *History) Version() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.version
+}
+
+// Commit 提交新版本的完整集合，与上一版本比较记录差异并按限制淘汰最旧的差异，返回本次差异
+// 首个版本没有可比较的基线，返回的差异中Adds为完整集合
+func (h *History) Commit(version uint64, domains map[string]struct{}, limits Limits) Diff {
+	if limits.MaxDiffs <= 0 {
+		limits.MaxDiffs = DefaultMaxDiffs
+	}
+	if limits.MaxEntries <= 0 {
+		limits.MaxEntries = DefaultMaxEntries
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	diff := Diff{Version: version}
+	for domain := range domains {
+		if _, ok := h.current[domain]; !ok {
+			diff.Adds = append(diff.Adds, domain)
+		}
+	}
+	for domain := range h.current {
+		if _, ok := domains[domain]; !ok {
+			diff.Removes = append(diff.Removes, domain)
+		}
+	}
+	sort.Strings(diff.Adds)
+	sort.Strings(diff.Removes)
+
+	sorted := make([]string, 0, len(domains))
+	for domain := range domains {
+		sorted = append(sorted, domain)
+	}
+	sort.Strings(sorted)
+
+	// 首个版本没有可比较的基线，不记录差异
+	if h.version > 0 {
+		h.diffs = append(h.diffs, diff)
+		h.entries += len(diff.Adds) + len(diff.Removes)
+	}
+	for len(h.diffs) > 0 && (len(h.diffs) > limits.MaxDiffs || h.entries > limits.MaxEntries) {
+		h.entries -= len(h.diffs[0].Adds) + len(h.diffs[0].Removes)
+		h.diffs = h.diffs[1:]
+	}
+
+	h.version = version
+	h.current = domains
+	h.sorted = sorted
+	return diff
+}
+
+// Delta 计算客户端从(epoch, version)同步到当前版本的差异
+// epoch不一致、版本超出保留范围或差异条数超过maxEntries(>0时)时返回全量重置
+func (h *History) Delta(epoch string, version uint64, maxEntries int) Delta {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	delta := Delta{Epoch: h.epoch, Version: h.version}
+	if epoch != h.epoch || version == 0 || version > h.version || version < h.oldestVersion() {
+		return h.reset(delta)
+	}
+
+	// 依次合并客户端版本之后的所有差异
+	net := map[string]bool{}
+	for _, diff := range h.diffs {
+		if diff.Version <= version {
+			continue
+		}
+		for _, domain := range diff.Adds {
+			if added, ok := net[domain]; ok && !added {
+				delete(net, domain)
+			} else {
+				net[domain] = true
+			}
+		}
+		for _, domain := range diff.Removes {
+			if added, ok := net[domain]; ok && added {
+				delete(net, domain)
+			} else {
+				net[domain] = false
+			}
+		}
+	}
+	if maxEntries > 0 && len(net) > maxEntries {
+		return h.reset(delta)
+	}
+
+	for domain, added := range net {
+		if added {
+			delta.Adds = append(delta.Adds, domain)
+		} else {
+			delta.Removes = append(delta.Removes, domain)
+		}
+	}
+	sort.Strings(delta.Adds)
+	sort.Strings(delta.Removes)
+	return delta
+}
+
+// oldestVersion 可增量同步的最旧客户端版本，调用方需持有读锁
+func (h *History) oldestVersion() uint64 {
+	if len(h.diffs) == 0 {
+		return h.version
+	}
+	return h.diffs[0].Version - 1
+}
+
+// reset 返回全量重置，调用方需持有读锁
+func (h *History) reset(delta Delta) Delta {
+	delta.Reset = true
+	delta.Adds = h.sorted
+	return delta
+}
+
+// newEpoch 生成历史标识: 创建时间+随机数
+func newEpoch() string {
+	random := make([]byte, 4)
+	_, _ = rand.Read(random)
+	return fmt.Sprintf("%x-%s", time.Now().Unix(), hex.EncodeToString(random))
+}
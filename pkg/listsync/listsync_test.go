@@ -0,0 +1,98 @@
+package listsync
+
+import (
+	"reflect"
+	"testing"
+)
+
+func set(domains ...string) map[string]struct{} {
+	m := map[string]struct{}{}
+	for _, domain := range domains {
+		m[domain] = struct{}{}
+	}
+	return m
+}
+
+func TestHistoryDelta(t *testing.T) {
+	h := NewHistory()
+	h.Commit(1, set("a.com", "b.com"), Limits{})
+	h.Commit(2, set("a.com", "c.com"), Limits{})
+	h.Commit(3, set("b.com", "c.com", "d.com"), Limits{})
+
+	// 版本1 -> 3: b.com先删后加，抵消
+	delta := h.Delta(h.Epoch(), 1, 0)
+	if delta.Reset || delta.Version != 3 {
+		t.Fatalf("Delta(1) = %+v", delta)
+	}
+	if !reflect.DeepEqual(delta.Adds, []string{"c.com", "d.com"}) || !reflect.DeepEqual(delta.Removes, []string{"a.com"}) {
+		t.Errorf("Delta(1) adds = %v, removes = %v", delta.Adds, delta.Removes)
+	}
+
+	// 已是最新版本
+	if delta := h.Delta(h.Epoch(), 3, 0); delta.Reset || len(delta.Adds)+len(delta.Removes) != 0 {
+		t.Errorf("Delta(3) = %+v", delta)
+	}
+
+	// 超过条数限制、epoch不一致、未知版本均全量重置
+	for _, delta := range []Delta{h.Delta(h.Epoch(), 1, 2), h.Delta("other", 2, 0), h.Delta(h.Epoch(), 9, 0), h.Delta(h.Epoch(), 0, 0)} {
+		if !delta.Reset || !reflect.DeepEqual(delta.Adds, []string{"b.com", "c.com", "d.com"}) {
+			t.Errorf("expected reset, got %+v", delta)
+		}
+	}
+}
+
+func TestHistoryLimits(t *testing.T) {
+	h := NewHistory()
+	h.Commit(1, set("a.com"), Limits{MaxDiffs: 1})
+	h.Commit(2, set("b.com"), Limits{MaxDiffs: 1})
+	h.Commit(3, set("c.com"), Limits{MaxDiffs: 1})
+
+	if delta := h.Delta(h.Epoch(), 1, 0); !delta.Reset {
+		t.Errorf("Delta(1) expected reset after eviction, got %+v", delta)
+	}
+	if delta := h.Delta(h.Epoch(), 2, 0); delta.Reset {
+		t.Errorf("Delta(2) expected incremental, got %+v", delta)
+	}
+}
+
+func TestEncodeLines(t *testing.T) {
+	domains := []string{"a.com", "b.com"}
+	data, err := EncodeLines(domains)
+	if err != nil {
+		t.Fatalf("EncodeLines() error = %v", err)
+	}
+	got, err := DecodeLines(data)
+	if err != nil || !reflect.DeepEqual(got, domains) {
+		t.Errorf("DecodeLines() = %v, %v", got, err)
+	}
+	if Negotiate(0) != EncodingJSON || Negotiate(99) != LatestEncoding {
+		t.Error("Negotiate() unexpected result")
+	}
+}
+
+func TestBroker(t *testing.T) {
+	b := NewBroker()
+	sub, err := b.Subscribe(1)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if _, err := b.Subscribe(1); err != ErrTooManySubscribers {
+		t.Errorf("Subscribe() over limit error = %v", err)
+	}
+
+	b.Publish(Update{Version: 1, Adds: []string{"a.com"}})
+	if update := <-sub.C; update.Version != 1 || !reflect.DeepEqual(update.Adds, []string{"a.com"}) {
+		t.Errorf("received %+v", update)
+	}
+
+	// 缓冲已满时丢弃订阅者
+	for i := 0; i <= DefaultSubscriberBuffer; i++ {
+		b.Publish(Update{Version: uint64(i + 2)})
+	}
+	for range sub.C {
+	}
+	if !sub.Dropped() || b.Len() != 0 {
+		t.Errorf("Dropped() = %v, Len() = %d", sub.Dropped(), b.Len())
+	}
+	sub.Close()
+}
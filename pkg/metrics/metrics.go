@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+// Namespace 指标名前缀
+const Namespace = "godex"
+
+// 结果标签值
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultSkipped = "skipped" // 定时任务上次执行未完成，跳过本次
+	ResultTimeout = "timeout" // 定时任务执行超时
+)
+
+// 上报条目状态标签值
+const (
+	ReportItemSent    = "sent"
+	ReportItemSpooled = "spooled"
+	ReportItemDropped = "dropped"
+)
+
+// Registry 指标注册表，包含Go运行时及进程指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests HTTP请求数，route为注册的路由模板，未匹配路由时为unmatched
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration HTTP请求耗时，流式接口为连接时长
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// RateLimited 被限流拒绝的请求数
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "HTTP requests rejected by the rate limiter, by route.",
+	}, []string{"route"})
+
+	// CheckLookups 检查接口(HTTP及gRPC)的域名查询次数，不含DNS拦截及流式批量检查
+	CheckLookups = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "check",
+		Name:      "lookups_total",
+		Help:      "Domain lookups by the HTTP and gRPC check endpoints.",
+	})
+
+	// CheckHits 检查接口的域名命中次数，按数据来源区分
+	CheckHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "check",
+		Name:      "hits_total",
+		Help:      "Check endpoint lookups that matched the phishing sites cache, by source.",
+	}, []string{"source"})
+
+	// TaskRuns 定时任务执行次数
+	TaskRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "task",
+		Name:      "runs_total",
+		Help:      "Scheduled task runs by task and result.",
+	}, []string{"task", "result"})
+
+	// TaskDuration 定时任务执行耗时
+	TaskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "task",
+		Name:      "duration_seconds",
+		Help:      "Scheduled task run duration by task.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"task"})
+
+	// ReportSends 上报发送次数，按输出端区分
+	ReportSends = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "report",
+		Name:      "sends_total",
+		Help:      "Report sends by sink and result.",
+	}, []string{"sink", "result"})
+
+	// ReportItems 上报条目数，按输出端及状态区分：已发送、写入spool、丢弃
+	ReportItems = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "report",
+		Name:      "items_total",
+		Help:      "Report items by sink and status: sent, spooled or dropped.",
+	}, []string{"sink", "status"})
+
+	// UpstreamDuration 外部调用耗时，如OSS及第三方HTTP接口
+	UpstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "upstream",
+		Name:      "request_duration_seconds",
+		Help:      "Outbound call latency by target, operation and result.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"target", "operation", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		RateLimited,
+		CheckLookups,
+		CheckHits,
+		TaskRuns,
+		TaskDuration,
+		ReportSends,
+		ReportItems,
+		UpstreamDuration,
+	)
+}
+
+// MustRegister 注册业务自定义的指标，如基于缓存状态的GaugeFunc
+func MustRegister(cs ...prometheus.Collector) {
+	Registry.MustRegister(cs...)
+}
+
+// Handler 以Prometheus文本格式输出指标，响应压缩交由上层中间件处理
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{DisableCompression: true})
+}
+
+// Result 根据错误返回结果标签值
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
+
+// ObserveUpstream 记录一次外部调用的耗时
+func ObserveUpstream(target, operation string, start time.Time, err error) {
+	UpstreamDuration.WithLabelValues(target, operation, Result(err)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveHTTPUpstream 记录一次已收到响应的外部HTTP调用的耗时，状态码非2xx时结果为失败
+func ObserveHTTPUpstream(target, operation string, start time.Time, status int) {
+	result := ResultSuccess
+	if status/100 != 2 {
+		result = ResultFailure
+	}
+	UpstreamDuration.WithLabelValues(target, operation, result).Observe(time.Since(start).Seconds())
+}
+
+// ObserveTask 记录一次任务执行的结果与耗时
+func ObserveTask(task string, start time.Time, err error) {
+	ObserveTaskResult(task, Result(err), start)
+}
+
+// ObserveTaskResult 按结果标签记录一次任务执行的结果与耗时
+func ObserveTaskResult(task, result string, start time.Time) {
+	TaskRuns.WithLabelValues(task, result).Inc()
+	TaskDuration.WithLabelValues(task).Observe(time.Since(start).Seconds())
+}
+
+// ObserveTaskSkipped 记录一次因上次执行未完成而跳过的任务
+func ObserveTaskSkipped(task string) {
+	TaskRuns.WithLabelValues(task, ResultSkipped).Inc()
+}
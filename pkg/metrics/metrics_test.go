@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveTask(t *testing.T) {
+	start := time.Now()
+	ObserveTask("testTask", start, nil)
+	ObserveTask("testTask", start, errors.New("boom"))
+	ObserveTask("testTask", start, errors.New("boom"))
+
+	if got := testutil.ToFloat64(TaskRuns.WithLabelValues("testTask", ResultSuccess)); got != 1 {
+		t.Fatalf("success runs = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(TaskRuns.WithLabelValues("testTask", ResultFailure)); got != 2 {
+		t.Fatalf("failure runs = %v, want 2", got)
+	}
+}
+
+func TestObserveHTTPUpstream(t *testing.T) {
+	start := time.Now()
+	ObserveHTTPUpstream("test", "get", start, 204)
+	ObserveHTTPUpstream("test", "get", start, 503)
+
+	// 成功与失败各一个时间序列
+	count, err := testutil.GatherAndCount(Registry, "godex_upstream_request_duration_seconds")
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("series = %d, want 2", count)
+	}
+}
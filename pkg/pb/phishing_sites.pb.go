@@ -0,0 +1,576 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: phishing_sites.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CheckSitesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sites         []string               `protobuf:"bytes,1,rep,name=sites,proto3" json:"sites,omitempty"` // 待检查的域名
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckSitesRequest) Reset() {
+	*x = CheckSitesRequest{}
+	mi := &file_phishing_sites_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckSitesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckSitesRequest) ProtoMessage() {}
+
+func (x *CheckSitesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_phishing_sites_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckSitesRequest.ProtoReflect.Descriptor instead.
+func (*CheckSitesRequest) Descriptor() ([]byte, []int) {
+	return file_phishing_sites_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CheckSitesRequest) GetSites() []string {
+	if x != nil {
+		return x.Sites
+	}
+	return nil
+}
+
+type CheckSitesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*CheckResult         `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"` // 命中的结果
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckSitesResponse) Reset() {
+	*x = CheckSitesResponse{}
+	mi := &file_phishing_sites_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckSitesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckSitesResponse) ProtoMessage() {}
+
+func (x *CheckSitesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_phishing_sites_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckSitesResponse.ProtoReflect.Descriptor instead.
+func (*CheckSitesResponse) Descriptor() ([]byte, []int) {
+	return file_phishing_sites_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckSitesResponse) GetResults() []*CheckResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type CheckResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`   // 查询的原始域名
+	Domain        string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"` // 匹配到的
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"` // 数据来源
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckResult) Reset() {
+	*x = CheckResult{}
+	mi := &file_phishing_sites_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckResult) ProtoMessage() {}
+
+func (x *CheckResult) ProtoReflect() protoreflect.Message {
+	mi := &file_phishing_sites_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckResult.ProtoReflect.Descriptor instead.
+func (*CheckResult) Descriptor() ([]byte, []int) {
+	return file_phishing_sites_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CheckResult) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *CheckResult) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *CheckResult) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type CheckSiteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Site          string                 `protobuf:"bytes,1,opt,name=site,proto3" json:"site,omitempty"` // 待检查的域名
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckSiteRequest) Reset() {
+	*x = CheckSiteRequest{}
+	mi := &file_phishing_sites_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckSiteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckSiteRequest) ProtoMessage() {}
+
+func (x *CheckSiteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_phishing_sites_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckSiteRequest.ProtoReflect.Descriptor instead.
+func (*CheckSiteRequest) Descriptor() ([]byte, []int) {
+	return file_phishing_sites_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CheckSiteRequest) GetSite() string {
+	if x != nil {
+		return x.Site
+	}
+	return ""
+}
+
+type CheckSiteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hit           bool                   `protobuf:"varint,1,opt,name=hit,proto3" json:"hit,omitempty"`      // 是否命中
+	Result        *CheckResult           `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"` // 命中时的结果
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckSiteResponse) Reset() {
+	*x = CheckSiteResponse{}
+	mi := &file_phishing_sites_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckSiteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckSiteResponse) ProtoMessage() {}
+
+func (x *CheckSiteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_phishing_sites_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckSiteResponse.ProtoReflect.Descriptor instead.
+func (*CheckSiteResponse) Descriptor() ([]byte, []int) {
+	return file_phishing_sites_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CheckSiteResponse) GetHit() bool {
+	if x != nil {
+		return x.Hit
+	}
+	return false
+}
+
+func (x *CheckSiteResponse) GetResult() *CheckResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_phishing_sites_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_phishing_sites_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_phishing_sites_proto_rawDescGZIP(), []int{5}
+}
+
+// StatsResponse 时间为unix秒(0表示未发生)，耗时为毫秒
+type StatsResponse struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Total                 int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Sources               map[string]int64       `protobuf:"bytes,2,rep,name=sources,proto3" json:"sources,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Version               uint64                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	LoadCount             int64                  `protobuf:"varint,4,opt,name=load_count,json=loadCount,proto3" json:"load_count,omitempty"`
+	LoadFailCount         int64                  `protobuf:"varint,5,opt,name=load_fail_count,json=loadFailCount,proto3" json:"load_fail_count,omitempty"`
+	LastLoadAt            int64                  `protobuf:"varint,6,opt,name=last_load_at,json=lastLoadAt,proto3" json:"last_load_at,omitempty"`
+	LastLoadSuccessAt     int64                  `protobuf:"varint,7,opt,name=last_load_success_at,json=lastLoadSuccessAt,proto3" json:"last_load_success_at,omitempty"`
+	LastLoadDurationMs    int64                  `protobuf:"varint,8,opt,name=last_load_duration_ms,json=lastLoadDurationMs,proto3" json:"last_load_duration_ms,omitempty"`
+	RecentLoadDurationsMs []int64                `protobuf:"varint,9,rep,packed,name=recent_load_durations_ms,json=recentLoadDurationsMs,proto3" json:"recent_load_durations_ms,omitempty"`
+	LastLoadSources       map[string]int64       `protobuf:"bytes,10,rep,name=last_load_sources,json=lastLoadSources,proto3" json:"last_load_sources,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	LastImportAt          int64                  `protobuf:"varint,11,opt,name=last_import_at,json=lastImportAt,proto3" json:"last_import_at,omitempty"`
+	LastImportDurationMs  int64                  `protobuf:"varint,12,opt,name=last_import_duration_ms,json=lastImportDurationMs,proto3" json:"last_import_duration_ms,omitempty"`
+	DataUpdatedAt         int64                  `protobuf:"varint,13,opt,name=data_updated_at,json=dataUpdatedAt,proto3" json:"data_updated_at,omitempty"`
+	LastError             string                 `protobuf:"bytes,14,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	LastErrorAt           int64                  `protobuf:"varint,15,opt,name=last_error_at,json=lastErrorAt,proto3" json:"last_error_at,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_phishing_sites_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_phishing_sites_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_phishing_sites_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StatsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetSources() map[string]int64 {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLoadCount() int64 {
+	if x != nil {
+		return x.LoadCount
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLoadFailCount() int64 {
+	if x != nil {
+		return x.LoadFailCount
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLastLoadAt() int64 {
+	if x != nil {
+		return x.LastLoadAt
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLastLoadSuccessAt() int64 {
+	if x != nil {
+		return x.LastLoadSuccessAt
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLastLoadDurationMs() int64 {
+	if x != nil {
+		return x.LastLoadDurationMs
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetRecentLoadDurationsMs() []int64 {
+	if x != nil {
+		return x.RecentLoadDurationsMs
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetLastLoadSources() map[string]int64 {
+	if x != nil {
+		return x.LastLoadSources
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetLastImportAt() int64 {
+	if x != nil {
+		return x.LastImportAt
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLastImportDurationMs() int64 {
+	if x != nil {
+		return x.LastImportDurationMs
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetDataUpdatedAt() int64 {
+	if x != nil {
+		return x.DataUpdatedAt
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *StatsResponse) GetLastErrorAt() int64 {
+	if x != nil {
+		return x.LastErrorAt
+	}
+	return 0
+}
+
+var File_phishing_sites_proto protoreflect.FileDescriptor
+
+const file_phishing_sites_proto_rawDesc = "" +
+	"\n" +
+	"\x14phishing_sites.proto\x12\bgodex.v1\")\n" +
+	"\x11CheckSitesRequest\x12\x14\n" +
+	"\x05sites\x18\x01 \x03(\tR\x05sites\"E\n" +
+	"\x12CheckSitesResponse\x12/\n" +
+	"\aresults\x18\x01 \x03(\v2\x15.godex.v1.CheckResultR\aresults\"S\n" +
+	"\vCheckResult\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\"&\n" +
+	"\x10CheckSiteRequest\x12\x12\n" +
+	"\x04site\x18\x01 \x01(\tR\x04site\"T\n" +
+	"\x11CheckSiteResponse\x12\x10\n" +
+	"\x03hit\x18\x01 \x01(\bR\x03hit\x12-\n" +
+	"\x06result\x18\x02 \x01(\v2\x15.godex.v1.CheckResultR\x06result\"\x0e\n" +
+	"\fStatsRequest\"\xa7\x06\n" +
+	"\rStatsResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x03R\x05total\x12>\n" +
+	"\asources\x18\x02 \x03(\v2$.godex.v1.StatsResponse.SourcesEntryR\asources\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\x12\x1d\n" +
+	"\n" +
+	"load_count\x18\x04 \x01(\x03R\tloadCount\x12&\n" +
+	"\x0fload_fail_count\x18\x05 \x01(\x03R\rloadFailCount\x12 \n" +
+	"\flast_load_at\x18\x06 \x01(\x03R\n" +
+	"lastLoadAt\x12/\n" +
+	"\x14last_load_success_at\x18\a \x01(\x03R\x11lastLoadSuccessAt\x121\n" +
+	"\x15last_load_duration_ms\x18\b \x01(\x03R\x12lastLoadDurationMs\x127\n" +
+	"\x18recent_load_durations_ms\x18\t \x03(\x03R\x15recentLoadDurationsMs\x12X\n" +
+	"\x11last_load_sources\x18\n" +
+	" \x03(\v2,.godex.v1.StatsResponse.LastLoadSourcesEntryR\x0flastLoadSources\x12$\n" +
+	"\x0elast_import_at\x18\v \x01(\x03R\flastImportAt\x125\n" +
+	"\x17last_import_duration_ms\x18\f \x01(\x03R\x14lastImportDurationMs\x12&\n" +
+	"\x0fdata_updated_at\x18\r \x01(\x03R\rdataUpdatedAt\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x0e \x01(\tR\tlastError\x12\"\n" +
+	"\rlast_error_at\x18\x0f \x01(\x03R\vlastErrorAt\x1a:\n" +
+	"\fSourcesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\x1aB\n" +
+	"\x14LastLoadSourcesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x012\xd8\x01\n" +
+	"\rPhishingSites\x12G\n" +
+	"\n" +
+	"CheckSites\x12\x1b.godex.v1.CheckSitesRequest\x1a\x1c.godex.v1.CheckSitesResponse\x12D\n" +
+	"\tCheckSite\x12\x1a.godex.v1.CheckSiteRequest\x1a\x1b.godex.v1.CheckSiteResponse\x128\n" +
+	"\x05Stats\x12\x16.godex.v1.StatsRequest\x1a\x17.godex.v1.StatsResponseB\x11Z\x0fgodex/pkg/pb;pbb\x06proto3"
+
+var (
+	file_phishing_sites_proto_rawDescOnce sync.Once
+	file_phishing_sites_proto_rawDescData []byte
+)
+
+func file_phishing_sites_proto_rawDescGZIP() []byte {
+	file_phishing_sites_proto_rawDescOnce.Do(func() {
+		file_phishing_sites_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_phishing_sites_proto_rawDesc), len(file_phishing_sites_proto_rawDesc)))
+	})
+	return file_phishing_sites_proto_rawDescData
+}
+
+var file_phishing_sites_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_phishing_sites_proto_goTypes = []any{
+	(*CheckSitesRequest)(nil),  // 0: godex.v1.CheckSitesRequest
+	(*CheckSitesResponse)(nil), // 1: godex.v1.CheckSitesResponse
+	(*CheckResult)(nil),        // 2: godex.v1.CheckResult
+	(*CheckSiteRequest)(nil),   // 3: godex.v1.CheckSiteRequest
+	(*CheckSiteResponse)(nil),  // 4: godex.v1.CheckSiteResponse
+	(*StatsRequest)(nil),       // 5: godex.v1.StatsRequest
+	(*StatsResponse)(nil),      // 6: godex.v1.StatsResponse
+	nil,                        // 7: godex.v1.StatsResponse.SourcesEntry
+	nil,                        // 8: godex.v1.StatsResponse.LastLoadSourcesEntry
+}
+var file_phishing_sites_proto_depIdxs = []int32{
+	2, // 0: godex.v1.CheckSitesResponse.results:type_name -> godex.v1.CheckResult
+	2, // 1: godex.v1.CheckSiteResponse.result:type_name -> godex.v1.CheckResult
+	7, // 2: godex.v1.StatsResponse.sources:type_name -> godex.v1.StatsResponse.SourcesEntry
+	8, // 3: godex.v1.StatsResponse.last_load_sources:type_name -> godex.v1.StatsResponse.LastLoadSourcesEntry
+	0, // 4: godex.v1.PhishingSites.CheckSites:input_type -> godex.v1.CheckSitesRequest
+	3, // 5: godex.v1.PhishingSites.CheckSite:input_type -> godex.v1.CheckSiteRequest
+	5, // 6: godex.v1.PhishingSites.Stats:input_type -> godex.v1.StatsRequest
+	1, // 7: godex.v1.PhishingSites.CheckSites:output_type -> godex.v1.CheckSitesResponse
+	4, // 8: godex.v1.PhishingSites.CheckSite:output_type -> godex.v1.CheckSiteResponse
+	6, // 9: godex.v1.PhishingSites.Stats:output_type -> godex.v1.StatsResponse
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_phishing_sites_proto_init() }
+func file_phishing_sites_proto_init() {
+	if File_phishing_sites_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_phishing_sites_proto_rawDesc), len(file_phishing_sites_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_phishing_sites_proto_goTypes,
+		DependencyIndexes: file_phishing_sites_proto_depIdxs,
+		MessageInfos:      file_phishing_sites_proto_msgTypes,
+	}.Build()
+	File_phishing_sites_proto = out.File
+	file_phishing_sites_proto_goTypes = nil
+	file_phishing_sites_proto_depIdxs = nil
+}
@@ -0,0 +1,207 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: phishing_sites.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PhishingSites_CheckSites_FullMethodName = "/godex.v1.PhishingSites/CheckSites"
+	PhishingSites_CheckSite_FullMethodName  = "/godex.v1.PhishingSites/CheckSite"
+	PhishingSites_Stats_FullMethodName      = "/godex.v1.PhishingSites/Stats"
+)
+
+// PhishingSitesClient is the client API for PhishingSites service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PhishingSites 钓鱼网站检测服务，与HTTP接口/browserext/phishing_sites共用同一逻辑层
+type PhishingSitesClient interface {
+	// CheckSites 批量检查，仅返回命中的域名，对应POST /browserext/phishing_sites/check
+	CheckSites(ctx context.Context, in *CheckSitesRequest, opts ...grpc.CallOption) (*CheckSitesResponse, error)
+	// CheckSite 检查单个域名
+	CheckSite(ctx context.Context, in *CheckSiteRequest, opts ...grpc.CallOption) (*CheckSiteResponse, error)
+	// Stats 缓存统计及数据新鲜度，对应GET /browserext/phishing_sites/stats
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+}
+
+type phishingSitesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPhishingSitesClient(cc grpc.ClientConnInterface) PhishingSitesClient {
+	return &phishingSitesClient{cc}
+}
+
+func (c *phishingSitesClient) CheckSites(ctx context.Context, in *CheckSitesRequest, opts ...grpc.CallOption) (*CheckSitesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckSitesResponse)
+	err := c.cc.Invoke(ctx, PhishingSites_CheckSites_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *phishingSitesClient) CheckSite(ctx context.Context, in *CheckSiteRequest, opts ...grpc.CallOption) (*CheckSiteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckSiteResponse)
+	err := c.cc.Invoke(ctx, PhishingSites_CheckSite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *phishingSitesClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, PhishingSites_Stats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PhishingSitesServer is the server API for PhishingSites service.
+// All implementations must embed UnimplementedPhishingSitesServer
+// for forward compatibility.
+//
+// PhishingSites 钓鱼网站检测服务，与HTTP接口/browserext/phishing_sites共用同一逻辑层
+type PhishingSitesServer interface {
+	// CheckSites 批量检查，仅返回命中的域名，对应POST /browserext/phishing_sites/check
+	CheckSites(context.Context, *CheckSitesRequest) (*CheckSitesResponse, error)
+	// CheckSite 检查单个域名
+	CheckSite(context.Context, *CheckSiteRequest) (*CheckSiteResponse, error)
+	// Stats 缓存统计及数据新鲜度，对应GET /browserext/phishing_sites/stats
+	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
+	mustEmbedUnimplementedPhishingSitesServer()
+}
+
+// UnimplementedPhishingSitesServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPhishingSitesServer struct{}
+
+func (UnimplementedPhishingSitesServer) CheckSites(context.Context, *CheckSitesRequest) (*CheckSitesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckSites not implemented")
+}
+func (UnimplementedPhishingSitesServer) CheckSite(context.Context, *CheckSiteRequest) (*CheckSiteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckSite not implemented")
+}
+func (UnimplementedPhishingSitesServer) Stats(context.Context, *StatsRequest) (*StatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedPhishingSitesServer) mustEmbedUnimplementedPhishingSitesServer() {}
+func (UnimplementedPhishingSitesServer) testEmbeddedByValue()                       {}
+
+// UnsafePhishingSitesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PhishingSitesServer will
+// result in compilation errors.
+type UnsafePhishingSitesServer interface {
+	mustEmbedUnimplementedPhishingSitesServer()
+}
+
+func RegisterPhishingSitesServer(s grpc.ServiceRegistrar, srv PhishingSitesServer) {
+	// If the following call pancis, it indicates UnimplementedPhishingSitesServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PhishingSites_ServiceDesc, srv)
+}
+
+func _PhishingSites_CheckSites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckSitesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhishingSitesServer).CheckSites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PhishingSites_CheckSites_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhishingSitesServer).CheckSites(ctx, req.(*CheckSitesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PhishingSites_CheckSite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckSiteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhishingSitesServer).CheckSite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PhishingSites_CheckSite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhishingSitesServer).CheckSite(ctx, req.(*CheckSiteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PhishingSites_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhishingSitesServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PhishingSites_Stats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhishingSitesServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PhishingSites_ServiceDesc is the grpc.ServiceDesc for PhishingSites service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PhishingSites_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "godex.v1.PhishingSites",
+	HandlerType: (*PhishingSitesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CheckSites",
+			Handler:    _PhishingSites_CheckSites_Handler,
+		},
+		{
+			MethodName: "CheckSite",
+			Handler:    _PhishingSites_CheckSite_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _PhishingSites_Stats_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "phishing_sites.proto",
+}
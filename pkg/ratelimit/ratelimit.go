@@ -0,0 +1,233 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 限额范围
+const (
+	defaultScope = "default"  // 未单独配置限额的路由共用
+	preAuthScope = "pre-auth" // 认证前按IP计数，所有路由共用
+)
+
+// defaultMaxKeys 默认最多保留的令牌桶个数
+const defaultMaxKeys = 100000
+
+// overflowKey 令牌桶个数达到上限后新调用方共用的键
+const overflowKey = "\x00overflow"
+
+// 清理空闲令牌桶的最小间隔
+const (
+	pruneInterval     = time.Minute
+	fullPruneInterval = time.Second // 令牌桶个数达到上限时
+)
+
+// Config 限流配置，按调用方(认证后的客户端ID，未认证时为IP)分别计数
+type Config struct {
+	Enable  bool         `yaml:"enable" json:"enable"`
+	Default Limit        `yaml:"default" json:"default"` // 未单独配置的路由共用的限额，rate为0时不限流
+	Routes  []RouteLimit `yaml:"routes" json:"routes"`   // 单独配置限额的路由，各路由分别计数
+
+	// PreAuth 认证前按IP计数的限额(所有路由共用)，限制凭证猜测，rate为0时不限流
+	PreAuth Limit `yaml:"pre-auth" json:"pre-auth"`
+	// MaxKeys 最多保留的令牌桶个数，达到上限后新调用方共用一个令牌桶，默认100000
+	MaxKeys int `yaml:"max-keys" json:"max-keys"`
+}
+
+// Limit 令牌桶限额
+type Limit struct {
+	Rate  float64 `yaml:"rate" json:"rate"`   // 每秒补充的令牌数，为0时不限流
+	Burst int     `yaml:"burst" json:"burst"` // 桶容量，即允许的突发请求数，默认为rate向上取整且至少为1
+}
+
+// RouteLimit 单个路由的限额
+type RouteLimit struct {
+	Method string `yaml:"method" json:"method"` // 为空时匹配所有方法
+	Path   string `yaml:"path" json:"path"`     // 注册的路由模板，如/browserext/phishing_sites/check
+	Limit  `yaml:",inline"`
+}
+
+// Decision 一次限流判定的结果
+type Decision struct {
+	Limited    bool          // 是否配置了限额，未配置时其余字段无意义
+	Allowed    bool          // 是否放行
+	Limit      int           // 桶容量
+	Remaining  int           // 剩余令牌数
+	Reset      time.Duration // 令牌桶补满所需时间
+	RetryAfter time.Duration // 被拒绝时下一个令牌可用所需时间
+}
+
+// bucket 令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Option 限流器配置选项函数类型
+type Option func(*Limiter)
+
+// WithClock 设置时钟，用于测试
+func WithClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// Limiter 按路由及调用方计数的令牌桶限流器，线程安全
+type Limiter struct {
+	defaultLimit Limit
+	preAuthLimit Limit
+	routes       map[string]Limit // method + " " + path -> 限额，method为空时为" " + path
+	maxKeys      int
+	now          func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket // scope + "\n" + key -> 令牌桶
+	lastPrune time.Time
+}
+
+// NewLimiter 创建限流器，路由限额的rate或burst为负数时返回错误
+func NewLimiter(config Config, options ...Option) (*Limiter, error) {
+	defaultLimit, err := normalize(config.Default)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default rate limit: %v", err)
+	}
+	preAuthLimit, err := normalize(config.PreAuth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-auth rate limit: %v", err)
+	}
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = defaultMaxKeys
+	}
+	l := &Limiter{
+		defaultLimit: defaultLimit,
+		preAuthLimit: preAuthLimit,
+		routes:       map[string]Limit{},
+		maxKeys:      config.MaxKeys,
+		now:          time.Now,
+		buckets:      map[string]*bucket{},
+	}
+	for _, route := range config.Routes {
+		if route.Path == "" {
+			return nil, fmt.Errorf("rate limit route path is empty")
+		}
+		limit, err := normalize(route.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit for %s %s: %v", route.Method, route.Path, err)
+		}
+		l.routes[routeKey(route.Method, route.Path)] = limit
+	}
+	for _, option := range options {
+		option(l)
+	}
+	return l, nil
+}
+
+// normalize 校验限额并补全默认的桶容量
+func normalize(limit Limit) (Limit, error) {
+	if limit.Rate < 0 || limit.Burst < 0 {
+		return limit, fmt.Errorf("rate and burst must not be negative")
+	}
+	if limit.Rate > 0 && limit.Burst == 0 {
+		limit.Burst = max(1, int(math.Ceil(limit.Rate)))
+	}
+	return limit, nil
+}
+
+// routeKey 路由限额的键
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// Allow 消耗调用方在该路由上的一个令牌，route为注册的路由模板，key为调用方标识
+func (l *Limiter) Allow(method, route, key string) Decision {
+	scope, limit := l.match(method, route)
+	return l.take(scope, limit, key)
+}
+
+// AllowPreAuth 认证前消耗调用方(IP)的一个令牌，所有路由共用
+func (l *Limiter) AllowPreAuth(key string) Decision {
+	return l.take(preAuthScope, l.preAuthLimit, key)
+}
+
+// take 消耗调用方在限额范围内的一个令牌
+func (l *Limiter) take(scope string, limit Limit, key string) Decision {
+	if limit.Rate <= 0 {
+		return Decision{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now, pruneInterval)
+	id := scope + "\n" + key
+	b, ok := l.buckets[id]
+	if !ok && len(l.buckets) >= l.maxKeys {
+		// 令牌桶个数达到上限(如伪造大量调用方标识)时先清理，仍超过上限时新调用方共用一个令牌桶
+		l.prune(now, fullPruneInterval)
+		if len(l.buckets) >= l.maxKeys {
+			id = scope + "\n" + overflowKey
+			b, ok = l.buckets[id]
+		}
+	}
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[id] = b
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+
+	decision := Decision{Limited: true, Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	return decision
+}
+
+// match 查找路由的限额，先精确匹配方法，再匹配不限方法的配置，都没有时使用默认限额
+func (l *Limiter) match(method, route string) (string, Limit) {
+	if limit, ok := l.routes[routeKey(method, route)]; ok {
+		return routeKey(method, route), limit
+	}
+	if limit, ok := l.routes[routeKey("", route)]; ok {
+		return routeKey("", route), limit
+	}
+	return defaultScope, l.defaultLimit
+}
+
+// prune 清理已补满的令牌桶，补满的桶与新建的桶等价；距上次清理不足interval时不清理，调用方需持有锁
+func (l *Limiter) prune(now time.Time, interval time.Duration) {
+	if now.Sub(l.lastPrune) < interval {
+		return
+	}
+	l.lastPrune = now
+	for id, b := range l.buckets {
+		scope, _, _ := strings.Cut(id, "\n")
+		limit := l.routes[scope]
+		switch scope {
+		case defaultScope:
+			limit = l.defaultLimit
+		case preAuthScope:
+			limit = l.preAuthLimit
+		}
+		if b.tokens+now.Sub(b.last).Seconds()*limit.Rate >= float64(limit.Burst) {
+			delete(l.buckets, id)
+		}
+	}
+}
+
+// seconds 秒数转换为时长
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l, err := NewLimiter(Config{
+		Default: Limit{Rate: 1, Burst: 2},
+		Routes: []RouteLimit{
+			{Method: "POST", Path: "/check", Limit: Limit{Rate: 10, Burst: 1}},
+			{Path: "/export"},
+		},
+	}, WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("NewLimiter: %v", err)
+	}
+
+	// 默认限额：突发2次后拒绝，1秒后补充1个令牌
+	for i := 0; i < 2; i++ {
+		if d := l.Allow("GET", "/stats", "ip:1"); !d.Allowed || d.Remaining != 1-i {
+			t.Fatalf("request %d: %+v", i, d)
+		}
+	}
+	d := l.Allow("GET", "/stats", "ip:1")
+	if d.Allowed || d.RetryAfter != time.Second || d.Reset != 2*time.Second || d.Limit != 2 {
+		t.Fatalf("limited request: %+v", d)
+	}
+	// 默认限额在未单独配置的路由间共用，不同调用方分别计数
+	if d = l.Allow("POST", "/sync", "ip:1"); d.Allowed {
+		t.Fatalf("default scope not shared: %+v", d)
+	}
+	if d = l.Allow("GET", "/stats", "ip:2"); !d.Allowed {
+		t.Fatalf("other key limited: %+v", d)
+	}
+	now = now.Add(time.Second)
+	if d = l.Allow("GET", "/stats", "ip:1"); !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("after refill: %+v", d)
+	}
+
+	// 路由限额按方法匹配，rate为0的路由不限流
+	if d = l.Allow("POST", "/check", "ip:1"); !d.Allowed || d.Limit != 1 {
+		t.Fatalf("route limit: %+v", d)
+	}
+	if d = l.Allow("POST", "/check", "ip:1"); d.Allowed || d.RetryAfter != 100*time.Millisecond {
+		t.Fatalf("route limited: %+v", d)
+	}
+	for i := 0; i < 5; i++ {
+		if d = l.Allow("GET", "/export", "ip:1"); !d.Allowed || d.Limited {
+			t.Fatalf("unlimited route: %+v", d)
+		}
+	}
+}
+
+func TestLimiterPrune(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l, _ := NewLimiter(Config{Default: Limit{Rate: 1}}, WithClock(func() time.Time { return now }))
+	l.Allow("GET", "/stats", "ip:1")
+	now = now.Add(2 * pruneInterval)
+	l.Allow("GET", "/stats", "ip:2")
+	if _, ok := l.buckets["default\nip:1"]; ok || len(l.buckets) != 1 {
+		t.Fatalf("idle bucket not pruned: %v", l.buckets)
+	}
+}
+
+func TestLimiterMaxKeys(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l, _ := NewLimiter(Config{Default: Limit{Rate: 1, Burst: 1}, MaxKeys: 2}, WithClock(func() time.Time { return now }))
+	l.Allow("GET", "/stats", "ip:1")
+	l.Allow("GET", "/stats", "ip:2")
+
+	// 达到上限后新调用方共用一个令牌桶，令牌桶个数不再增长
+	if d := l.Allow("GET", "/stats", "ip:3"); !d.Allowed {
+		t.Fatalf("first overflow request: %+v", d)
+	}
+	if d := l.Allow("GET", "/stats", "ip:4"); d.Allowed {
+		t.Fatalf("overflow bucket not shared: %+v", d)
+	}
+	if len(l.buckets) != 3 {
+		t.Fatalf("buckets = %d, want 3", len(l.buckets))
+	}
+
+	// 清理已补满的令牌桶后新调用方重新单独计数
+	now = now.Add(2 * time.Second)
+	if d := l.Allow("GET", "/stats", "ip:5"); !d.Allowed {
+		t.Fatalf("after prune: %+v", d)
+	}
+	if _, ok := l.buckets["default\nip:5"]; !ok {
+		t.Fatalf("new key not tracked after prune: %v", l.buckets)
+	}
+}
+
+func TestLimiterPreAuth(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l, _ := NewLimiter(Config{PreAuth: Limit{Rate: 1, Burst: 1}}, WithClock(func() time.Time { return now }))
+	if d := l.AllowPreAuth("ip:1"); !d.Allowed {
+		t.Fatalf("first request: %+v", d)
+	}
+	if d := l.AllowPreAuth("ip:1"); d.Allowed || d.RetryAfter != time.Second {
+		t.Fatalf("limited request: %+v", d)
+	}
+	// 路由限额未配置时不限流，与认证前的限额互不影响
+	if d := l.Allow("GET", "/stats", "ip:1"); !d.Allowed || d.Limited {
+		t.Fatalf("route limit: %+v", d)
+	}
+}
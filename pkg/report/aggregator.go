@@ -0,0 +1,194 @@
+package report
+
+import (
+	"sync"
+	"time"
+)
+
+// 聚合默认配置
+const (
+	defaultAggregateWindow  = 60
+	defaultAggregateMaxKeys = 10000
+)
+
+// AggregateConfig 命中聚合配置，窗口内同一域名、来源、客户端及调用方的命中合并为一条上报
+type AggregateConfig struct {
+	Enable  bool `yaml:"enable" json:"enable"`
+	Window  int  `yaml:"window" json:"window"`     // 聚合窗口(秒)，默认60
+	MaxKeys int  `yaml:"max-keys" json:"max-keys"` // 窗口内最多聚合的键数，达到上限时提前发送，默认10000
+}
+
+// Hit 一次命中
+type Hit struct {
+	URL       string
+	Source    string
+	ClientID  string
+	Head      ReportHead // 调用方的上报公共头，不同公共头的命中分别聚合及上报
+	ClientIP  string
+	TraceID   string
+	Time      time.Time
+	Immediate bool // 高风险命中，不聚合立即发送
+}
+
+// HitValue 聚合后上报的op_obj_value
+type HitValue struct {
+	URL       string `json:"url"`
+	Source    string `json:"source"`
+	ClientID  string `json:"client_id,omitempty"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"` // 窗口内首次命中的请求
+	Count     int    `json:"count"`
+	FirstSeen int64  `json:"first_seen"` // 窗口内首次命中时间(Unix毫秒)
+	LastSeen  int64  `json:"last_seen"`  // 窗口内最后命中时间(Unix毫秒)
+}
+
+// hitKey 聚合键
+type hitKey struct {
+	url      string
+	source   string
+	clientID string
+	clientIP string
+	head     ReportHead
+}
+
+// Aggregator 按窗口聚合命中，窗口结束、键数达到上限或关闭时按公共头分组通过emit输出，线程安全
+type Aggregator struct {
+	config AggregateConfig
+	emit   func(ReportHead, ReportPayload)
+
+	mu      sync.Mutex
+	pending map[hitKey]*HitValue
+	stop    chan struct{}
+	done    chan struct{}
+	closed  bool
+}
+
+// NewAggregator 创建聚合器并启动按窗口输出的协程
+func NewAggregator(config AggregateConfig, emit func(ReportHead, ReportPayload)) *Aggregator {
+	if config.Window <= 0 {
+		config.Window = defaultAggregateWindow
+	}
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = defaultAggregateMaxKeys
+	}
+	a := &Aggregator{
+		config:  config,
+		emit:    emit,
+		pending: map[hitKey]*HitValue{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go a.loop()
+	return a
+}
+
+// Add 记录命中，高风险命中及关闭后的命中立即输出
+func (a *Aggregator) Add(hits ...Hit) {
+	var immediate, flushed map[ReportHead]ReportPayload
+	a.mu.Lock()
+	for _, hit := range hits {
+		millis := hit.Time.UnixMilli()
+		if hit.Immediate || a.closed {
+			value := &HitValue{URL: hit.URL, Source: hit.Source, ClientID: hit.ClientID, ClientIP: hit.ClientIP, TraceID: hit.TraceID, Count: 1, FirstSeen: millis, LastSeen: millis}
+			immediate = appendGroup(immediate, hit.Head, hitItem(value))
+			continue
+		}
+
+		key := hitKey{url: hit.URL, source: hit.Source, clientID: hit.ClientID, clientIP: hit.ClientIP, head: hit.Head}
+		value, ok := a.pending[key]
+		if !ok {
+			if len(a.pending) >= a.config.MaxKeys {
+				for head, payload := range a.drain() {
+					flushed = appendGroup(flushed, head, payload...)
+				}
+			}
+			value = &HitValue{URL: hit.URL, Source: hit.Source, ClientID: hit.ClientID, ClientIP: hit.ClientIP, TraceID: hit.TraceID, FirstSeen: millis}
+			a.pending[key] = value
+		}
+		value.Count++
+		if millis < value.FirstSeen {
+			value.FirstSeen, value.TraceID = millis, hit.TraceID
+		}
+		value.LastSeen = max(value.LastSeen, millis)
+	}
+	a.mu.Unlock()
+
+	a.emitGroups(flushed)
+	a.emitGroups(immediate)
+}
+
+// Close 停止聚合并输出窗口内剩余的命中
+func (a *Aggregator) Close() {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	a.closed = true
+	a.mu.Unlock()
+	close(a.stop)
+	<-a.done
+}
+
+// loop 每个窗口输出一次
+func (a *Aggregator) loop() {
+	defer close(a.done)
+	ticker := time.NewTicker(time.Duration(a.config.Window) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.flush()
+		case <-a.stop:
+			a.flush()
+			return
+		}
+	}
+}
+
+// flush 输出窗口内的命中
+func (a *Aggregator) flush() {
+	a.mu.Lock()
+	groups := a.drain()
+	a.mu.Unlock()
+	a.emitGroups(groups)
+}
+
+// drain 按公共头分组取出窗口内的命中并清空，调用方需持有锁
+func (a *Aggregator) drain() map[ReportHead]ReportPayload {
+	groups := map[ReportHead]ReportPayload{}
+	for key, value := range a.pending {
+		groups[key.head] = append(groups[key.head], hitItem(value))
+	}
+	a.pending = map[hitKey]*HitValue{}
+	return groups
+}
+
+// emitGroups 按公共头分别输出
+func (a *Aggregator) emitGroups(groups map[ReportHead]ReportPayload) {
+	for head, payload := range groups {
+		if len(payload) > 0 {
+			a.emit(head, payload)
+		}
+	}
+}
+
+// appendGroup 向公共头对应的分组追加条目，groups为nil时创建
+func appendGroup(groups map[ReportHead]ReportPayload, head ReportHead, items ...ReportPayloadItem) map[ReportHead]ReportPayload {
+	if groups == nil {
+		groups = map[ReportHead]ReportPayload{}
+	}
+	groups[head] = append(groups[head], items...)
+	return groups
+}
+
+// hitItem 聚合结果转换为上报条目，user_timestamp_为首次命中时间
+func hitItem(value *HitValue) ReportPayloadItem {
+	return ReportPayloadItem{
+		OpRes:         OpResOK,
+		OpObjType:     OpObjTypePhishing,
+		OpObjValue:    value,
+		UserTimestamp: value.FirstSeen,
+		Timestamp:     time.Now().UnixMilli(),
+	}
+}
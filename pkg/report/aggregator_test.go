@@ -0,0 +1,69 @@
+package report
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAggregator(t *testing.T) {
+	var mu sync.Mutex
+	var emitted []ReportPayload
+	a := NewAggregator(AggregateConfig{Window: 3600, MaxKeys: 2}, func(head ReportHead, payload ReportPayload) {
+		mu.Lock()
+		defer mu.Unlock()
+		emitted = append(emitted, payload)
+	})
+
+	t0 := time.UnixMilli(1700000000000)
+	a.Add(
+		Hit{URL: "a.com", Source: "s", ClientID: "ext", Time: t0},
+		Hit{URL: "a.com", Source: "s", ClientID: "ext", Time: t0.Add(2 * time.Second)},
+		Hit{URL: "a.com", Source: "s", ClientID: "other", Time: t0.Add(time.Second)},
+	)
+	a.Add(Hit{URL: "b.com", Source: "high", Time: t0, Immediate: true})
+	if len(emitted) != 1 || emitted[0][0].OpObjValue.(*HitValue).URL != "b.com" {
+		t.Fatalf("immediate hit not emitted: %+v", emitted)
+	}
+
+	// 键数达到上限时提前输出已聚合的命中
+	a.Add(Hit{URL: "c.com", Source: "s", Time: t0})
+	if len(emitted) != 2 || len(emitted[1]) != 2 {
+		t.Fatalf("max keys flush: %+v", emitted)
+	}
+	for _, item := range emitted[1] {
+		value := item.OpObjValue.(*HitValue)
+		if value.ClientID == "ext" && (value.Count != 2 || value.FirstSeen != t0.UnixMilli() || value.LastSeen != t0.Add(2*time.Second).UnixMilli()) {
+			t.Fatalf("unexpected aggregate: %+v", value)
+		}
+	}
+
+	a.Close()
+	if len(emitted) != 3 || emitted[2][0].OpObjValue.(*HitValue).URL != "c.com" {
+		t.Fatalf("remaining hits not flushed on close: %+v", emitted)
+	}
+}
+
+func TestAggregatorGroupsByHead(t *testing.T) {
+	emitted := map[ReportHead]ReportPayload{}
+	a := NewAggregator(AggregateConfig{Window: 3600}, func(head ReportHead, payload ReportPayload) {
+		emitted[head] = append(emitted[head], payload...)
+	})
+
+	t0 := time.UnixMilli(1700000000000)
+	alice, bob := ReportHead{UserId: 1, InstallId: "i1"}, ReportHead{UserId: 2}
+	a.Add(
+		Hit{URL: "a.com", Source: "s", Head: alice, TraceID: "t2", Time: t0.Add(time.Second)},
+		Hit{URL: "a.com", Source: "s", Head: alice, TraceID: "t1", Time: t0},
+		Hit{URL: "a.com", Source: "s", Head: bob, TraceID: "t3", Time: t0},
+	)
+	a.Close()
+
+	if len(emitted) != 2 || len(emitted[alice]) != 1 || len(emitted[bob]) != 1 {
+		t.Fatalf("unexpected groups: %+v", emitted)
+	}
+	// trace_id为窗口内首次命中的请求
+	if value := emitted[alice][0].OpObjValue.(*HitValue); value.Count != 2 || value.TraceID != "t1" {
+		t.Fatalf("unexpected aggregate: %+v", value)
+	}
+}
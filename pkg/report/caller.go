@@ -0,0 +1,108 @@
+package report
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// 调用方请求头，由浏览器扩展携带
+const (
+	HeaderUserID           = "X-Godex-User-Id"           // 用户ID(整数)
+	HeaderInstallID        = "X-Godex-Install-Id"        // 扩展安装ID
+	HeaderExtensionVersion = "X-Godex-Extension-Version" // 扩展版本
+)
+
+// 客户端IP上报方式
+const (
+	ClientIPNone  = "none"  // 不上报
+	ClientIPPlain = "plain" // 上报原始IP
+	ClientIPHash  = "hash"  // 上报hex(HMAC-SHA256(privacy.hash-key, ip))
+)
+
+// Caller 发起检查的调用方信息，由请求中间件放入ctx
+type Caller struct {
+	UserID           int
+	InstallID        string
+	ExtensionVersion string
+	IP               string
+	TraceID          string
+	OptOut           bool // 请求携带opt-out请求头，命中不上报
+}
+
+// callerKey ctx中调用方信息的键
+type callerKey struct{}
+
+// WithCaller 将调用方信息放入ctx
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFrom 获取ctx中的调用方信息，不存在时返回零值
+func CallerFrom(ctx context.Context) Caller {
+	caller, _ := ctx.Value(callerKey{}).(Caller)
+	return caller
+}
+
+// Head 上报公共头，包含用户ID、安装ID及扩展版本
+func (c Caller) Head() ReportHead {
+	return ReportHead{UserId: c.UserID, InstallId: c.InstallID, ExtensionVersion: c.ExtensionVersion}
+}
+
+// FieldsConfig 上报字段策略，决定调用方信息中哪些字段写入ReportHead及op_obj_value，默认均不上报
+type FieldsConfig struct {
+	UserID           bool   `yaml:"user-id" json:"user-id"`                     // comm.user_id
+	InstallID        bool   `yaml:"install-id" json:"install-id"`               // comm.install_id
+	ExtensionVersion bool   `yaml:"extension-version" json:"extension-version"` // comm.extension_version
+	ClientIP         string `yaml:"client-ip" json:"client-ip"`                 // op_obj_value.client_ip: none(默认)、plain或hash(密钥为privacy.hash-key)
+	TraceID          bool   `yaml:"trace-id" json:"trace-id"`                   // op_obj_value.trace_id，聚合时为窗口内首次命中的请求
+}
+
+// Validate 校验配置
+func (c FieldsConfig) Validate() error {
+	switch c.ClientIP {
+	case "", ClientIPNone, ClientIPPlain, ClientIPHash:
+		return nil
+	default:
+		return fmt.Errorf("unsupported client-ip %q, supported: %s, %s, %s", c.ClientIP, ClientIPNone, ClientIPPlain, ClientIPHash)
+	}
+}
+
+// Apply 按策略清除不上报的字段并以hashKey对IP做哈希，不支持的client-ip或hashKey为空时按none处理
+func (c FieldsConfig) Apply(caller Caller, hashKey string) Caller {
+	if !c.UserID {
+		caller.UserID = 0
+	}
+	if !c.InstallID {
+		caller.InstallID = ""
+	}
+	if !c.ExtensionVersion {
+		caller.ExtensionVersion = ""
+	}
+	if !c.TraceID {
+		caller.TraceID = ""
+	}
+	switch {
+	case caller.IP == "":
+	case c.ClientIP == ClientIPPlain:
+	case c.ClientIP == ClientIPHash && hashKey != "":
+		caller.IP = HashIP(hashKey, caller.IP)
+	default:
+		caller.IP = ""
+	}
+	return caller
+}
+
+// HashIP hex(HMAC-SHA256(key, ip))
+func HashIP(key, ip string) string {
+	return keyedHash(key, ip)
+}
+
+// keyedHash hex(HMAC-SHA256(key, value))
+func keyedHash(key, value string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,36 @@
+package report
+
+import "testing"
+
+func TestFieldsConfigApply(t *testing.T) {
+	caller := Caller{UserID: 7, InstallID: "install", ExtensionVersion: "1.2.3", IP: "203.0.113.9", TraceID: "trace"}
+
+	if got := (FieldsConfig{}).Apply(caller, "key"); got != (Caller{}) {
+		t.Fatalf("default policy should drop all fields: %+v", got)
+	}
+
+	policy := FieldsConfig{UserID: true, InstallID: true, ExtensionVersion: true, ClientIP: ClientIPHash, TraceID: true}
+	got := policy.Apply(caller, "key")
+	if got.UserID != 7 || got.InstallID != "install" || got.ExtensionVersion != "1.2.3" || got.TraceID != "trace" {
+		t.Fatalf("unexpected caller: %+v", got)
+	}
+	if got.IP == caller.IP || got.IP != HashIP("key", caller.IP) || len(got.IP) != 64 {
+		t.Fatalf("ip not hashed: %q", got.IP)
+	}
+
+	// 未配置密钥时不上报IP
+	if got = policy.Apply(caller, ""); got.IP != "" {
+		t.Fatalf("ip without key: %q", got.IP)
+	}
+
+	policy.ClientIP = ClientIPPlain
+	if got = policy.Apply(caller, ""); got.IP != caller.IP {
+		t.Fatalf("plain ip: %q", got.IP)
+	}
+	if err := (FieldsConfig{ClientIP: "raw"}).Validate(); err == nil {
+		t.Fatal("expected invalid client-ip")
+	}
+	if err := (ReportConfig{Fields: FieldsConfig{ClientIP: ClientIPHash}}).ValidatePolicy(); err == nil {
+		t.Fatal("expected missing hash-key")
+	}
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// 加密信封版本
+const (
+	ProtocolV1 = "v1" // RSA-OAEP包装16位随机串，AES-128-CBC(IV与密钥相同)，无完整性校验，仅用于兼容PHP接收端
+	ProtocolV2 = "v2" // RSA-OAEP包装32字节随机密钥，AES-256-GCM(随机nonce)
+)
+
+// 表单字段
+const (
+	FieldKey     = "_t" // base64(RSA-OAEP-SHA256(密钥))
+	FieldData    = "_a" // v1: base64(AES-CBC密文)；v2: base64(nonce || GCM密文及tag)
+	FieldVersion = "_v" // 信封版本，v2时为"2"，v1不携带该字段
+)
+
+// V2AdditionalData v2的GCM附加数据，将密文绑定到信封版本，防止被当作其他版本解密
+const V2AdditionalData = "godex-report-v2"
+
+// v2KeySize v2的AES-256密钥长度
+const v2KeySize = 32
+
+// sealV1 v1信封
+func sealV1(publicKey *rsa.PublicKey, plaintext []byte) (map[string]string, error) {
+	// 生成16位随机字符串
+	random, err := generateRandomString(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate random string: %v", err)
+	}
+
+	// AES加密数据
+	encryptedData, err := aesEncrypt(random, string(plaintext))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data with AES: %v", err)
+	}
+
+	// RSA加密随机字符串
+	encryptedRandom, err := rsaEncrypt(publicKey, random)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt random string with RSA: %v", err)
+	}
+	return map[string]string{FieldKey: encryptedRandom, FieldData: encryptedData}, nil
+}
+
+// sealV2 v2信封，每次上报使用新的随机密钥及nonce
+func sealV2(publicKey *rsa.PublicKey, plaintext []byte) (map[string]string, error) {
+	key := make([]byte, v2KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, []byte(V2AdditionalData))
+
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap key with RSA: %v", err)
+	}
+	return map[string]string{
+		FieldVersion: "2",
+		FieldKey:     base64.StdEncoding.EncodeToString(wrappedKey),
+		FieldData:    base64.StdEncoding.EncodeToString(sealed),
+	}, nil
+}
@@ -0,0 +1,103 @@
+package report
+
+import (
+	"fmt"
+	"golang.org/x/net/publicsuffix"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// HeaderOptOut 请求不参与上报的请求头，值为1或true时该请求的命中均不上报
+const HeaderOptOut = "X-Godex-Report-Opt-Out"
+
+// 上报URL的脱敏方式
+const (
+	URLFull  = "full"  // 原样上报
+	URLHost  = "host"  // 仅保留主机名，去除协议、用户信息、端口、路径及query
+	URLETLD1 = "etld1" // 仅保留可注册域名(eTLD+1)，如a.b.example.co.uk上报example.co.uk
+)
+
+// PrivacyConfig 上报隐私策略，在上报入口统一应用
+type PrivacyConfig struct {
+	URL             string  `yaml:"url" json:"url"`                           // URL脱敏方式: full(默认)、host或etld1
+	HashIdentifiers bool    `yaml:"hash-identifiers" json:"hash-identifiers"` // client_id及install_id上报为hex(HMAC-SHA256(hash-key, id))
+	HashKey         string  `yaml:"hash-key" json:"hash-key"`                 // 标识及客户端IP(fields.client-ip为hash时)的哈希密钥
+	SamplePercent   float64 `yaml:"sample-percent" json:"sample-percent"`     // 上报的命中比例(0, 100]，默认100；高风险命中不采样
+}
+
+// Validate 校验配置
+func (c PrivacyConfig) Validate() error {
+	switch c.URL {
+	case "", URLFull, URLHost, URLETLD1:
+	default:
+		return fmt.Errorf("unsupported privacy url %q, supported: %s, %s, %s", c.URL, URLFull, URLHost, URLETLD1)
+	}
+	if c.SamplePercent < 0 || c.SamplePercent > 100 {
+		return fmt.Errorf("sample-percent %v out of range (0, 100]", c.SamplePercent)
+	}
+	if c.HashIdentifiers && c.HashKey == "" {
+		return fmt.Errorf("hash-key is required when hash-identifiers is enabled")
+	}
+	return nil
+}
+
+// sampled 按采样比例判断命中是否上报
+func (c PrivacyConfig) sampled() bool {
+	if c.SamplePercent <= 0 || c.SamplePercent >= 100 {
+		return true
+	}
+	return rand.Float64()*100 < c.SamplePercent
+}
+
+// redactURL 按脱敏方式处理URL，无法解析时仅保留主机名部分
+func (c PrivacyConfig) redactURL(raw string) string {
+	if c.URL == "" || c.URL == URLFull {
+		return raw
+	}
+	host := hostOf(raw)
+	if c.URL != URLETLD1 || net.ParseIP(host) != nil {
+		return host
+	}
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
+
+// identifier 按配置对标识做哈希
+func (c PrivacyConfig) identifier(id string) string {
+	if !c.HashIdentifiers || id == "" {
+		return id
+	}
+	return keyedHash(c.HashKey, id)
+}
+
+// hostOf 提取URL或域名中的主机名(小写，去除端口及末尾的点)
+func hostOf(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "//" + raw
+	}
+	host := raw
+	if u, err := url.Parse(raw); err == nil {
+		host = u.Hostname()
+	} else {
+		// 无法解析时截断至第一个路径、query或fragment分隔符
+		host = strings.TrimPrefix(host, "//")
+		if i := strings.IndexAny(host, "/?#"); i >= 0 {
+			host = host[:i]
+		}
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// OptedOut 判断opt-out请求头的值
+func OptedOut(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPrivacyRedactURL(t *testing.T) {
+	cases := []struct {
+		mode, raw, want string
+	}{
+		{URLFull, "https://a.b.example.co.uk/login?x=1", "https://a.b.example.co.uk/login?x=1"},
+		{URLHost, "https://user@A.b.example.co.uk:8443/login?x=1#f", "a.b.example.co.uk"},
+		{URLHost, "evil.example/path?q=1", "evil.example"},
+		{URLETLD1, "https://a.b.example.co.uk/login", "example.co.uk"},
+		{URLETLD1, "login.evil.example.com.", "example.com"},
+		{URLETLD1, "203.0.113.9/x", "203.0.113.9"},
+	}
+	for _, c := range cases {
+		if got := (PrivacyConfig{URL: c.mode}).redactURL(c.raw); got != c.want {
+			t.Errorf("%s(%q) = %q, want %q", c.mode, c.raw, got, c.want)
+		}
+	}
+
+	if err := (PrivacyConfig{URL: "path"}).Validate(); err == nil {
+		t.Error("expected invalid url mode")
+	}
+	if err := (PrivacyConfig{HashIdentifiers: true}).Validate(); err == nil {
+		t.Error("expected missing hash-key")
+	}
+	if _, err := NewRecorder(nil, "/conf", ReportConfig{Privacy: PrivacyConfig{SamplePercent: 200}}); err == nil {
+		t.Error("expected NewRecorder to reject invalid privacy config")
+	}
+}
+
+func TestRecorderPrivacy(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "hits.jsonl")
+	config := ReportConfig{
+		FlushInterval: 10,
+		Sinks:         []SinkConfig{{Type: SinkFile, Path: file}},
+		Fields:        FieldsConfig{InstallID: true},
+		Privacy:       PrivacyConfig{URL: URLETLD1, HashIdentifiers: true, HashKey: "k", SamplePercent: 0.000001},
+	}
+	reporter := NewReporter(config)
+	recorder, err := NewRecorder(reporter, "/conf", config)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+
+	ctx := WithCaller(context.Background(), Caller{InstallID: "install"})
+	now := time.Now()
+	recorder.Record(ctx,
+		Hit{URL: "https://login.evil.example.com/a?b", Source: "s", ClientID: "ext", Time: now, Immediate: true},
+		// 采样比例极低，非高风险命中几乎不会上报
+		Hit{URL: "other.example.com", Source: "s", Time: now},
+	)
+	recorder.Record(WithCaller(context.Background(), Caller{OptOut: true}), Hit{URL: "opt-out.example.com", Source: "s", Time: now, Immediate: true})
+	recorder.Close()
+	if err := reporter.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	records := readRecords(t, file)
+	if len(records) != 1 || len(records[0].List) != 1 {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	if records[0].Comm.InstallId != keyedHash("k", "install") {
+		t.Fatalf("install_id not hashed: %q", records[0].Comm.InstallId)
+	}
+	value := records[0].List[0].OpObjValue.(map[string]any)
+	if value["url"] != "example.com" || value["client_id"] != keyedHash("k", "ext") {
+		t.Fatalf("unexpected op_obj_value: %+v", value)
+	}
+}
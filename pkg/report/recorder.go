@@ -0,0 +1,82 @@
+package report
+
+import (
+	"context"
+	"time"
+)
+
+// Recorder 命中上报入口，统一应用opt-out、采样、字段策略及隐私策略后聚合或放入报告器队列，线程安全
+type Recorder struct {
+	reporter   *Reporter
+	aggregator *Aggregator
+	urlPath    string
+	fields     FieldsConfig
+	privacy    PrivacyConfig
+}
+
+// NewRecorder 创建上报入口，启用config.Aggregate时创建聚合器；字段策略或隐私策略配置有误时返回错误
+func NewRecorder(reporter *Reporter, urlPath string, config ReportConfig) (*Recorder, error) {
+	if err := config.ValidatePolicy(); err != nil {
+		return nil, err
+	}
+	r := &Recorder{reporter: reporter, urlPath: urlPath, fields: config.Fields, privacy: config.Privacy}
+	if config.Aggregate.Enable {
+		r.aggregator = NewAggregator(config.Aggregate, func(head ReportHead, payload ReportPayload) {
+			reporter.Enqueue(urlPath, head, payload)
+		})
+	}
+	return r, nil
+}
+
+// Record 上报一次检查的命中，调用方信息取自ctx(见WithCaller)，hit中的Head、ClientIP及TraceID由此填充
+func (r *Recorder) Record(ctx context.Context, hits ...Hit) {
+	caller := CallerFrom(ctx)
+	if caller.OptOut || len(hits) == 0 {
+		return
+	}
+	caller = r.fields.Apply(caller, r.privacy.HashKey)
+	head := caller.Head()
+	head.InstallId = r.privacy.identifier(head.InstallId)
+
+	kept := make([]Hit, 0, len(hits))
+	for _, hit := range hits {
+		if !hit.Immediate && !r.privacy.sampled() {
+			continue
+		}
+		hit.URL = r.privacy.redactURL(hit.URL)
+		hit.ClientID = r.privacy.identifier(hit.ClientID)
+		hit.Head, hit.ClientIP, hit.TraceID = head, caller.IP, caller.TraceID
+		kept = append(kept, hit)
+	}
+	if len(kept) == 0 {
+		return
+	}
+	if r.aggregator != nil {
+		r.aggregator.Add(kept...)
+		return
+	}
+
+	// 未启用聚合时每次检查的命中合并为一次上报
+	now := time.Now().UnixMilli()
+	payload := make(ReportPayload, 0, len(kept))
+	for _, hit := range kept {
+		value := map[string]interface{}{"url": hit.URL, "source": hit.Source}
+		for key, field := range map[string]string{"client_id": hit.ClientID, "client_ip": hit.ClientIP, "trace_id": hit.TraceID} {
+			if field != "" {
+				value[key] = field
+			}
+		}
+		payload = append(payload, ReportPayloadItem{
+			OpRes: OpResOK, OpObjType: OpObjTypePhishing,
+			OpObjValue: value, UserTimestamp: hit.Time.UnixMilli(), Timestamp: now,
+		})
+	}
+	r.reporter.Enqueue(r.urlPath, head, payload)
+}
+
+// Close 停止聚合并输出窗口内剩余的命中，需在关闭报告器之前调用
+func (r *Recorder) Close() {
+	if r.aggregator != nil {
+		r.aggregator.Close()
+	}
+}
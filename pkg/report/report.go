@@ -1,6 +1,7 @@
 package report
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -13,6 +14,7 @@ import (
 	"fmt"
 	"github.com/go-resty/resty/v2"
 	"godex/pkg/logger"
+	"godex/pkg/metrics"
 	"strings"
 	"time"
 )
@@ -26,10 +28,43 @@ type ReportConfig struct {
 	Endpoint     string `yaml:"endpoint" json:"endpoint"`
 	Enable       bool   `yaml:"enable" json:"enable"`
 	AESPublicKey string `yaml:"aes-public-key" json:"aes-public-key"`
+
+	Protocol      string `yaml:"protocol" json:"protocol"`               // 加密信封版本: v1(AES-CBC，兼容PHP接收端)或v2(AES-GCM)，默认v1
+	QueueSize     int    `yaml:"queue-size" json:"queue-size"`           // 待上报队列长度(次)，队列已满时丢弃，默认10000
+	BatchSize     int    `yaml:"batch-size" json:"batch-size"`           // 单次上报的最多条数，默认100
+	FlushInterval int    `yaml:"flush-interval" json:"flush-interval"`   // 未攒满一批时最长等待时间(毫秒)，默认2000
+	Timeout       int    `yaml:"timeout" json:"timeout"`                 // 单次上报超时(毫秒)，默认2000
+	MaxRetries    int    `yaml:"max-retries" json:"max-retries"`         // 失败后最多重试次数，默认3，小于0时不重试
+	RetryBackoff  int    `yaml:"retry-backoff" json:"retry-backoff"`     // 首次重试间隔(毫秒)，之后每次翻倍，默认1000
+	SpoolDir      string `yaml:"spool-dir" json:"spool-dir"`             // 重试仍失败的批次写入该目录，启动时及每隔spool-interval重新上报；为空时丢弃
+	SpoolInterval int    `yaml:"spool-interval" json:"spool-interval"`   // 重新上报spool的间隔(毫秒)，默认30000
+	SpoolMaxFiles int    `yaml:"spool-max-files" json:"spool-max-files"` // spool最多保留的批次数，超过时丢弃新的批次，默认10000
+
+	Sinks     []SinkConfig    `yaml:"sinks" json:"sinks"`         // 输出端，可同时配置多个，为空时使用加密上报协议(http)
+	Aggregate AggregateConfig `yaml:"aggregate" json:"aggregate"` // 命中聚合，未启用时每次检查的命中分别上报
+	Fields    FieldsConfig    `yaml:"fields" json:"fields"`       // 调用方信息上报策略
+	Privacy   PrivacyConfig   `yaml:"privacy" json:"privacy"`     // URL脱敏、标识哈希及采样
+}
+
+// ValidatePolicy 校验字段策略及隐私策略，fields.client-ip为hash时需配置privacy.hash-key
+func (c ReportConfig) ValidatePolicy() error {
+	if err := c.Fields.Validate(); err != nil {
+		return fmt.Errorf("report fields: %v", err)
+	}
+	if err := c.Privacy.Validate(); err != nil {
+		return fmt.Errorf("report privacy: %v", err)
+	}
+	if c.Fields.ClientIP == ClientIPHash && c.Privacy.HashKey == "" {
+		return fmt.Errorf("report privacy: hash-key is required when fields.client-ip is hash")
+	}
+	return nil
 }
 
 type ReportHead struct {
 	UserId int `json:"user_id"`
+
+	InstallId        string `json:"install_id,omitempty"`
+	ExtensionVersion string `json:"extension_version,omitempty"`
 }
 type ReportPayload = []ReportPayloadItem
 type ReportPayloadItem struct {
@@ -40,57 +75,23 @@ type ReportPayloadItem struct {
 	Timestamp     int64 `json:"timestamp_"`
 }
 
-// Reporter 报告器结构体
-type Reporter struct {
-	config ReportConfig
-}
-
-// NewReporter 创建新的报告器实例
-func NewReporter(config ReportConfig) *Reporter {
-	return &Reporter{
-		config: config,
-	}
-}
-
-// SyncSend 异步发送报告
-func (r *Reporter) SyncSend(urlPath string, head ReportHead, payload ReportPayload) {
-	go func() {
-		err := r.Send(urlPath, head, payload)
-		if err != nil {
-			logger.Errorf("Failed to send phishing sites report: %v", err)
-		}
-	}()
-}
-
-// Send 发送报告
-func (r *Reporter) Send(urlPath string, head ReportHead, payload ReportPayload) error {
-	// 验证配置
-	if r.config.Endpoint == "" {
-		return fmt.Errorf("endpoint is not configured")
-	}
-	if r.config.AESPublicKey == "" {
-		return fmt.Errorf("AES public key is not configured")
-	}
-
-	return send(r.config.Endpoint, urlPath, r.config.AESPublicKey, head, payload)
-}
-
-// send 发送加密的数据到指定的URL，对应PHP的send方法
-func send(baseURL, urlPath, rsaPublicKeyPEM string, head ReportHead, payload ReportPayload) error {
+// send 发送加密的数据到指定的URL，对应PHP的send方法，protocol为信封版本(见Protocol*)，返回失败时是否可重试
+// 网络错误、5xx及429可重试，配置错误及其他状态码不可重试
+func send(ctx context.Context, client *resty.Client, baseURL, urlPath, rsaPublicKeyPEM, protocol string, head ReportHead, payload ReportPayload) (bool, error) {
 	// 解析RSA公钥
 	block, _ := pem.Decode([]byte(rsaPublicKeyPEM))
 	if block == nil {
-		return fmt.Errorf("failed to decode PEM block")
+		return false, fmt.Errorf("failed to decode PEM block")
 	}
 
 	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
 	if err != nil {
-		return fmt.Errorf("failed to parse public key: %v", err)
+		return false, fmt.Errorf("failed to parse public key: %v", err)
 	}
 
 	rsaPublicKey, ok := pub.(*rsa.PublicKey)
 	if !ok {
-		return fmt.Errorf("not an RSA public key")
+		return false, fmt.Errorf("not an RSA public key")
 	}
 
 	// 构造数据结构
@@ -102,50 +103,44 @@ func send(baseURL, urlPath, rsaPublicKeyPEM string, head ReportHead, payload Rep
 	// 将数据转换为JSON
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal data: %v", err)
-	}
-
-	// 生成16位随机字符串
-	random, err := generateRandomString(16)
-	if err != nil {
-		return fmt.Errorf("failed to generate random string: %v", err)
+		return false, fmt.Errorf("failed to marshal data: %v", err)
 	}
 
-	// AES加密数据
-	encryptedData, err := aesEncrypt(random, string(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to encrypt data with AES: %v", err)
+	// 按协议版本加密
+	var apiData map[string]string
+	switch protocol {
+	case "", ProtocolV1:
+		apiData, err = sealV1(rsaPublicKey, jsonData)
+	case ProtocolV2:
+		apiData, err = sealV2(rsaPublicKey, jsonData)
+	default:
+		err = fmt.Errorf("unsupported report protocol %q", protocol)
 	}
-
-	// RSA加密随机字符串
-	encryptedRandom, err := rsaEncrypt(rsaPublicKey, random)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt random string with RSA: %v", err)
-	}
-
-	// 构造API数据
-	apiData := map[string]string{
-		"_t": encryptedRandom,
-		"_a": encryptedData,
+		return false, err
 	}
 
 	// 发送POST请求
 	fullURL := strings.TrimRight(baseURL, "/") + urlPath
-	client := resty.New().SetTimeout(2 * time.Second)
+	start := time.Now()
 	resp, err := client.R().
+		SetContext(ctx).
 		SetFormData(apiData).
 		Post(fullURL)
 
 	if err != nil {
+		metrics.ObserveUpstream("report", "send", start, err)
 		// 记录错误日志
 		logger.Errorf("Request error: %v, URL: %s, Data: %+v", err, fullURL, apiData)
-		return fmt.Errorf("request failed: %v", err)
+		return true, fmt.Errorf("request failed: %v", err)
 	}
 
 	// 解析响应
+	metrics.ObserveHTTPUpstream("report", "send", start, resp.StatusCode())
 	if resp.StatusCode() != 200 {
 		logger.Errorf("Request error: status %d, body: %s, URL: %s", resp.StatusCode(), resp.String(), fullURL)
-		return fmt.Errorf("HTTP error: status %d", resp.StatusCode())
+		retryable := resp.StatusCode() >= 500 || resp.StatusCode() == 429
+		return retryable, fmt.Errorf("HTTP error: status %d", resp.StatusCode())
 	}
 
 	// 尝试解析响应为JSON
@@ -158,7 +153,7 @@ func send(baseURL, urlPath, rsaPublicKeyPEM string, head ReportHead, payload Rep
 
 	logger.Infof("Successfully sent request to %+v", result)
 
-	return nil
+	return false, nil
 }
 
 // generateRandomString 生成指定长度的随机字符串
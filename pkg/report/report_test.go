@@ -1,7 +1,10 @@
 package report
 
 import (
-	"fmt"
+	"context"
+	"github.com/go-resty/resty/v2"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -49,8 +52,14 @@ func TestSend(t *testing.T) {
 			Timestamp:     nowMillis,
 		},
 	}
-	err := send("https://sys-test.adspower.net", "/conf", rsaPublicKeyPEM, reportHead, reportPayload)
-	if err != nil {
-		fmt.Printf("Send failed: %v", err)
+	// 完整的加解密往返见reportdecoder包的测试
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("_t") == "" || r.PostForm.Get("_a") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	if _, err := send(context.Background(), resty.New().SetTimeout(2*time.Second), server.URL, "/conf", rsaPublicKeyPEM, ProtocolV1, reportHead, reportPayload); err != nil {
+		t.Fatalf("Send failed: %v", err)
 	}
 }
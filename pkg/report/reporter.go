@@ -0,0 +1,422 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"godex/pkg/logger"
+	"godex/pkg/metrics"
+	"sync"
+	"time"
+)
+
+// 默认配置
+const (
+	defaultQueueSize     = 10000
+	defaultBatchSize     = 100
+	defaultFlushInterval = 2000
+	defaultTimeout       = 2000
+	defaultMaxRetries    = 3
+	defaultRetryBackoff  = 1000
+	defaultSpoolInterval = 30000
+	defaultSpoolMaxFiles = 10000
+	maxRetryBackoff      = 60 * time.Second
+)
+
+// Batch 一次上报的数据，同一urlPath及head的上报合并为一批
+type Batch struct {
+	URLPath string        `json:"url_path"`
+	Head    ReportHead    `json:"head"`
+	Payload ReportPayload `json:"payload"`
+	Sink    string        `json:"sink,omitempty"` // 写入spool时记录失败的输出端，重新上报时只发送到该输出端
+}
+
+// key 合并批次的键
+func (b Batch) key() string {
+	head, _ := json.Marshal(b.Head)
+	return b.URLPath + "\n" + string(head)
+}
+
+// Reporter 报告器，上报先进入内存队列，按条数或时间合并成批后发送到各输出端，失败按指数退避重试，
+// 最终失败的批次按输出端写入spool目录，启动时及定时重新上报，退出时发送队列中剩余的上报
+type Reporter struct {
+	config ReportConfig
+	sinks  []Sink
+	spool  *spool
+	queue  chan Batch
+	sends  []chan Batch // 与sinks一一对应的带缓冲队列，各输出端独立发送及重试，互不阻塞
+
+	ctx      context.Context    // 发送请求的ctx，Close超时时取消，剩余批次直接写入spool
+	abort    context.CancelFunc // 取消ctx
+	stopping chan struct{}      // Close时关闭，不再等待重试
+	wg       sync.WaitGroup
+
+	mu     sync.Mutex // 保护closed
+	closed bool
+
+	replaySpool bool // 是否重新上报spool，同一spool目录只应由一个进程重新上报
+}
+
+// Option 报告器选项
+type Option func(*Reporter)
+
+// WithoutReplay 不重新上报spool中的批次，仍写入spool，用于与服务共用spool目录的命令行进程
+func WithoutReplay() Option {
+	return func(r *Reporter) {
+		r.replaySpool = false
+	}
+}
+
+// NewReporter 创建报告器并启动合并、发送及spool重新上报协程
+// 未配置sinks时使用加密上报协议(http)；配置错误的输出端记录错误日志后忽略
+func NewReporter(config ReportConfig, options ...Option) *Reporter {
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = defaultMaxRetries
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaultRetryBackoff
+	}
+	if config.SpoolInterval <= 0 {
+		config.SpoolInterval = defaultSpoolInterval
+	}
+	if config.SpoolMaxFiles <= 0 {
+		config.SpoolMaxFiles = defaultSpoolMaxFiles
+	}
+	if len(config.Sinks) == 0 {
+		config.Sinks = []SinkConfig{{Type: SinkHTTP}}
+	}
+
+	ctx, abort := context.WithCancel(context.Background())
+	r := &Reporter{
+		config:      config,
+		spool:       newSpool(config.SpoolDir, config.SpoolMaxFiles),
+		queue:       make(chan Batch, config.QueueSize),
+		ctx:         ctx,
+		abort:       abort,
+		stopping:    make(chan struct{}),
+		replaySpool: true,
+	}
+	for _, option := range options {
+		option(r)
+	}
+	names := map[string]bool{}
+	for _, sinkConfig := range config.Sinks {
+		sink, err := NewSink(sinkConfig, config)
+		if err == nil && names[sink.Name()] {
+			err = fmt.Errorf("report sink %s is duplicated", sink.Name())
+		}
+		if err != nil {
+			logger.Errorf("Report sink ignored: %v", err)
+			continue
+		}
+		names[sink.Name()] = true
+		r.sinks = append(r.sinks, sink)
+		r.sends = append(r.sends, make(chan Batch, max(1, config.QueueSize/config.BatchSize)))
+	}
+	if len(r.sinks) == 0 {
+		logger.Errorf("No report sink is available, reports will be dropped")
+	}
+
+	r.wg.Add(2 + len(r.sinks))
+	go r.batchLoop()
+	for i, sink := range r.sinks {
+		go r.sendLoop(sink, r.sends[i])
+	}
+	go r.replayLoop()
+	return r
+}
+
+// Enqueue 将上报放入队列，不阻塞；队列已满或报告器已关闭时丢弃并计数，调用方位于请求路径上，不做文件读写
+func (r *Reporter) Enqueue(urlPath string, head ReportHead, payload ReportPayload) {
+	if len(payload) == 0 {
+		return
+	}
+	batch := Batch{URLPath: urlPath, Head: head, Payload: payload}
+
+	r.mu.Lock()
+	queued := false
+	if !r.closed {
+		select {
+		case r.queue <- batch:
+			queued = true
+		default:
+		}
+	}
+	r.mu.Unlock()
+
+	if !queued {
+		for _, sink := range r.sinks {
+			metrics.ReportItems.WithLabelValues(sink.Name(), metrics.ReportItemDropped).Add(float64(len(payload)))
+		}
+	}
+}
+
+// SyncSend 异步发送报告
+//
+// Deprecated: 使用Enqueue，上报经过队列合并、重试及spool
+func (r *Reporter) SyncSend(urlPath string, head ReportHead, payload ReportPayload) {
+	r.Enqueue(urlPath, head, payload)
+}
+
+// Send 立即发送一次报告到所有输出端，不经过队列，不重试
+func (r *Reporter) Send(urlPath string, head ReportHead, payload ReportPayload) error {
+	return r.SendContext(context.Background(), urlPath, head, payload)
+}
+
+// SendContext 同Send，ctx结束时取消进行中的请求
+func (r *Reporter) SendContext(ctx context.Context, urlPath string, head ReportHead, payload ReportPayload) error {
+	if len(r.sinks) == 0 {
+		return fmt.Errorf("no report sink is configured")
+	}
+	var errs []error
+	for _, sink := range r.sinks {
+		if _, err := r.post(ctx, sink, Batch{URLPath: urlPath, Head: head, Payload: payload}); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 停止接收新的上报，发送队列中剩余的上报(不再重试，失败时写入spool)；
+// ctx结束时取消进行中的请求，剩余批次直接写入spool后返回ctx的错误
+func (r *Reporter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+		close(r.stopping)
+	}
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.abort()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// batchLoop 按urlPath及head合并上报，攒满batch-size或每隔flush-interval交给各输出端的发送协程
+func (r *Reporter) batchLoop() {
+	defer r.wg.Done()
+	defer func() {
+		for _, sends := range r.sends {
+			close(sends)
+		}
+	}()
+
+	ticker := time.NewTicker(time.Duration(r.config.FlushInterval) * time.Millisecond)
+	defer ticker.Stop()
+	pending := map[string]*Batch{}
+	flushAll := func() {
+		for key, batch := range pending {
+			r.dispatch(*batch)
+			delete(pending, key)
+		}
+	}
+
+	for {
+		select {
+		case batch, ok := <-r.queue:
+			if !ok {
+				flushAll()
+				return
+			}
+			key := batch.key()
+			merged, ok := pending[key]
+			if !ok {
+				merged = &Batch{URLPath: batch.URLPath, Head: batch.Head}
+				pending[key] = merged
+			}
+			merged.Payload = append(merged.Payload, batch.Payload...)
+			for len(merged.Payload) >= r.config.BatchSize {
+				r.dispatch(Batch{URLPath: merged.URLPath, Head: merged.Head, Payload: merged.Payload[:r.config.BatchSize:r.config.BatchSize]})
+				merged.Payload = merged.Payload[r.config.BatchSize:]
+			}
+			if len(merged.Payload) == 0 {
+				delete(pending, key)
+			}
+		case <-ticker.C:
+			flushAll()
+		}
+	}
+}
+
+// dispatch 将批次交给所有输出端，不阻塞；输出端队列已满(发送持续失败或过慢)时该输出端的批次直接写入spool
+func (r *Reporter) dispatch(batch Batch) {
+	for i, sends := range r.sends {
+		select {
+		case sends <- batch:
+		default:
+			r.fallback(r.sinks[i], batch, fmt.Errorf("sink queue is full"))
+		}
+	}
+}
+
+// sendLoop 输出端的发送协程
+func (r *Reporter) sendLoop(sink Sink, sends chan Batch) {
+	defer r.wg.Done()
+	for batch := range sends {
+		r.deliver(sink, batch)
+	}
+}
+
+// deliver 发送一个批次，可重试的错误按指数退避重试，关闭中不再等待重试，最终失败时写入spool
+func (r *Reporter) deliver(sink Sink, batch Batch) {
+	backoff := time.Duration(r.config.RetryBackoff) * time.Millisecond
+	var err error
+	for attempts := 0; attempts <= r.config.MaxRetries; attempts++ {
+		if attempts > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-r.stopping:
+				timer.Stop()
+				r.fallback(sink, batch, err)
+				return
+			case <-timer.C:
+			}
+			backoff = min(backoff*2, maxRetryBackoff)
+		}
+		if r.ctx.Err() != nil {
+			err = r.ctx.Err()
+			break
+		}
+
+		var retryable bool
+		if retryable, err = r.post(r.ctx, sink, batch); err == nil {
+			metrics.ReportItems.WithLabelValues(sink.Name(), metrics.ReportItemSent).Add(float64(len(batch.Payload)))
+			return
+		}
+		logger.Warnf("Report sink %s batch of %d items attempt %d failed: %v", sink.Name(), len(batch.Payload), attempts+1, err)
+		if !retryable {
+			r.drop(sink.Name(), batch, err)
+			return
+		}
+	}
+	r.fallback(sink, batch, err)
+}
+
+// post 发送一次请求，返回失败时是否可重试
+func (r *Reporter) post(ctx context.Context, sink Sink, batch Batch) (bool, error) {
+	retryable, err := sink.Send(ctx, batch)
+	metrics.ReportSends.WithLabelValues(sink.Name(), metrics.Result(err)).Inc()
+	return retryable, err
+}
+
+// replayLoop 启动时及每隔spool-interval重新上报spool中的批次
+func (r *Reporter) replayLoop() {
+	defer r.wg.Done()
+	if r.config.SpoolDir == "" || !r.replaySpool {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(r.config.SpoolInterval) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		r.replay()
+		select {
+		case <-r.stopping:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// replay 按写入顺序重新上报spool中的批次，某个输出端遇到可重试的失败时本轮跳过该输出端的后续批次
+func (r *Reporter) replay() {
+	names, err := r.spool.list()
+	if err != nil {
+		logger.Errorf("Failed to list report spool: %v", err)
+		return
+	}
+	unavailable := map[string]bool{}
+	for _, name := range names {
+		select {
+		case <-r.stopping:
+			return
+		default:
+		}
+
+		batch, err := r.spool.read(name)
+		if err != nil {
+			logger.Errorf("Failed to read report spool %s, removed: %v", name, err)
+			r.spool.remove(name)
+			continue
+		}
+		sink := r.sink(batch.Sink)
+		if sink == nil {
+			r.drop(batch.Sink, batch, fmt.Errorf("report sink %q is not configured", batch.Sink))
+			r.spool.remove(name)
+			continue
+		}
+		if unavailable[sink.Name()] {
+			continue
+		}
+
+		retryable, err := r.post(r.ctx, sink, batch)
+		if err != nil && retryable {
+			logger.Warnf("Replay report spool %s to sink %s failed, retry later: %v", name, sink.Name(), err)
+			unavailable[sink.Name()] = true
+			continue
+		}
+		if err != nil {
+			r.drop(sink.Name(), batch, err)
+		} else {
+			metrics.ReportItems.WithLabelValues(sink.Name(), metrics.ReportItemSent).Add(float64(len(batch.Payload)))
+		}
+		r.spool.remove(name)
+	}
+}
+
+// sink 按名称查找输出端，名称为空时(旧版本写入的spool)使用第一个输出端
+func (r *Reporter) sink(name string) Sink {
+	for _, sink := range r.sinks {
+		if name == "" || sink.Name() == name {
+			return sink
+		}
+	}
+	return nil
+}
+
+// fallback 按输出端写入spool，未配置spool或写入失败时丢弃
+func (r *Reporter) fallback(sink Sink, batch Batch, cause error) {
+	if r.config.SpoolDir == "" {
+		r.drop(sink.Name(), batch, cause)
+		return
+	}
+	batch.Sink = sink.Name()
+	if err := r.spool.write(batch); err != nil {
+		r.drop(sink.Name(), batch, fmt.Errorf("%v, spool failed: %v", cause, err))
+		return
+	}
+	metrics.ReportItems.WithLabelValues(sink.Name(), metrics.ReportItemSpooled).Add(float64(len(batch.Payload)))
+	logger.Warnf("Report sink %s batch of %d items spooled: %v", sink.Name(), len(batch.Payload), cause)
+}
+
+// drop 丢弃批次
+func (r *Reporter) drop(sinkName string, batch Batch, cause error) {
+	metrics.ReportItems.WithLabelValues(sinkName, metrics.ReportItemDropped).Add(float64(len(batch.Payload)))
+	logger.Errorf("Report sink %s batch of %d items dropped: %v", sinkName, len(batch.Payload), cause)
+}
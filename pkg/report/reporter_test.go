@@ -0,0 +1,102 @@
+package report
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestServer(status *atomic.Int32, requests *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+}
+
+func testItems(n int) ReportPayload {
+	payload := ReportPayload{}
+	for i := 0; i < n; i++ {
+		payload = append(payload, ReportPayloadItem{OpObjType: OpObjTypePhishing, OpObjValue: map[string]any{"url": "a.com"}})
+	}
+	return payload
+}
+
+func TestReporterBatch(t *testing.T) {
+	var status, requests atomic.Int32
+	status.Store(http.StatusOK)
+	server := newTestServer(&status, &requests)
+	defer server.Close()
+
+	r := NewReporter(ReportConfig{Endpoint: server.URL, AESPublicKey: rsaPublicKeyPEM, BatchSize: 3, FlushInterval: 60000})
+	for i := 0; i < 5; i++ {
+		r.Enqueue("/conf", ReportHead{}, testItems(1))
+	}
+	r.Enqueue("/conf", ReportHead{UserId: 1}, testItems(1))
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// 3条一批 + 剩余2条 + 不同head的1条
+	if got := requests.Load(); got != 3 {
+		t.Fatalf("requests = %d, want 3", got)
+	}
+}
+
+func TestReporterSpool(t *testing.T) {
+	var status, requests atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := newTestServer(&status, &requests)
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := ReportConfig{Endpoint: server.URL, AESPublicKey: rsaPublicKeyPEM, MaxRetries: -1, SpoolDir: dir, SpoolInterval: 60000}
+	r := NewReporter(config)
+	r.Enqueue("/conf", ReportHead{}, testItems(2))
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// 关闭后的上报直接丢弃，不写入spool
+	r.Enqueue("/conf", ReportHead{}, testItems(1))
+	if names, _ := r.spool.list(); len(names) != 1 || r.spool.count != 1 {
+		t.Fatalf("spooled files = %d (count %d), want 1", len(names), r.spool.count)
+	}
+
+	// 重启后重新上报spool并删除已发送的文件
+	status.Store(http.StatusOK)
+	requests.Store(0)
+	r = NewReporter(config)
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 || requests.Load() != 1 {
+		t.Fatalf("spool not replayed: files=%d requests=%d", len(entries), requests.Load())
+	}
+}
+
+func TestReporterWithoutReplay(t *testing.T) {
+	var status, requests atomic.Int32
+	status.Store(http.StatusOK)
+	server := newTestServer(&status, &requests)
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := ReportConfig{Endpoint: server.URL, AESPublicKey: rsaPublicKeyPEM, SpoolDir: dir, SpoolInterval: 60000}
+	if err := newSpool(dir, 10).write(Batch{URLPath: "/conf", Payload: testItems(1), Sink: SinkHTTP}); err != nil {
+		t.Fatalf("write spool: %v", err)
+	}
+	r := NewReporter(config, WithoutReplay())
+	time.Sleep(100 * time.Millisecond)
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if names, _ := r.spool.list(); len(names) != 1 || requests.Load() != 0 {
+		t.Fatalf("spool replayed: files=%d requests=%d", len(names), requests.Load())
+	}
+}
@@ -0,0 +1,236 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"godex/pkg/metrics"
+	"godex/pkg/tracing"
+	"godex/pkg/webhook"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 输出端类型
+const (
+	SinkHTTP    = "http"    // 加密上报协议，即原有的上报平台
+	SinkFile    = "file"    // 追加写入本地JSON Lines文件
+	SinkStdout  = "stdout"  // 输出到标准输出(JSON Lines)
+	SinkWebhook = "webhook" // 以JSON POST到通用Webhook
+)
+
+// Sink 上报输出端，Send返回失败时是否可重试，实现需线程安全
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, batch Batch) (bool, error)
+}
+
+// SinkConfig 输出端配置
+type SinkConfig struct {
+	Type         string            `yaml:"type" json:"type"`                     // http、file、stdout或webhook
+	Name         string            `yaml:"name" json:"name"`                     // 名称，用于日志、指标及spool，不可重复，默认为type
+	Endpoint     string            `yaml:"endpoint" json:"endpoint"`             // http: 上报地址，默认为report.endpoint
+	AESPublicKey string            `yaml:"aes-public-key" json:"aes-public-key"` // http: RSA公钥，默认为report.aes-public-key
+	Protocol     string            `yaml:"protocol" json:"protocol"`             // http: 加密信封版本v1或v2，默认为report.protocol
+	Path         string            `yaml:"path" json:"path"`                     // file: 文件路径
+	URL          string            `yaml:"url" json:"url"`                       // webhook: 接收地址
+	Secret       string            `yaml:"secret" json:"secret"`                 // webhook: 签名密钥，签名方式与webhook包一致，为空时不签名
+	Headers      map[string]string `yaml:"headers" json:"headers"`               // webhook: 附加请求头
+}
+
+// Record 明文输出端(file、stdout、webhook)输出的一条记录
+type Record struct {
+	Time int64         `json:"time"` // 输出时间(Unix毫秒)
+	Path string        `json:"path"` // 上报路径，如/conf
+	Comm ReportHead    `json:"comm"`
+	List ReportPayload `json:"list"`
+}
+
+// newRecord 批次转换为明文记录
+func newRecord(batch Batch) Record {
+	return Record{Time: time.Now().UnixMilli(), Path: batch.URLPath, Comm: batch.Head, List: batch.Payload}
+}
+
+// NewSink 按配置创建输出端，config中未设置的公共参数(地址、公钥及超时)取自report配置
+func NewSink(config SinkConfig, report ReportConfig) (Sink, error) {
+	name := config.Name
+	if name == "" {
+		name = config.Type
+	}
+	timeout := time.Duration(report.Timeout) * time.Millisecond
+
+	switch config.Type {
+	case SinkHTTP:
+		if config.Endpoint == "" {
+			config.Endpoint = report.Endpoint
+		}
+		if config.AESPublicKey == "" {
+			config.AESPublicKey = report.AESPublicKey
+		}
+		if config.Protocol == "" {
+			config.Protocol = report.Protocol
+		}
+		if config.Protocol == "" {
+			config.Protocol = ProtocolV1
+		}
+		// 验证配置
+		if config.Endpoint == "" {
+			return nil, fmt.Errorf("report sink %s: endpoint is not configured", name)
+		}
+		if config.AESPublicKey == "" {
+			return nil, fmt.Errorf("report sink %s: AES public key is not configured", name)
+		}
+		if config.Protocol != ProtocolV1 && config.Protocol != ProtocolV2 {
+			return nil, fmt.Errorf("report sink %s: unsupported protocol %q, supported: %s, %s", name, config.Protocol, ProtocolV1, ProtocolV2)
+		}
+		client := tracing.InstrumentResty(resty.New().SetTimeout(timeout), "report")
+		return &httpSink{name: name, endpoint: config.Endpoint, publicKey: config.AESPublicKey, protocol: config.Protocol, client: client}, nil
+	case SinkFile:
+		if config.Path == "" {
+			return nil, fmt.Errorf("report sink %s: path is not configured", name)
+		}
+		return &fileSink{name: name, path: config.Path}, nil
+	case SinkStdout:
+		return &writerSink{name: name, writer: os.Stdout}, nil
+	case SinkWebhook:
+		if config.URL == "" {
+			return nil, fmt.Errorf("report sink %s: url is not configured", name)
+		}
+		client := tracing.InstrumentResty(resty.New().SetTimeout(timeout), "report-webhook")
+		return &webhookSink{name: name, url: config.URL, secret: config.Secret, headers: config.Headers, client: client}, nil
+	default:
+		return nil, fmt.Errorf("report sink %s: unsupported type %q, supported: %s, %s, %s, %s", name, config.Type, SinkHTTP, SinkFile, SinkStdout, SinkWebhook)
+	}
+}
+
+// httpSink 加密上报协议
+type httpSink struct {
+	name      string
+	endpoint  string
+	publicKey string
+	protocol  string
+	client    *resty.Client
+}
+
+// Name 实现Sink
+func (s *httpSink) Name() string {
+	return s.name
+}
+
+// Send 实现Sink
+func (s *httpSink) Send(ctx context.Context, batch Batch) (bool, error) {
+	return send(ctx, s.client, s.endpoint, batch.URLPath, s.publicKey, s.protocol, batch.Head, batch.Payload)
+}
+
+// fileSink 追加写入JSON Lines文件，每次写入时打开文件，便于外部轮转
+type fileSink struct {
+	name string
+	path string
+	mu   sync.Mutex
+}
+
+// Name 实现Sink
+func (s *fileSink) Name() string {
+	return s.name
+}
+
+// Send 实现Sink，写入失败可重试
+func (s *fileSink) Send(ctx context.Context, batch Batch) (bool, error) {
+	line, err := json.Marshal(newRecord(batch))
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal record: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return true, fmt.Errorf("failed to create report file dir: %v", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return true, fmt.Errorf("failed to open report file: %v", err)
+	}
+	defer file.Close()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		return true, fmt.Errorf("failed to write report file: %v", err)
+	}
+	return false, nil
+}
+
+// writerSink 输出到io.Writer，如标准输出
+type writerSink struct {
+	name   string
+	writer io.Writer
+	mu     sync.Mutex
+}
+
+// Name 实现Sink
+func (s *writerSink) Name() string {
+	return s.name
+}
+
+// Send 实现Sink
+func (s *writerSink) Send(ctx context.Context, batch Batch) (bool, error) {
+	line, err := json.Marshal(newRecord(batch))
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal record: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err = s.writer.Write(append(line, '\n')); err != nil {
+		return true, err
+	}
+	return false, nil
+}
+
+// webhookSink 以JSON POST记录，设置密钥时按webhook包的方式签名
+type webhookSink struct {
+	name    string
+	url     string
+	secret  string
+	headers map[string]string
+	client  *resty.Client
+}
+
+// Name 实现Sink
+func (s *webhookSink) Name() string {
+	return s.name
+}
+
+// Send 实现Sink，网络错误、5xx及429可重试
+func (s *webhookSink) Send(ctx context.Context, batch Batch) (bool, error) {
+	body, err := json.Marshal(newRecord(batch))
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal record: %v", err)
+	}
+
+	request := s.client.R().
+		SetContext(ctx).
+		SetHeaders(s.headers).
+		SetHeader("Content-Type", "application/json").
+		SetBody(body)
+	if s.secret != "" {
+		timestamp := time.Now().Unix()
+		request.SetHeader(webhook.HeaderTimestamp, strconv.FormatInt(timestamp, 10)).
+			SetHeader(webhook.HeaderSignature, webhook.Sign(s.secret, timestamp, body))
+	}
+
+	start := time.Now()
+	resp, err := request.Post(s.url)
+	if err != nil {
+		metrics.ObserveUpstream("report-webhook", "send", start, err)
+		return true, fmt.Errorf("request failed: %v", err)
+	}
+	metrics.ObserveHTTPUpstream("report-webhook", "send", start, resp.StatusCode())
+	if resp.StatusCode() >= 200 && resp.StatusCode() < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode() >= 500 || resp.StatusCode() == 429
+	return retryable, fmt.Errorf("HTTP error: status %d", resp.StatusCode())
+}
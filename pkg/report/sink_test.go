@@ -0,0 +1,121 @@
+package report
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReporterSinks(t *testing.T) {
+	var status, requests atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := newTestServer(&status, &requests)
+	defer server.Close()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "reports", "hits.jsonl")
+	config := ReportConfig{
+		MaxRetries: -1, SpoolDir: filepath.Join(dir, "spool"), SpoolInterval: 60000,
+		Sinks: []SinkConfig{{Type: SinkFile, Path: file}, {Type: SinkWebhook, Name: "soc", URL: server.URL}, {Type: "unknown"}},
+	}
+	r := NewReporter(config)
+	if len(r.sinks) != 2 {
+		t.Fatalf("sinks = %d, want 2", len(r.sinks))
+	}
+	r.Enqueue("/conf", ReportHead{UserId: 1}, testItems(2))
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// 文件输出端成功，Webhook失败的批次按输出端写入spool
+	records := readRecords(t, file)
+	if len(records) != 1 || records[0].Path != "/conf" || records[0].Comm.UserId != 1 || len(records[0].List) != 2 {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	names, _ := r.spool.list()
+	if len(names) != 1 {
+		t.Fatalf("spooled files = %d, want 1", len(names))
+	}
+	if batch, _ := r.spool.read(names[0]); batch.Sink != "soc" {
+		t.Fatalf("spooled sink = %q", batch.Sink)
+	}
+
+	// 重新上报只发送到失败的输出端
+	status.Store(http.StatusOK)
+	requests.Store(0)
+	r = NewReporter(config)
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if names, _ = r.spool.list(); len(names) != 0 || requests.Load() != 1 || len(readRecords(t, file)) != 1 {
+		t.Fatalf("replay: files=%d requests=%d", len(names), requests.Load())
+	}
+}
+
+func readRecords(t *testing.T, path string) []Record {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open records: %v", err)
+	}
+	defer file.Close()
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("decode record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestReporterSlowSink(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "hits.jsonl")
+	config := ReportConfig{
+		QueueSize: 2, BatchSize: 1, MaxRetries: -1, SpoolDir: filepath.Join(dir, "spool"), SpoolInterval: 60000,
+		Sinks: []SinkConfig{{Type: SinkFile, Path: file}, {Type: SinkWebhook, Name: "slow", URL: server.URL}},
+	}
+	r := NewReporter(config)
+	for i := 1; i <= 5; i++ {
+		r.Enqueue("/conf", ReportHead{UserId: i}, testItems(1))
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 阻塞的输出端不影响其他输出端，其队列已满的批次写入spool
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(file); err == nil && len(readRecords(t, file)) == 5 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(readRecords(t, file)); n != 5 {
+		t.Fatalf("file records = %d, want 5", n)
+	}
+	if names, _ := r.spool.list(); len(names) == 0 {
+		t.Fatal("overflow of the slow sink not spooled")
+	}
+	close(release)
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
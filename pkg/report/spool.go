@@ -0,0 +1,123 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// spoolExt spool文件扩展名，写入过程中的临时文件不使用该扩展名
+const spoolExt = ".json"
+
+// spool 上报失败的批次，每个批次一个文件，文件名以写入时间开头以便按顺序重新上报
+type spool struct {
+	dir      string
+	maxFiles int
+
+	mu    sync.Mutex // 保护count
+	count int        // 文件个数，首次写入时读取目录，之后在内存中维护
+	ready bool
+}
+
+// newSpool 创建spool
+func newSpool(dir string, maxFiles int) *spool {
+	return &spool{dir: dir, maxFiles: maxFiles}
+}
+
+// reserve 占用一个文件名额，已满时返回错误；写入失败时需调用release归还
+func (s *spool) reserve() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ready {
+		names, err := s.list()
+		if err != nil {
+			return err
+		}
+		s.count, s.ready = len(names), true
+	}
+	if s.count >= s.maxFiles {
+		return fmt.Errorf("spool is full (%d files)", s.count)
+	}
+	s.count++
+	return nil
+}
+
+// release 归还一个文件名额
+func (s *spool) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count > 0 {
+		s.count--
+	}
+}
+
+// write 写入批次，先写临时文件再重命名，避免重新上报时读到不完整的文件
+func (s *spool) write(batch Batch) (err error) {
+	if err = s.reserve(); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			s.release()
+		}
+	}()
+
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("marshal batch failed: %v", err)
+	}
+	if err = os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("create spool dir failed: %v", err)
+	}
+	name := fmt.Sprintf("%020d-%s%s", time.Now().UnixNano(), uuid.NewString(), spoolExt)
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write spool file failed: %v", err)
+	}
+	if err = os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rename spool file failed: %v", err)
+	}
+	return nil
+}
+
+// list 按写入顺序列出spool文件
+func (s *spool) list() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), spoolExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// read 读取spool文件
+func (s *spool) read(name string) (Batch, error) {
+	var batch Batch
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return batch, err
+	}
+	err = json.Unmarshal(data, &batch)
+	return batch, err
+}
+
+// remove 删除spool文件
+func (s *spool) remove(name string) {
+	if err := os.Remove(filepath.Join(s.dir, name)); err == nil {
+		s.release()
+	}
+}
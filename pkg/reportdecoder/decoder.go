@@ -0,0 +1,209 @@
+package reportdecoder
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"godex/pkg/report"
+	"net/http"
+)
+
+// 表单字段，与report包的上报协议一致
+const (
+	FieldKey     = report.FieldKey
+	FieldData    = report.FieldData
+	FieldVersion = report.FieldVersion
+)
+
+// 密钥长度: v1为16位随机串(AES-128)，v2为AES-256
+const (
+	aesKeySize   = 16
+	gcmKeySize   = 32
+	versionV2Tag = "2"
+)
+
+// maxFormBytes 单次上报请求体的最大字节数
+const maxFormBytes = 8 << 20
+
+// Report 解密后的上报内容
+type Report struct {
+	Version string               `json:"-"` // 信封版本，report.ProtocolV1或report.ProtocolV2
+	Comm    report.ReportHead    `json:"comm"`
+	List    report.ReportPayload `json:"list"`
+}
+
+// Decoder 使用RSA私钥解密上报，线程安全
+type Decoder struct {
+	privateKey *rsa.PrivateKey
+}
+
+// NewDecoder 创建解密器，privateKeyPEM支持PKCS#1及PKCS#8格式的RSA私钥
+func NewDecoder(privateKeyPEM []byte) (*Decoder, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return &Decoder{privateKey: key}, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA private key")
+	}
+	return &Decoder{privateKey: key}, nil
+}
+
+// GenerateKey 生成RSA密钥对，返回PKCS#8私钥及PKIX公钥(用于report.aes-public-key)的PEM
+func GenerateKey(bits int) (privateKeyPEM, publicKeyPEM []byte, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKeyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})
+	publicKeyPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return privateKeyPEM, publicKeyPEM, nil
+}
+
+// DecodeRequest 解析上报请求的表单并解密
+func (d *Decoder) DecodeRequest(r *http.Request) (Report, error) {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxFormBytes)
+	if err := r.ParseForm(); err != nil {
+		return Report{}, fmt.Errorf("parse form failed: %v", err)
+	}
+	return d.Decode(r.PostForm.Get(FieldVersion), r.PostForm.Get(FieldKey), r.PostForm.Get(FieldData))
+}
+
+// Decode 按_v字段的版本解密_t/_a字段并校验上报内容，version为空或"1"时为v1，"2"时为v2
+func (d *Decoder) Decode(version, encryptedKey, encryptedData string) (Report, error) {
+	var rpt Report
+	if encryptedKey == "" || encryptedData == "" {
+		return rpt, fmt.Errorf("missing %s or %s field", FieldKey, FieldData)
+	}
+
+	cipherKey, err := base64.StdEncoding.DecodeString(encryptedKey)
+	if err != nil {
+		return rpt, fmt.Errorf("decode %s failed: %v", FieldKey, err)
+	}
+	key, err := rsa.DecryptOAEP(sha256.New(), nil, d.privateKey, cipherKey, nil)
+	if err != nil {
+		return rpt, fmt.Errorf("decrypt %s failed: %v", FieldKey, err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encryptedData)
+	if err != nil {
+		return rpt, fmt.Errorf("decode %s failed: %v", FieldData, err)
+	}
+
+	var plaintext []byte
+	switch version {
+	case "", "1":
+		version = report.ProtocolV1
+		if len(key) != aesKeySize {
+			return rpt, fmt.Errorf("invalid AES key length %d", len(key))
+		}
+		plaintext, err = aesDecrypt(key, ciphertext)
+	case versionV2Tag:
+		version = report.ProtocolV2
+		if len(key) != gcmKeySize {
+			return rpt, fmt.Errorf("invalid AES key length %d", len(key))
+		}
+		plaintext, err = gcmDecrypt(key, ciphertext)
+	default:
+		return rpt, fmt.Errorf("unsupported %s %q", FieldVersion, version)
+	}
+	if err != nil {
+		return rpt, fmt.Errorf("decrypt %s failed: %v", FieldData, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(plaintext))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&rpt); err != nil {
+		return rpt, fmt.Errorf("invalid report schema: %v", err)
+	}
+	rpt.Version = version
+	return rpt, Validate(rpt)
+}
+
+// Validate 校验上报内容
+func Validate(rpt Report) error {
+	if len(rpt.List) == 0 {
+		return fmt.Errorf("invalid report schema: list is empty")
+	}
+	for i, item := range rpt.List {
+		switch {
+		case item.OpRes != report.OpResOK && item.OpRes != report.OpResError:
+			return fmt.Errorf("invalid report schema: list[%d].op_res %d", i, item.OpRes)
+		case item.OpObjType <= 0:
+			return fmt.Errorf("invalid report schema: list[%d].op_obj_type is required", i)
+		case item.OpObjValue == nil:
+			return fmt.Errorf("invalid report schema: list[%d].op_obj_value is required", i)
+		case item.Timestamp <= 0 || item.UserTimestamp <= 0:
+			return fmt.Errorf("invalid report schema: list[%d] timestamps are required", i)
+		}
+	}
+	return nil
+}
+
+// aesDecrypt AES-128-CBC解密，IV与密钥相同，对应report包的aesEncrypt
+func aesDecrypt(key, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("ciphertext length %d is not a multiple of the block size", len(ciphertext))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, key).CryptBlocks(plaintext, ciphertext)
+	return pkcs7Unpad(plaintext, aes.BlockSize)
+}
+
+// gcmDecrypt AES-256-GCM解密并校验tag，ciphertext为nonce || 密文及tag，对应report包的v2信封
+func gcmDecrypt(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, []byte(report.V2AdditionalData))
+}
+
+// pkcs7Unpad 去除PKCS7填充
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > blockSize || padding > len(data) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, fmt.Errorf("invalid padding")
+		}
+	}
+	return data[:len(data)-padding], nil
+}
@@ -0,0 +1,118 @@
+package reportdecoder
+
+import (
+	"context"
+	"encoding/base64"
+	"godex/pkg/report"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	privateKey, publicKey, err := GenerateKey(2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	decoder, err := NewDecoder(privateKey)
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+
+	for _, protocol := range []string{report.ProtocolV1, report.ProtocolV2} {
+		t.Run(protocol, func(t *testing.T) {
+			var mu sync.Mutex
+			var received []Received
+			server := httptest.NewServer(Handler(decoder, func(r Received) error {
+				mu.Lock()
+				defer mu.Unlock()
+				received = append(received, r)
+				return nil
+			}))
+			defer server.Close()
+
+			now := time.Now().UnixMilli()
+			payload := report.ReportPayload{{
+				OpRes: report.OpResOK, OpObjType: report.OpObjTypePhishing,
+				OpObjValue: map[string]any{"url": "phishing.example", "source": "fixed-sniffer"}, UserTimestamp: now, Timestamp: now,
+			}}
+			reporter := report.NewReporter(report.ReportConfig{Endpoint: server.URL, AESPublicKey: string(publicKey), Protocol: protocol})
+			defer reporter.Close(context.Background())
+			if err = reporter.SendContext(context.Background(), "/conf", report.ReportHead{UserId: 7}, payload); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(received) != 1 {
+				t.Fatalf("received %d reports", len(received))
+			}
+			got := received[0]
+			if got.Path != "/conf" || got.Version != protocol || got.Report.Comm.UserId != 7 || len(got.Report.List) != 1 {
+				t.Fatalf("unexpected report: %+v", got)
+			}
+			if value := got.Report.List[0].OpObjValue.(map[string]any); value["url"] != "phishing.example" {
+				t.Fatalf("unexpected op_obj_value: %+v", value)
+			}
+		})
+	}
+}
+
+func TestDecodeV2Tampered(t *testing.T) {
+	privateKey, publicKey, _ := GenerateKey(2048)
+	decoder, _ := NewDecoder(privateKey)
+
+	// 捕获v2表单
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		form = r.PostForm
+	}))
+	defer server.Close()
+	now := time.Now().UnixMilli()
+	payload := report.ReportPayload{{OpRes: report.OpResOK, OpObjType: report.OpObjTypePhishing, OpObjValue: "phishing.example", UserTimestamp: now, Timestamp: now}}
+	reporter := report.NewReporter(report.ReportConfig{Endpoint: server.URL, AESPublicKey: string(publicKey), Protocol: report.ProtocolV2})
+	defer reporter.Close(context.Background())
+	if err := reporter.Send("/conf", report.ReportHead{}, payload); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if form.Get(FieldVersion) != "2" {
+		t.Fatalf("%s = %q", FieldVersion, form.Get(FieldVersion))
+	}
+	if _, err := decoder.Decode(form.Get(FieldVersion), form.Get(FieldKey), form.Get(FieldData)); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	// 篡改密文任一字节均应解密失败
+	data, _ := base64.StdEncoding.DecodeString(form.Get(FieldData))
+	data[len(data)/2] ^= 1
+	if _, err := decoder.Decode("2", form.Get(FieldKey), base64.StdEncoding.EncodeToString(data)); err == nil {
+		t.Fatal("expected tampered ciphertext to be rejected")
+	}
+	// v2密文不能按v1解密
+	if _, err := decoder.Decode("1", form.Get(FieldKey), form.Get(FieldData)); err == nil {
+		t.Fatal("expected version downgrade to be rejected")
+	}
+}
+
+func TestHandlerRejectsInvalid(t *testing.T) {
+	privateKey, _, _ := GenerateKey(2048)
+	decoder, _ := NewDecoder(privateKey)
+	handler := Handler(decoder, func(Received) error { return nil })
+
+	req := httptest.NewRequest(http.MethodPost, "/conf", strings.NewReader("_t=abc&_a=def"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	if err := Validate(Report{List: report.ReportPayload{{OpObjType: report.OpObjTypePhishing}}}); err == nil {
+		t.Fatal("expected validation error")
+	}
+}
@@ -0,0 +1,53 @@
+package reportdecoder
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Received 接收到的一次上报
+type Received struct {
+	ReceivedAt int64  `json:"received_at"` // 接收时间(Unix毫秒)
+	Path       string `json:"path"`        // 上报路径，如/conf
+	Version    string `json:"version"`     // 信封版本，v1或v2
+	Report     Report `json:"report"`
+}
+
+// response 接收端响应，报告器仅判断HTTP状态码
+type response struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// Handler 接收上报的http.Handler，解密校验通过后交给fn处理；
+// 解密或校验失败返回400，fn返回错误时返回500(报告器会重试)
+func Handler(decoder *Decoder, fn func(Received) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		rpt, err := decoder.DecodeRequest(r)
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err = fn(Received{ReceivedAt: time.Now().UnixMilli(), Path: r.URL.Path, Version: rpt.Version, Report: rpt}); err != nil {
+			writeResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeResponse(w, http.StatusOK, "ok")
+	})
+}
+
+// writeResponse 输出JSON响应
+func writeResponse(w http.ResponseWriter, status int, msg string) {
+	code := 0
+	if status != http.StatusOK {
+		code = status
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response{Code: code, Msg: msg})
+}
@@ -24,4 +24,6 @@ const (
 	ErrorTypeRPCFail ErrorType = 4
 	// ErrorTypeDBFail ...
 	ErrorTypeDBFail ErrorType = 5
+	// ErrorTypeAuthFail ...
+	ErrorTypeAuthFail ErrorType = 6
 )
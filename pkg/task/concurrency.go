@@ -0,0 +1,83 @@
+package task
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"godex/pkg/logger"
+	"godex/pkg/metrics"
+	"sync"
+	"time"
+)
+
+// 并发策略，同一任务函数上次执行未完成时的处理方式，同一函数的多个任务(如@once与定时任务)共用
+const (
+	ConcurrencySkip  = "skip"  // 跳过本次，记录为skipped
+	ConcurrencyQueue = "queue" // 等待上次执行完成后执行
+	ConcurrencyAllow = "allow" // 允许并发执行
+)
+
+// validateConcurrency 校验并发策略，为空时使用skip
+func validateConcurrency(concurrency string) error {
+	switch concurrency {
+	case "", ConcurrencySkip, ConcurrencyQueue, ConcurrencyAllow:
+		return nil
+	default:
+		return fmt.Errorf("unsupported concurrency %q, supported: %s, %s, %s", concurrency, ConcurrencySkip, ConcurrencyQueue, ConcurrencyAllow)
+	}
+}
+
+// newJob 创建任务，按并发策略包装
+func (ts *TaskScheduler) newJob(taskConfig TaskConfig, taskFunc TaskFunc) cron.Job {
+	job := cron.FuncJob(func() {
+		_ = ts.runTask(taskConfig, taskFunc)
+	})
+
+	var wrappers []cron.JobWrapper
+	switch taskConfig.Concurrency {
+	case "", ConcurrencySkip:
+		wrappers = append(wrappers, ts.skipIfRunning(taskConfig, ts.lock(taskConfig.Function)))
+	case ConcurrencyQueue:
+		wrappers = append(wrappers, queueIfRunning(ts.lock(taskConfig.Function)))
+	}
+	return cron.NewChain(wrappers...).Then(job)
+}
+
+// lock 任务函数的执行锁
+func (ts *TaskScheduler) lock(function string) *sync.Mutex {
+	mu, ok := ts.locks[function]
+	if !ok {
+		mu = &sync.Mutex{}
+		ts.locks[function] = mu
+	}
+	return mu
+}
+
+// skipIfRunning 任务函数正在执行时跳过本次，记录并输出日志
+func (ts *TaskScheduler) skipIfRunning(taskConfig TaskConfig, mu *sync.Mutex) cron.JobWrapper {
+	return func(job cron.Job) cron.Job {
+		return cron.FuncJob(func() {
+			if !mu.TryLock() {
+				logger.Warnf("Task '%s' skipped: function '%s' is still running", taskConfig.Name, taskConfig.Function)
+				metrics.ObserveTaskSkipped(taskConfig.Name)
+				ts.record(Run{
+					Task: taskConfig.Name, Function: taskConfig.Function, Trigger: triggerOf(taskConfig),
+					Start: time.Now(), Result: ResultSkipped, Error: "previous run still in progress",
+				})
+				return
+			}
+			defer mu.Unlock()
+			job.Run()
+		})
+	}
+}
+
+// queueIfRunning 任务函数正在执行时等待其完成后执行
+func queueIfRunning(mu *sync.Mutex) cron.JobWrapper {
+	return func(job cron.Job) cron.Job {
+		return cron.FuncJob(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			job.Run()
+		})
+	}
+}
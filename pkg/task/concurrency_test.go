@@ -0,0 +1,191 @@
+package task
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyPolicies(t *testing.T) {
+	ts := NewTaskScheduler()
+	release := make(chan struct{})
+	var running, maxRunning atomic.Int32
+	slow := func(ctx context.Context) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-release
+		return nil
+	}
+
+	// @once与定时任务使用同一任务函数，按函数互斥
+	once := ts.newJob(TaskConfig{Name: "once", Cron: scheduleOnce, Function: "slow"}, slow)
+	skip := ts.newJob(TaskConfig{Name: "skip", Cron: "@every 1s", Function: "slow"}, slow)
+	queue := ts.newJob(TaskConfig{Name: "queue", Cron: "@every 1s", Function: "slow", Concurrency: ConcurrencyQueue}, slow)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		once.Run()
+	}()
+	for running.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	skip.Run()
+	if last, _ := ts.History().Last("skip"); last.Result != ResultSkipped {
+		t.Fatalf("skip run = %+v", last)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		queue.Run()
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := ts.History().Last("queue"); ok {
+		t.Fatal("queued run started while function is running")
+	}
+	close(release)
+	wg.Wait()
+	if last, _ := ts.History().Last("queue"); last.Result != ResultSuccess || maxRunning.Load() != 1 {
+		t.Fatalf("queue run = %+v, max running = %d", last, maxRunning.Load())
+	}
+}
+
+func TestTaskTimeout(t *testing.T) {
+	ts := NewTaskScheduler()
+	release := make(chan struct{})
+	var running, maxRunning atomic.Int32
+	// 不接收ctx的任务函数，超时后仍继续执行
+	slow := WrapSimple(func() error {
+		if n := running.Add(1); n > maxRunning.Load() {
+			maxRunning.Store(n)
+		}
+		defer running.Add(-1)
+		<-release
+		return nil
+	})
+	job := ts.newJob(TaskConfig{Name: "slow", Function: "slow", Cron: "@every 1s", Timeout: 1}, slow)
+	next := ts.newJob(TaskConfig{Name: "next", Function: "slow", Cron: "@every 1s"}, slow)
+
+	done := make(chan struct{})
+	go func() {
+		job.Run()
+		close(done)
+	}()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := ts.History().Last("slow"); ok {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if last, ok := ts.History().Last("slow"); !ok || last.Result != ResultTimeout {
+		t.Fatalf("run = %+v, recorded = %v", last, ok)
+	}
+
+	// 超时后任务函数仍在执行，不释放执行锁，下一次触发记录为skipped
+	next.Run()
+	if last, _ := ts.History().Last("next"); last.Result != ResultSkipped {
+		t.Fatalf("next run = %+v", last)
+	}
+	select {
+	case <-done:
+		t.Fatal("job returned before the task function finished")
+	default:
+	}
+
+	close(release)
+	<-done
+	if maxRunning.Load() != 1 {
+		t.Fatalf("max running = %d, want 1", maxRunning.Load())
+	}
+	if err := ts.StopAndWait(context.Background()); err != nil {
+		t.Fatalf("StopAndWait: %v", err)
+	}
+	if runs := ts.History().Runs("slow", 0); len(runs) != 1 {
+		t.Fatalf("runs = %+v", runs)
+	}
+}
+
+func TestStopAndWait(t *testing.T) {
+	ts := NewTaskScheduler()
+	started := make(chan struct{})
+	var canceled atomic.Bool
+	ts.registry["wait"] = func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		canceled.Store(true)
+		return ctx.Err()
+	}
+	ts.registry["never"] = WrapSimple(func() error {
+		t.Error("@once task ran after stop")
+		return nil
+	})
+	err := ts.LoadTasksFromConfig([]TaskConfig{
+		{Name: "wait", Enable: true, Cron: "@every 1s", Function: "wait"},
+		{Name: "never", Enable: true, Cron: scheduleOnce, Function: "never"},
+	})
+	if err != nil {
+		t.Fatalf("LoadTasksFromConfig: %v", err)
+	}
+	_ = ts.Start()
+
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("task not started")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err = ts.StopAndWait(ctx); err != nil {
+		t.Fatalf("StopAndWait: %v", err)
+	}
+	// 停止时取消的任务按正常结束记录
+	if !canceled.Load() {
+		t.Fatal("StopAndWait returned before the running task finished")
+	}
+	if last, _ := ts.History().Last("wait"); last.Result != ResultFailure || last.Error != context.Canceled.Error() {
+		t.Fatalf("run = %+v", last)
+	}
+	if _, ok := ts.History().Last("never"); ok {
+		t.Fatal("@once task recorded after stop")
+	}
+}
+
+func TestStopAndWaitTimeout(t *testing.T) {
+	ts := NewTaskScheduler()
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	// 不接收ctx的任务无法取消，StopAndWait等待至ctx结束
+	ts.registry["stuck"] = WrapSimple(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	if err := ts.LoadTasksFromConfig([]TaskConfig{{Name: "stuck", Enable: true, Cron: "@every 1s", Function: "stuck"}}); err != nil {
+		t.Fatalf("LoadTasksFromConfig: %v", err)
+	}
+	_ = ts.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := ts.StopAndWait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("StopAndWait = %v", err)
+	}
+
+	// 已停止的调度器不再执行排队的任务
+	if err := ts.runTask(TaskConfig{Name: "late", Function: "late"}, WrapSimple(func() error { return nil })); err != context.Canceled {
+		t.Fatalf("runTask after stop = %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultHistorySize 默认保留的执行记录数
+const defaultHistorySize = 200
+
+// 触发方式
+const (
+	TriggerCron = "cron" // 按cron表达式触发
+	TriggerOnce = "once" // @once任务启动后执行一次
+)
+
+// 执行结果
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultSkipped = "skipped" // 上次执行未完成，按并发策略跳过
+	ResultTimeout = "timeout" // 执行超时
+)
+
+// HistoryConfig 任务执行记录配置
+type HistoryConfig struct {
+	Size int    `yaml:"size" json:"size"` // 最多保留的执行记录数(所有任务合计)，默认200
+	File string `yaml:"file" json:"file"` // 持久化文件，每次执行后覆盖写入，启动时读取；为空时仅保存在内存
+}
+
+// Run 一次任务执行记录
+type Run struct {
+	Task     string    `json:"task"`
+	Function string    `json:"function"`
+	Trigger  string    `json:"trigger"`
+	Start    time.Time `json:"start"`
+	Duration int64     `json:"duration_ms"` // 耗时(毫秒)
+	Result   string    `json:"result"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// History 有界的任务执行记录，超过上限时丢弃最早的记录，线程安全
+type History struct {
+	size int
+	file string
+
+	mu   sync.Mutex
+	runs []Run // 按开始时间从早到晚
+}
+
+// NewHistory 创建执行记录，配置了持久化文件时读取已保存的记录
+func NewHistory(config HistoryConfig) (*History, error) {
+	if config.Size <= 0 {
+		config.Size = defaultHistorySize
+	}
+	h := &History{size: config.Size, file: config.File}
+	if h.file == "" {
+		return h, nil
+	}
+	runs, err := ReadHistoryFile(h.file)
+	if err != nil {
+		return h, err
+	}
+	h.runs = runs[max(0, len(runs)-h.size):]
+	return h, nil
+}
+
+// Add 追加执行记录，配置了持久化文件时写入文件
+func (h *History) Add(run Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.runs = append(h.runs, run)
+	if len(h.runs) > h.size {
+		h.runs = append(h.runs[:0:0], h.runs[len(h.runs)-h.size:]...)
+	}
+	if h.file == "" {
+		return nil
+	}
+	return h.save()
+}
+
+// Runs 按开始时间从晚到早返回执行记录，task为空时返回所有任务，limit<=0时不限制条数
+func (h *History) Runs(task string, limit int) []Run {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	runs := []Run{}
+	for i := len(h.runs) - 1; i >= 0; i-- {
+		if task != "" && h.runs[i].Task != task {
+			continue
+		}
+		runs = append(runs, h.runs[i])
+		if limit > 0 && len(runs) >= limit {
+			break
+		}
+	}
+	return runs
+}
+
+// Last 任务最近一次执行记录
+func (h *History) Last(task string) (Run, bool) {
+	runs := h.Runs(task, 1)
+	if len(runs) == 0 {
+		return Run{}, false
+	}
+	return runs[0], true
+}
+
+// save 写入临时文件后重命名，避免进程退出时留下不完整的文件，调用方需持有锁
+func (h *History) save() error {
+	data, err := json.Marshal(h.runs)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(h.file), 0755); err != nil {
+		return err
+	}
+	tmp := h.file + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.file)
+}
+
+// ReadHistoryFile 读取持久化的执行记录，文件不存在时返回空
+func ReadHistoryFile(file string) ([]Run, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var runs []Run
+	if err = json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("invalid task history file %s: %v", file, err)
+	}
+	return runs, nil
+}
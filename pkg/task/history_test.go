@@ -0,0 +1,75 @@
+package task
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history", "tasks.json")
+	h, err := NewHistory(HistoryConfig{Size: 3, File: file})
+	if err != nil {
+		t.Fatalf("NewHistory: %v", err)
+	}
+	t0 := time.Unix(1700000000, 0)
+	for i, name := range []string{"a", "b", "a", "b", "a"} {
+		if err = h.Add(Run{Task: name, Start: t0.Add(time.Duration(i) * time.Second), Result: ResultSuccess}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if runs := h.Runs("", 0); len(runs) != 3 || !runs[0].Start.Equal(t0.Add(4*time.Second)) {
+		t.Fatalf("history not bounded: %+v", runs)
+	}
+	if runs := h.Runs("b", 0); len(runs) != 1 {
+		t.Fatalf("runs of b = %d", len(runs))
+	}
+
+	// 重新创建时读取持久化文件
+	h, err = NewHistory(HistoryConfig{Size: 2, File: file})
+	if err != nil {
+		t.Fatalf("NewHistory: %v", err)
+	}
+	if last, ok := h.Last("a"); !ok || !last.Start.Equal(t0.Add(4*time.Second)) || len(h.Runs("", 0)) != 2 {
+		t.Fatalf("persisted history not loaded: %+v", h.Runs("", 0))
+	}
+}
+
+func TestSchedulerStatus(t *testing.T) {
+	ts := NewTaskScheduler()
+	ts.registry["failing"] = WrapSimple(func() error { return errors.New("boom") })
+	err := ts.LoadTasksFromConfig([]TaskConfig{
+		{Name: "every", Enable: true, Cron: "@every 1s", Function: "failing"},
+		{Name: "disabled", Cron: "0 0 * * * *", Function: "failing"},
+		{Name: "missing", Cron: "0 0 * * * *", Function: "missing"},
+	})
+	if err != nil {
+		t.Fatalf("LoadTasksFromConfig: %v", err)
+	}
+	_ = ts.Start()
+	defer ts.Stop()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(ts.History().Runs("every", 0)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	statuses := ts.Status()
+	if len(statuses) != 3 {
+		t.Fatalf("statuses = %d", len(statuses))
+	}
+	every := statuses[0]
+	if every.LastRun == nil || every.LastRun.Result != ResultFailure || every.LastRun.Error != "boom" || every.LastRun.Trigger != TriggerCron {
+		t.Fatalf("last run not recorded: %+v", every.LastRun)
+	}
+	if every.NextRun == nil || !every.NextRun.After(time.Now().Add(-time.Second)) {
+		t.Fatalf("next run = %v", every.NextRun)
+	}
+	if statuses[1].NextRun != nil || statuses[1].Error != "" {
+		t.Fatalf("disabled task: %+v", statuses[1])
+	}
+	if statuses[2].Error == "" {
+		t.Fatalf("missing function not reported: %+v", statuses[2])
+	}
+}
@@ -1,22 +1,46 @@
 package task
 
+import "context"
+
 // TaskSchedulerInterface 任务调度器接口
 type TaskSchedulerInterface interface {
 	// RegisterTask 注册任务函数
 	RegisterTask(taskFunc TaskFunc)
 
+	// RegisterSimpleTask 注册不接收ctx的任务函数
+	RegisterSimpleTask(taskFunc SimpleTaskFunc)
+
 	// Start 启动任务调度器
 	Start() error
 
-	// Stop 停止任务调度器
+	// Stop 停止任务调度器并取消执行中任务的ctx，不等待其结束
 	Stop()
 
+	// StopAndWait 停止任务调度器并等待执行中的任务结束，ctx结束时返回其错误
+	StopAndWait(ctx context.Context) error
+
 	// GetAvailableTaskNames 获取所有可用的任务名称
 	GetAvailableTaskNames() []string
+
+	// Status 获取已配置任务的最近一次执行及下次执行时间
+	Status() []TaskStatus
+
+	// History 获取任务执行记录
+	History() *History
 }
 
-// TaskFunc 任务函数类型
-type TaskFunc func() error
+// TaskFunc 任务函数类型，ctx在超时或调度器停止时取消
+type TaskFunc func(ctx context.Context) error
+
+// SimpleTaskFunc 不接收ctx的任务函数，无法被取消，通过WrapSimple适配为TaskFunc
+type SimpleTaskFunc func() error
+
+// WrapSimple 将SimpleTaskFunc适配为TaskFunc，忽略ctx
+func WrapSimple(taskFunc SimpleTaskFunc) TaskFunc {
+	return func(ctx context.Context) error {
+		return taskFunc()
+	}
+}
 
 // 确保TaskScheduler实现了TaskSchedulerInterface接口
 var _ TaskSchedulerInterface = (*TaskScheduler)(nil)
@@ -1,13 +1,18 @@
 package task
 
 import (
+	"context"
+	stderrors "errors"
+	"fmt"
 	"github.com/robfig/cron/v3"
 	"godex/internal/errors"
 	"godex/pkg/errs"
 	"godex/pkg/logger"
+	"godex/pkg/metrics"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,6 +24,8 @@ type TaskConfig struct {
 	Cron        string `yaml:"cron" json:"cron"`
 	Function    string `yaml:"function" json:"function"`
 	Description string `yaml:"description" json:"description"`
+	Concurrency string `yaml:"concurrency" json:"concurrency"` // 同一任务函数上次执行未完成时: skip(默认)跳过、queue排队等待或allow并发执行
+	Timeout     int    `yaml:"timeout" json:"timeout"`         // 执行超时(秒)，超时记录为timeout，0为不限制
 }
 
 // TaskRegistry 任务注册表
@@ -28,14 +35,38 @@ type TaskRegistry map[string]TaskFunc
 type TaskScheduler struct {
 	cron     *cron.Cron
 	registry TaskRegistry
+	configs  []TaskConfig
+	history  *History
+	locks    map[string]*sync.Mutex // 按任务函数区分，同一函数的多个任务共用
+
+	ctx    context.Context // 所有任务ctx的父ctx，停止时取消
+	cancel context.CancelFunc
+	once   sync.WaitGroup // 执行中的@once任务
+}
+
+// Option 调度器选项
+type Option func(*TaskScheduler)
+
+// WithHistory 使用指定的执行记录，默认仅在内存中保留最近200条
+func WithHistory(history *History) Option {
+	return func(ts *TaskScheduler) {
+		ts.history = history
+	}
 }
 
 // NewTaskScheduler 创建新的任务调度器
-func NewTaskScheduler() *TaskScheduler {
-	return &TaskScheduler{
-		cron:     cron.New(cron.WithSeconds()),
+func NewTaskScheduler(options ...Option) *TaskScheduler {
+	ts := &TaskScheduler{
+		cron:     cron.New(cron.WithParser(scheduleParser)),
 		registry: make(TaskRegistry),
+		history:  &History{size: defaultHistorySize},
+		locks:    make(map[string]*sync.Mutex),
+	}
+	ts.ctx, ts.cancel = context.WithCancel(context.Background())
+	for _, option := range options {
+		option(ts)
 	}
+	return ts
 }
 
 // RegisterTask 注册任务函数，自动使用函数名作为任务名称
@@ -45,8 +76,15 @@ func (ts *TaskScheduler) RegisterTask(taskFunc TaskFunc) {
 	logger.Debugf("Registered task: %s", name)
 }
 
+// RegisterSimpleTask 注册不接收ctx的任务函数，自动使用函数名作为任务名称；超时及停止时无法中断
+func (ts *TaskScheduler) RegisterSimpleTask(taskFunc SimpleTaskFunc) {
+	name := getFunctionName(taskFunc)
+	ts.registry[name] = WrapSimple(taskFunc)
+	logger.Debugf("Registered task: %s", name)
+}
+
 // getFunctionName 获取函数名称
-func getFunctionName(taskFunc TaskFunc) string {
+func getFunctionName(taskFunc any) string {
 	funcPtr := runtime.FuncForPC(reflect.ValueOf(taskFunc).Pointer())
 	fullName := funcPtr.Name()
 
@@ -67,6 +105,22 @@ func (ts *TaskScheduler) GetAvailableTaskNames() []string {
 	return functions
 }
 
+// Status 按配置顺序返回每个任务的最近一次执行及下次执行时间
+func (ts *TaskScheduler) Status() []TaskStatus {
+	statuses := Statuses(ts.configs, ts.history, time.Now())
+	for i := range statuses {
+		if _, exists := ts.registry[statuses[i].Function]; !exists && statuses[i].Error == "" {
+			statuses[i].Error = "task function not found"
+		}
+	}
+	return statuses
+}
+
+// History 任务执行记录
+func (ts *TaskScheduler) History() *History {
+	return ts.history
+}
+
 // Start 启动任务调度器
 func (ts *TaskScheduler) Start() error {
 	// 输出所有可用的任务函数
@@ -78,14 +132,37 @@ func (ts *TaskScheduler) Start() error {
 	return nil
 }
 
-// Stop 停止任务调度器
+// Stop 停止任务调度器并取消执行中任务的ctx，不等待其结束
 func (ts *TaskScheduler) Stop() {
+	ts.cancel()
 	ts.cron.Stop()
 	logger.Info("Task scheduler stopped")
 }
 
+// StopAndWait 停止任务调度器，取消执行中任务的ctx并等待其结束(含@once任务)，ctx结束时返回其错误
+func (ts *TaskScheduler) StopAndWait(ctx context.Context) error {
+	ts.cancel()
+	cronDone := ts.cron.Stop()
+	done := make(chan struct{})
+	go func() {
+		<-cronDone.Done()
+		ts.once.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.Info("Task scheduler stopped, all running tasks finished")
+		return nil
+	case <-ctx.Done():
+		logger.Warnf("Task scheduler stopped, running tasks not finished: %v", ctx.Err())
+		return ctx.Err()
+	}
+}
+
 // LoadTasksFromConfig 从配置加载任务
 func (ts *TaskScheduler) LoadTasksFromConfig(taskConfigs []TaskConfig) error {
+	ts.configs = append(ts.configs, taskConfigs...)
 	for _, taskConfig := range taskConfigs {
 		if !taskConfig.Enable {
 			logger.Debugf("Task '%s' is disabled, skipping", taskConfig.Name)
@@ -100,37 +177,29 @@ func (ts *TaskScheduler) LoadTasksFromConfig(taskConfigs []TaskConfig) error {
 			continue
 		}
 
+		if err := validateConcurrency(taskConfig.Concurrency); err != nil {
+			return errs.Newf(errors.InternalError, "invalid task '%s': %v", taskConfig.Name, err)
+		}
+		job := ts.newJob(taskConfig, taskFunc)
+
 		// 处理 @once 类型的任务
-		if taskConfig.Cron == "@once" {
+		if taskConfig.Cron == scheduleOnce {
 			logger.Infof("🎉 Scheduling one-time task: %s", taskConfig.Name)
-
-			// 创建闭包，避免循环变量问题
-			taskName := taskConfig.Name
+			ts.once.Add(1)
 			go func() {
-				// 稍微延迟执行，确保系统完全启动
-				time.Sleep(5 * time.Second)
-				logger.Infof("Executing one-time task: %s", taskName)
-				if err := taskFunc(); err != nil {
-					logger.Errorf("One-time task '%s' execution failed: %v", taskName, err)
-				} else {
-					logger.Infof("One-time task '%s' executed successfully", taskName)
+				defer ts.once.Done()
+				// 稍微延迟执行，确保系统完全启动；期间停止调度器时不再执行
+				select {
+				case <-time.After(5 * time.Second):
+					job.Run()
+				case <-ts.ctx.Done():
+					logger.Infof("One-time task '%s' canceled: scheduler stopped", taskConfig.Name)
 				}
 			}()
 			continue
 		}
 
-		// 创建闭包，避免循环变量问题
-		taskName := taskConfig.Name
-		_, err := ts.cron.AddFunc(taskConfig.Cron, func() {
-			logger.Infof("Executing task: %s", taskName)
-			if err := taskFunc(); err != nil {
-				logger.Errorf("Task '%s' execution failed: %v", taskName, err)
-			} else {
-				logger.Infof("Task '%s' executed successfully", taskName)
-			}
-		})
-
-		if err != nil {
+		if _, err := ts.cron.AddJob(taskConfig.Cron, job); err != nil {
 			return errs.Newf(errors.InternalError, "failed to add cron job for task '%s': %v", taskConfig.Name, err)
 		}
 
@@ -140,3 +209,81 @@ func (ts *TaskScheduler) LoadTasksFromConfig(taskConfigs []TaskConfig) error {
 
 	return nil
 }
+
+// runTask 执行任务，记录执行次数、耗时及失败次数并写入执行记录
+// 任务ctx在超时或调度器停止时取消；超时即记录为timeout，但直到任务函数返回才返回(释放执行锁)，避免同一函数重叠执行
+func (ts *TaskScheduler) runTask(taskConfig TaskConfig, taskFunc TaskFunc) error {
+	taskName := taskConfig.Name
+	if err := ts.ctx.Err(); err != nil {
+		// 排队等待期间调度器已停止
+		logger.Infof("Task '%s' canceled: scheduler stopped", taskName)
+		return err
+	}
+
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if taskConfig.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ts.ctx, time.Duration(taskConfig.Timeout)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(ts.ctx)
+	}
+	defer cancel()
+
+	start := time.Now()
+	run := Run{Task: taskName, Function: taskConfig.Function, Trigger: triggerOf(taskConfig), Start: start}
+	logger.Infof("Executing task: %s (%s)", taskName, run.Trigger)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- taskFunc(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		if !stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// 调度器停止，等待任务函数响应取消
+			err = <-done
+			break
+		}
+		err = fmt.Errorf("task timed out after %ds", taskConfig.Timeout)
+		run.Duration, run.Result, run.Error = time.Since(start).Milliseconds(), ResultTimeout, err.Error()
+		logger.Errorf("Task '%s' execution failed: %v", taskName, err)
+		ts.record(run)
+		metrics.ObserveTaskResult(taskName, metrics.ResultTimeout, start)
+
+		// 未响应取消的任务函数结束前不释放执行锁
+		lateErr := <-done
+		logger.Warnf("Task '%s' finished %s after timing out, error: %v", taskName, time.Since(start).Round(time.Millisecond), lateErr)
+		return err
+	}
+
+	metrics.ObserveTask(taskName, start, err)
+	run.Duration, run.Result = time.Since(start).Milliseconds(), ResultSuccess
+	if err != nil {
+		run.Result, run.Error = ResultFailure, err.Error()
+		logger.Errorf("Task '%s' execution failed: %v", taskName, err)
+	} else {
+		logger.Infof("Task '%s' executed successfully", taskName)
+	}
+	ts.record(run)
+	return err
+}
+
+// record 写入执行记录
+func (ts *TaskScheduler) record(run Run) {
+	if err := ts.history.Add(run); err != nil {
+		logger.Warnf("Failed to save task history: %v", err)
+	}
+}
+
+// triggerOf 任务的触发方式
+func triggerOf(taskConfig TaskConfig) string {
+	if taskConfig.Cron == scheduleOnce {
+		return TriggerOnce
+	}
+	return TriggerCron
+}
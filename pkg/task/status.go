@@ -0,0 +1,51 @@
+package task
+
+import (
+	"github.com/robfig/cron/v3"
+	"time"
+)
+
+// scheduleOnce 启动后执行一次的任务
+const scheduleOnce = "@once"
+
+// scheduleParser cron表达式解析器，支持秒字段及@every等描述符，与调度器一致
+var scheduleParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// TaskStatus 任务状态
+type TaskStatus struct {
+	Name        string     `json:"name"`
+	Function    string     `json:"function"`
+	Cron        string     `json:"cron"`
+	Description string     `json:"description"`
+	Enable      bool       `json:"enable"`
+	LastRun     *Run       `json:"last_run,omitempty"`
+	NextRun     *time.Time `json:"next_run,omitempty"` // 下次执行时间，未启用、@once任务及表达式无效时为空
+	Error       string     `json:"error,omitempty"`    // 配置错误，如cron表达式无效或任务函数未注册
+}
+
+// Statuses 按配置顺序返回每个任务的最近一次执行及下次执行时间，history可为nil
+func Statuses(configs []TaskConfig, history *History, now time.Time) []TaskStatus {
+	statuses := make([]TaskStatus, 0, len(configs))
+	for _, config := range configs {
+		status := TaskStatus{
+			Name: config.Name, Function: config.Function, Cron: config.Cron,
+			Description: config.Description, Enable: config.Enable,
+		}
+		if history != nil {
+			if run, ok := history.Last(config.Name); ok {
+				status.LastRun = &run
+			}
+		}
+		if config.Cron != scheduleOnce {
+			schedule, err := scheduleParser.Parse(config.Cron)
+			if err != nil {
+				status.Error = err.Error()
+			} else if config.Enable {
+				next := schedule.Next(now)
+				status.NextRun = &next
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
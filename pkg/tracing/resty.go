@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/url"
+)
+
+// HeaderRequestID 出站请求携带的请求ID，与服务端requestid中间件使用的请求头一致
+const HeaderRequestID = "X-Request-Id"
+
+// InstrumentResty 为resty客户端的每个请求创建client span，并写入trace-context、baggage及请求ID请求头
+// target为下游名称，用于span名称；请求需通过SetContext传入上游ctx才能与调用方的span关联
+func InstrumentResty(client *resty.Client, target string) *resty.Client {
+	client.OnBeforeRequest(func(c *resty.Client, r *resty.Request) error {
+		ctx, _ := Tracer().Start(r.Context(), target+" "+r.Method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLFull(redactURL(r.URL)),
+				attribute.String("peer.service", target),
+			))
+		Inject(ctx, propagation.HeaderCarrier(r.Header))
+		if requestID := RequestID(ctx); requestID != "" && r.Header.Get(HeaderRequestID) == "" {
+			r.Header.Set(HeaderRequestID, requestID)
+		}
+		r.SetContext(ctx)
+		return nil
+	})
+	client.OnAfterResponse(func(c *resty.Client, resp *resty.Response) error {
+		span := trace.SpanFromContext(resp.Request.Context())
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode()))
+		if resp.StatusCode() >= http.StatusBadRequest {
+			span.SetStatus(codes.Error, resp.Status())
+		}
+		span.End()
+		return nil
+	})
+	client.OnError(func(r *resty.Request, err error) {
+		span := trace.SpanFromContext(r.Context())
+		if responseErr, ok := err.(*resty.ResponseError); ok && responseErr.Response != nil {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(responseErr.Response.StatusCode()))
+		}
+		End(span, err)
+	})
+	return client
+}
+
+// redactURL 去除URL中的query及用户信息，避免签名等敏感参数写入span
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	u.User, u.RawQuery, u.Fragment = nil, "", ""
+	return u.String()
+}
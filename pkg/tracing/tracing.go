@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"strings"
+)
+
+// 导出器类型
+const (
+	ExporterStdout = "stdout" // 输出到标准错误(不混入命令模式的输出)，用于本地调试
+	ExporterOTLP   = "otlp"   // OTLP/HTTP，如OpenTelemetry Collector、Jaeger、Tempo
+)
+
+// TracerName 本服务的Tracer名称
+const TracerName = "godex"
+
+// requestIDBaggageKey 请求ID在W3C Baggage中的键，随链路传递到下游
+const requestIDBaggageKey = "request.id"
+
+// Config 链路追踪配置
+type Config struct {
+	Enable      bool              `yaml:"enable" json:"enable"`
+	Exporter    string            `yaml:"exporter" json:"exporter"`         // stdout或otlp，默认stdout
+	Endpoint    string            `yaml:"endpoint" json:"endpoint"`         // OTLP/HTTP地址(host:port)，默认localhost:4318
+	Insecure    bool              `yaml:"insecure" json:"insecure"`         // OTLP是否使用HTTP而非HTTPS
+	Headers     map[string]string `yaml:"headers" json:"headers"`           // OTLP请求头，如鉴权
+	SampleRatio float64           `yaml:"sample-ratio" json:"sample-ratio"` // 根span采样比例(0~1]，默认1；上游已采样的请求始终采样
+}
+
+// Init 初始化全局TracerProvider及W3C trace-context/baggage传播器，返回退出时刷新并关闭导出器的函数
+// 未启用时只设置传播器，span不记录但仍透传上游的trace-context
+func Init(config Config, serviceName string) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !config.Enable {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(config)
+	if err != nil {
+		return nil, err
+	}
+	if serviceName == "" {
+		serviceName = TracerName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("create tracing resource failed: %v", err)
+	}
+	ratio := config.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// newExporter 按配置创建导出器
+func newExporter(config Config) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(config.Exporter) {
+	case "", ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case ExporterOTLP:
+		options := []otlptracehttp.Option{}
+		if config.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(config.Endpoint))
+		}
+		if config.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		if len(config.Headers) > 0 {
+			options = append(options, otlptracehttp.WithHeaders(config.Headers))
+		}
+		return otlptracehttp.New(context.Background(), options...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q, supported: %s, %s", config.Exporter, ExporterStdout, ExporterOTLP)
+	}
+}
+
+// Tracer 本服务的Tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Start 开始一个内部span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束span，err不为nil时记录错误并将状态置为Error
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract 从请求头中提取上游的trace-context及baggage
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// Inject 将ctx中的trace-context及baggage写入请求头
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// WithRequestID 将请求ID放入baggage，随出站请求传递给下游
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	member, err := baggage.NewMemberRaw(requestIDBaggageKey, requestID)
+	if err != nil {
+		return ctx
+	}
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// RequestID 获取baggage中的请求ID
+func RequestID(ctx context.Context) string {
+	return baggage.FromContext(ctx).Member(requestIDBaggageKey).Value()
+}
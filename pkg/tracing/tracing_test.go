@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrumentResty(t *testing.T) {
+	if _, err := Init(Config{}, "test"); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	ctx, parent := Start(WithRequestID(context.Background(), "req-1"), "parent")
+	client := InstrumentResty(resty.New(), "downstream")
+	if _, err := client.R().SetContext(ctx).Get(server.URL + "/path?token=secret"); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	parent.End()
+
+	traceID := parent.SpanContext().TraceID().String()
+	if !strings.Contains(header.Get("traceparent"), traceID) {
+		t.Fatalf("traceparent %q does not carry trace %s", header.Get("traceparent"), traceID)
+	}
+	if header.Get(HeaderRequestID) != "req-1" {
+		t.Fatalf("request id = %q, want req-1", header.Get(HeaderRequestID))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "downstream GET" || spans[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+	for _, attr := range spans[0].Attributes() {
+		if strings.Contains(attr.Value.Emit(), "secret") {
+			t.Fatalf("attribute %s leaks query", attr.Key)
+		}
+	}
+}
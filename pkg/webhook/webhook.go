@@ -0,0 +1,319 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"godex/pkg/logger"
+	"godex/pkg/metrics"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 默认配置
+const (
+	defaultTimeout      = 5000
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 1000
+	defaultQueueSize    = 1000
+	defaultWorkers      = 2
+	maxRetryBackoff     = 60 * time.Second
+)
+
+// 请求头
+const (
+	HeaderEvent     = "X-Godex-Event"     // 事件类型
+	HeaderDelivery  = "X-Godex-Delivery"  // 事件ID，重试时不变，接收方可据此去重
+	HeaderTimestamp = "X-Godex-Timestamp" // 签名时间(Unix秒)
+	HeaderSignature = "X-Godex-Signature" // 签名: sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+)
+
+// Config Webhook配置
+type Config struct {
+	Enable         bool             `yaml:"enable" json:"enable"`
+	Endpoints      []EndpointConfig `yaml:"endpoints" json:"endpoints"`
+	Timeout        int              `yaml:"timeout" json:"timeout"`                   // 单次投递超时(毫秒)，默认5000
+	MaxRetries     int              `yaml:"max-retries" json:"max-retries"`           // 失败后最多重试次数，默认3，小于0时不重试
+	RetryBackoff   int              `yaml:"retry-backoff" json:"retry-backoff"`       // 首次重试间隔(毫秒)，之后每次翻倍，默认1000
+	QueueSize      int              `yaml:"queue-size" json:"queue-size"`             // 待投递队列长度，队列已满时直接写入死信日志，默认1000
+	Workers        int              `yaml:"workers" json:"workers"`                   // 并发投递个数，默认2
+	DeadLetterFile string           `yaml:"dead-letter-file" json:"dead-letter-file"` // 死信日志文件(JSON Lines)，为空时仅记录错误日志
+}
+
+// EndpointConfig 接收端配置
+type EndpointConfig struct {
+	Name   string   `yaml:"name" json:"name"`
+	URL    string   `yaml:"url" json:"url"`
+	Secret string   `yaml:"secret" json:"secret"` // 签名密钥，为空时不签名
+	Events []string `yaml:"events" json:"events"` // 订阅的事件类型，为空或包含"*"时订阅全部事件
+}
+
+// Event 投递的事件
+type Event struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	CreatedAt int64  `json:"created_at"` // 事件产生时间(Unix毫秒)
+	Data      any    `json:"data"`
+}
+
+// DeadLetter 死信日志记录
+type DeadLetter struct {
+	Time     int64  `json:"time"` // 写入时间(Unix毫秒)
+	Endpoint string `json:"endpoint"`
+	URL      string `json:"url"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+	Event    Event  `json:"event"`
+}
+
+// Sign 计算签名
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名，供接收方使用，tolerance>0时同时校验签名时间与当前时间的偏差
+func Verify(secret string, timestamp int64, body []byte, signature string, tolerance time.Duration) bool {
+	if tolerance > 0 {
+		skew := time.Since(time.Unix(timestamp, 0))
+		if skew > tolerance || skew < -tolerance {
+			return false
+		}
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Subscribed 接收端是否订阅了该事件类型
+func (e EndpointConfig) Subscribed(eventType string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, event := range e.Events {
+		if event == "*" || event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// delivery 待投递的任务
+type delivery struct {
+	endpoint EndpointConfig
+	event    Event
+	body     []byte
+}
+
+// Dispatcher 异步投递事件到订阅的接收端，失败按指数退避重试，最终失败写入死信日志
+type Dispatcher struct {
+	config Config
+	client *resty.Client
+	queue  chan delivery
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex // 保护closed及向queue发送
+	closed bool
+
+	deadMu sync.Mutex // 串行化死信日志写入
+}
+
+// NewDispatcher 创建投递器并启动投递协程
+func NewDispatcher(config Config) *Dispatcher {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = defaultMaxRetries
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaultRetryBackoff
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = defaultWorkers
+	}
+
+	d := &Dispatcher{
+		config: config,
+		client: resty.New().SetTimeout(time.Duration(config.Timeout) * time.Millisecond),
+		queue:  make(chan delivery, config.QueueSize),
+	}
+	for i := 0; i < config.Workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	return d
+}
+
+// Emit 产生事件并投递到所有订阅的接收端，不阻塞
+func (d *Dispatcher) Emit(eventType string, data any) {
+	event := Event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		CreatedAt: time.Now().UnixMilli(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.Errorf("Failed to marshal webhook event %s: %v", eventType, err)
+		return
+	}
+
+	// 持有锁时只做非阻塞入队，未能入队的任务在释放锁后写入死信日志
+	var rejected []delivery
+	var cause error
+	d.mu.Lock()
+	for _, endpoint := range d.config.Endpoints {
+		if !endpoint.Subscribed(eventType) {
+			continue
+		}
+		task := delivery{endpoint: endpoint, event: event, body: body}
+		if d.closed {
+			rejected, cause = append(rejected, task), fmt.Errorf("dispatcher is closed")
+			continue
+		}
+		select {
+		case d.queue <- task:
+		default:
+			rejected, cause = append(rejected, task), fmt.Errorf("queue is full")
+		}
+	}
+	d.mu.Unlock()
+
+	for _, task := range rejected {
+		d.deadLetter(task, 0, cause)
+	}
+}
+
+// Close 停止接收新事件并等待队列中的事件投递完成，ctx结束时不再等待
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work 投递协程
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for task := range d.queue {
+		d.deliver(task)
+	}
+}
+
+// deliver 投递单个任务，网络错误、5xx及429时重试
+func (d *Dispatcher) deliver(task delivery) {
+	backoff := time.Duration(d.config.RetryBackoff) * time.Millisecond
+	var err error
+	attempts := 0
+	for attempts <= d.config.MaxRetries {
+		if attempts > 0 {
+			time.Sleep(backoff)
+			backoff = min(backoff*2, maxRetryBackoff)
+		}
+		attempts++
+
+		var retryable bool
+		if retryable, err = d.post(task); err == nil {
+			return
+		}
+		logger.Warnf("Webhook %s delivery %s attempt %d failed: %v", task.endpoint.Name, task.event.ID, attempts, err)
+		if !retryable {
+			break
+		}
+	}
+
+	d.deadLetter(task, attempts, err)
+}
+
+// post 发送一次请求，返回失败时是否可重试
+func (d *Dispatcher) post(task delivery) (bool, error) {
+	request := d.client.R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader(HeaderEvent, task.event.Type).
+		SetHeader(HeaderDelivery, task.event.ID).
+		SetBody(task.body)
+	if task.endpoint.Secret != "" {
+		timestamp := time.Now().Unix()
+		request.SetHeader(HeaderTimestamp, strconv.FormatInt(timestamp, 10)).
+			SetHeader(HeaderSignature, Sign(task.endpoint.Secret, timestamp, task.body))
+	}
+
+	start := time.Now()
+	resp, err := request.Post(task.endpoint.URL)
+	if err != nil {
+		metrics.ObserveUpstream("webhook", "deliver", start, err)
+		return true, fmt.Errorf("request failed: %v", err)
+	}
+	metrics.ObserveHTTPUpstream("webhook", "deliver", start, resp.StatusCode())
+	if resp.StatusCode() >= 200 && resp.StatusCode() < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode() >= 500 || resp.StatusCode() == 429
+	return retryable, fmt.Errorf("HTTP error: status %d", resp.StatusCode())
+}
+
+// deadLetter 写入死信日志
+func (d *Dispatcher) deadLetter(task delivery, attempts int, cause error) {
+	logger.Errorf("Webhook %s delivery %s (%s) dead-lettered after %d attempts: %v", task.endpoint.Name, task.event.ID, task.event.Type, attempts, cause)
+	if d.config.DeadLetterFile == "" {
+		return
+	}
+
+	d.deadMu.Lock()
+	defer d.deadMu.Unlock()
+
+	line, err := json.Marshal(DeadLetter{
+		Time:     time.Now().UnixMilli(),
+		Endpoint: task.endpoint.Name,
+		URL:      task.endpoint.URL,
+		Attempts: attempts,
+		Error:    cause.Error(),
+		Event:    task.event,
+	})
+	if err != nil {
+		logger.Errorf("Failed to marshal webhook dead letter: %v", err)
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(d.config.DeadLetterFile), 0755); err != nil {
+		logger.Errorf("Failed to create webhook dead letter dir: %v", err)
+		return
+	}
+	file, err := os.OpenFile(d.config.DeadLetterFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logger.Errorf("Failed to open webhook dead letter file: %v", err)
+		return
+	}
+	defer file.Close()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		logger.Errorf("Failed to write webhook dead letter: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDispatcherRetryAndSign(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if !Verify("secret", timestamp, body, r.Header.Get(HeaderSignature), time.Minute) {
+			t.Errorf("invalid signature %q", r.Header.Get(HeaderSignature))
+		}
+		var event Event
+		_ = json.Unmarshal(body, &event)
+		if r.Header.Get(HeaderDelivery) != event.ID || r.Header.Get(HeaderEvent) != event.Type {
+			t.Errorf("headers = %v, event = %+v", r.Header, event)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(Config{
+		Endpoints: []EndpointConfig{
+			{Name: "soc", URL: server.URL, Secret: "secret", Events: []string{"check.hit"}},
+			{Name: "other", URL: server.URL, Events: []string{"sites.loaded"}},
+		},
+		RetryBackoff: 1,
+	})
+	d.Emit("check.hit", map[string]string{"domain": "evil.com"})
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if event := <-received; event.Type != "check.hit" {
+		t.Errorf("received %+v", event)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+}
+
+func TestDispatcherDeadLetter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "dead-letter.log")
+	d := NewDispatcher(Config{
+		Endpoints:      []EndpointConfig{{Name: "soc", URL: server.URL}},
+		RetryBackoff:   1,
+		DeadLetterFile: file,
+	})
+	d.Emit("sites.imported", map[string]int{"count": 1})
+	_ = d.Close(context.Background())
+	d.Emit("sites.imported", nil)
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("open dead letter: %v", err)
+	}
+	defer f.Close()
+	var letters []DeadLetter
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var letter DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			t.Fatalf("invalid dead letter line: %v", err)
+		}
+		letters = append(letters, letter)
+	}
+	// 4xx不重试；关闭后产生的事件直接写入死信
+	if len(letters) != 2 || letters[0].Attempts != 1 || letters[0].Endpoint != "soc" || letters[1].Attempts != 0 {
+		t.Errorf("dead letters = %+v", letters)
+	}
+}